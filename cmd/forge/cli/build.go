@@ -8,8 +8,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/waste3d/forge/cmd/forge/cli/helpers"
 	pb "github.com/waste3d/forge/internal/gen/proto"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 var buildCmd = &cobra.Command{
@@ -20,35 +18,41 @@ var buildCmd = &cobra.Command{
 }
 
 func init() {
+	buildCmd.Flags().BoolP("detach", "d", false, "Не ждать завершения: вывести идентификатор задания и вернуться")
 	rootCmd.AddCommand(buildCmd)
 }
 
 func runBuild(cmd *cobra.Command, args []string) {
 	servicesToBuild := args
+	detach, _ := cmd.Flags().GetBool("detach")
 
-	if err := runBuildLogic(servicesToBuild); err != nil {
+	detached, err := runBuildLogic(cmd.Context(), servicesToBuild, detach)
+	if err != nil {
 		errorLog(os.Stderr, "\n❌ Ошибка выполнения 'build': %v\n", err)
 		os.Exit(1)
 	}
 
-	successLog("\n✅ Команда 'build' успешно завершена.\n")
+	if !detached {
+		successLog("\n✅ Команда 'build' успешно завершена.\n")
+	}
 }
 
-func runBuildLogic(servicesToBuild []string) error {
+// runBuildLogic возвращает true, если CLI не дождался завершения задания
+func runBuildLogic(ctx context.Context, servicesToBuild []string, detach bool) (bool, error) {
 	if !isDaemonRunning() {
-		return fmt.Errorf("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+		return false, fmt.Errorf("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
 	}
 
 	infoLog("Чтение и обработка файла forge.yaml...\n")
 	configPath := "forge.yaml"
 	modifiedYamlContent, err := helpers.LoadAndPrepareConfig(configPath)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	conn, err := grpc.Dial(daemonAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := dialDaemon()
 	if err != nil {
-		return fmt.Errorf("не удалось подключиться к демону: %w", err)
+		return false, err
 	}
 	defer conn.Close()
 	client := pb.NewForgeClient(conn)
@@ -56,14 +60,26 @@ func runBuildLogic(servicesToBuild []string) error {
 	req := &pb.BuildRequest{
 		ConfigContent: string(modifiedYamlContent),
 		ServicesName:  servicesToBuild,
+		Detach:        detach,
 	}
 
 	infoLog("Отправляем Build-запрос демону...\n")
-	stream, err := client.Build(context.Background(), req)
-	if err != nil {
-		return fmt.Errorf("ошибка при вызове Build: %w", err)
+	open := func(ctx context.Context) (pb.Forge_UpClient, error) {
+		stream, err := client.Build(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при вызове Build: %w", err)
+		}
+		return stream, nil
+	}
+
+	if detach {
+		stream, err := open(ctx)
+		if err != nil {
+			return false, err
+		}
+		return true, printDetachedJob(stream)
 	}
 
 	infoLog("Ожидание логов сборки от демона...\n")
-	return PrintLogs(stream)
+	return followJob(ctx, open)
 }
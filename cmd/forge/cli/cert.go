@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/waste3d/forge/internal/transport"
+)
+
+// systemCertCmd - для настройки TLS между CLI и удаленным демоном
+var systemCertCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "Управление сертификатами для подключения к удаленному демону",
+}
+
+var systemCertGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Создает локальный CA, сертификаты сервера и клиента и токен доступа",
+	Long:  "Создает локальный CA, сертификат сервера для указанных хостов, клиентский сертификат для mTLS и токен доступа. Если CA уже существует в директории, он переиспользуется. Существующие сертификаты сервера и клиента перезаписываются только с флагом --force.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		hosts, _ := cmd.Flags().GetStringSlice("host")
+		force, _ := cmd.Flags().GetBool("force")
+
+		if err := runCertGenerateLogic(dir, hosts, force); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'cert generate': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	systemCertGenerateCmd.Flags().String("dir", "", "Директория для сертификатов (по умолчанию ~/.forge/certs)")
+	systemCertGenerateCmd.Flags().StringSlice("host", nil, "Имя или IP-адрес демона для сертификата сервера (по умолчанию localhost, 127.0.0.1 и имя этой машины)")
+	systemCertGenerateCmd.Flags().Bool("force", false, "Перезаписать существующие сертификаты сервера и клиента")
+
+	systemCertCmd.AddCommand(systemCertGenerateCmd)
+	systemCmd.AddCommand(systemCertCmd)
+}
+
+func runCertGenerateLogic(dir string, hosts []string, force bool) error {
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("не удалось определить домашнюю директорию: %w", err)
+		}
+		dir = filepath.Join(home, ".forge", "certs")
+	}
+
+	if len(hosts) == 0 {
+		hosts = []string{"localhost", "127.0.0.1"}
+		if hostname, err := os.Hostname(); err == nil && hostname != "localhost" {
+			hosts = append(hosts, hostname)
+		}
+	}
+
+	if err := transport.GenerateCertificates(dir, hosts, force); err != nil {
+		if errors.Is(err, transport.ErrCertificatesExist) {
+			return fmt.Errorf("%w. Чтобы выпустить их заново, повторите команду с флагом --force: текущие сертификаты и ключи будут заменены", err)
+		}
+		return err
+	}
+
+	successLog("✅ Сертификаты созданы в %s для хостов: %v\n\n", dir, hosts)
+	infoLog("На сервере запустите демон:\n")
+	fmt.Printf("  forged -addr 0.0.0.0:9001 -tls-cert %s -tls-key %s -tls-client-ca %s -token-file %s\n\n",
+		filepath.Join(dir, transport.ServerFile),
+		filepath.Join(dir, transport.ServerKeyFile),
+		filepath.Join(dir, transport.CAFile),
+		filepath.Join(dir, transport.TokenFile),
+	)
+	infoLog("Скопируйте на клиент %s, %s, %s и токен, затем подключайтесь:\n",
+		transport.CAFile, transport.ClientFile, transport.ClientKeyFile)
+	fmt.Printf("  forge ps --daemon-addr <хост>:9001 --tls-ca %s --tls-cert %s --tls-key %s --token <токен>\n",
+		transport.CAFile, transport.ClientFile, transport.ClientKeyFile)
+	return nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Работа с конфигурациями, примененными демоном",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show <appName>",
+	Short: "Печатает конфигурацию, примененную последним успешным 'forge up'",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runConfigShowLogic(cmd.Context(), args[0]); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'config show': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigShowLogic(ctx context.Context, appName string) error {
+	if !isDaemonRunning() {
+		return errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	resp, err := client.GetAppliedConfig(ctx, &pb.AppliedConfigRequest{AppName: appName})
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове GetAppliedConfig: %w", err)
+	}
+
+	run := resp.GetRun()
+	state := "остановлено"
+	if resp.GetRunning() {
+		state = "запущено"
+	}
+
+	// Метаданные печатаем в stderr, чтобы stdout можно было сохранить как forge.yaml
+	infoLog("# Запуск %s от %s (%s), пользователь '%s', директория '%s'. Приложение %s.\n",
+		shortID(run.GetId()),
+		time.Unix(run.GetStartedAt(), 0).Format(time.RFC3339),
+		shortID(run.GetConfigHash()),
+		run.GetClientUser(),
+		run.GetWorkingDir(),
+		state,
+	)
+	fmt.Print(resp.GetConfigContent())
+	return nil
+}
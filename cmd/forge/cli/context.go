@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/waste3d/forge/cmd/forge/cli/helpers"
+	"github.com/waste3d/forge/internal/transport"
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Управление контекстами: именованными подключениями к разным демонам",
+}
+
+var contextCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Создает контекст или обновляет существующий",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runContextCreateLogic(cmd, args[0]); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'context create': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Делает контекст текущим для всех последующих команд",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runContextUseLogic(args[0]); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'context use': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var contextLsCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "Показывает список контекстов",
+	Aliases: []string{"list"},
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runContextLsLogic(); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'context ls': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var contextRmCmd = &cobra.Command{
+	Use:     "rm <name>",
+	Short:   "Удаляет контекст",
+	Aliases: []string{"remove"},
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runContextRmLogic(args[0]); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'context rm': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	// Локальные --token и --tls-* перекрывают одноименные глобальные флаги:
+	// здесь они описывают сохраняемый контекст, а не подключение текущей команды
+	f := contextCreateCmd.Flags()
+	f.String("addr", "", "Адрес демона: unix:///путь/к/сокету или host:port")
+	f.String("description", "", "Описание контекста")
+	f.String("token", "", "Токен доступа к демону")
+	f.String("tls-ca", "", "CA-сертификат для проверки демона")
+	f.String("tls-cert", "", "Клиентский сертификат для mTLS")
+	f.String("tls-key", "", "Ключ клиентского сертификата")
+	f.String("tls-server-name", "", "Имя, по которому проверяется сертификат демона")
+	contextCreateCmd.MarkFlagRequired("addr")
+
+	contextCmd.AddCommand(contextCreateCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextLsCmd)
+	contextCmd.AddCommand(contextRmCmd)
+	rootCmd.AddCommand(contextCmd)
+}
+
+func runContextCreateLogic(cmd *cobra.Command, name string) error {
+	if name == helpers.DefaultContextName {
+		return fmt.Errorf("контекст '%s' встроенный и не может быть изменен", name)
+	}
+
+	f := cmd.Flags()
+	ctx := helpers.Context{Name: name}
+	ctx.Address, _ = f.GetString("addr")
+	ctx.Description, _ = f.GetString("description")
+	ctx.Token, _ = f.GetString("token")
+	ctx.TLSServerName, _ = f.GetString("tls-server-name")
+
+	if _, _, err := transport.Parse(ctx.Address); err != nil {
+		return err
+	}
+
+	// Пути к сертификатам сохраняем абсолютными: контекст используется из любой директории
+	for flag, dst := range map[string]*string{"tls-ca": &ctx.TLSCA, "tls-cert": &ctx.TLSCert, "tls-key": &ctx.TLSKey} {
+		path, _ := f.GetString(flag)
+		if path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		*dst = abs
+	}
+
+	cfg, err := helpers.LoadCLIConfig()
+	if err != nil {
+		return err
+	}
+	cfg.SetContext(ctx)
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	successLog("✅ Контекст '%s' сохранен. Переключитесь на него командой 'forge context use %s'.\n", name, name)
+	return nil
+}
+
+func runContextUseLogic(name string) error {
+	cfg, err := helpers.LoadCLIConfig()
+	if err != nil {
+		return err
+	}
+
+	if name == helpers.DefaultContextName {
+		cfg.CurrentContext = ""
+	} else {
+		if _, ok := cfg.Context(name); !ok {
+			return fmt.Errorf("контекст '%s' не найден. Список контекстов: 'forge context ls'", name)
+		}
+		cfg.CurrentContext = name
+	}
+
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	successLog("✅ Текущий контекст: %s\n", name)
+	return nil
+}
+
+func runContextLsLogic() error {
+	cfg, err := helpers.LoadCLIConfig()
+	if err != nil {
+		return err
+	}
+
+	current := cfg.CurrentContext
+	if current == "" {
+		current = helpers.DefaultContextName
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tADDRESS\tAUTH\tDESCRIPTION")
+
+	contexts := append([]helpers.Context{{
+		Name:        helpers.DefaultContextName,
+		Address:     transport.DefaultAddress(),
+		Description: "Локальный демон",
+	}}, cfg.Contexts...)
+
+	for _, ctx := range contexts {
+		name := ctx.Name
+		if name == current {
+			name += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, ctx.Address, contextAuth(ctx), ctx.Description)
+	}
+	return w.Flush()
+}
+
+func runContextRmLogic(name string) error {
+	if name == helpers.DefaultContextName {
+		return errors.New("контекст 'default' встроенный и не может быть удален")
+	}
+
+	cfg, err := helpers.LoadCLIConfig()
+	if err != nil {
+		return err
+	}
+	if !cfg.RemoveContext(name) {
+		return fmt.Errorf("контекст '%s' не найден", name)
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	successLog("✅ Контекст '%s' удален.\n", name)
+	return nil
+}
+
+// contextAuth кратко описывает способ аутентификации контекста для 'forge context ls'
+func contextAuth(ctx helpers.Context) string {
+	var auth string
+	switch {
+	case ctx.TLSCert != "":
+		auth = "mtls"
+	case ctx.TLSCA != "":
+		auth = "tls"
+	}
+	if ctx.Token != "" {
+		if auth != "" {
+			auth += "+"
+		}
+		auth += "token"
+	}
+	if auth == "" {
+		return "-"
+	}
+	return auth
+}
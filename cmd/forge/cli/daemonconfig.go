@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/transport"
+	ai "github.com/waste3d/forge/openai"
+	"gopkg.in/yaml.v3"
+)
+
+var systemConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Печатает действующие настройки демона (forged.yaml с учетом флагов)",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSystemConfigLogic(cmd.Context()); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'system config': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemConfigCmd)
+}
+
+func runSystemConfigLogic(ctx context.Context) error {
+	if !isDaemonRunning() {
+		return errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	resp, err := client.GetDaemonConfig(ctx, &pb.DaemonConfigRequest{})
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове GetDaemonConfig: %w", err)
+	}
+
+	// Пояснение печатаем в stderr, чтобы stdout можно было сохранить как forged.yaml
+	if resp.GetLoaded() {
+		fmt.Fprintf(os.Stderr, "# Файл настроек: %s\n", resp.GetPath())
+	} else {
+		fmt.Fprintf(os.Stderr, "# Файл %s не найден, действуют значения по умолчанию\n", resp.GetPath())
+	}
+	os.Stdout.Write(resp.GetContent())
+	return nil
+}
+
+// daemonAIConfig возвращает настройки ИИ из конфигурации демона. Если демон
+// недоступен, используются настройки по умолчанию.
+//
+// Секция ai решает, значение какой переменной окружения CLI отправит и по
+// какому адресу, поэтому ее принимают только от локального демона на
+// Unix-сокете: удаленный демон мог бы так получить любой секрет пользователя.
+func daemonAIConfig(ctx context.Context) ai.Config {
+	if network, _, err := transport.Parse(daemonAddress); err != nil || network != "unix" {
+		return ai.DefaultConfig()
+	}
+	if !isDaemonRunning() {
+		return ai.DefaultConfig()
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return ai.DefaultConfig()
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	resp, err := pb.NewForgeClient(conn).GetDaemonConfig(ctx, &pb.DaemonConfigRequest{})
+	if err != nil {
+		return ai.DefaultConfig()
+	}
+
+	// Из настроек демона CLI нужна только секция ai
+	settings := struct {
+		AI ai.Config `yaml:"ai"`
+	}{AI: ai.DefaultConfig()}
+	if err := yaml.Unmarshal(resp.GetContent(), &settings); err != nil || settings.AI.Validate() != nil {
+		return ai.DefaultConfig()
+	}
+	return settings.AI
+}
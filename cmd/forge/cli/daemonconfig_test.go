@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	ai "github.com/waste3d/forge/openai"
+)
+
+func TestDaemonAIConfigRemote(t *testing.T) {
+	oldAddr := daemonAddress
+	defer func() { daemonAddress = oldAddr }()
+
+	// Удаленный демон не выбирает, какой секрет и куда отправит CLI: к нему даже не подключаемся
+	daemonAddress = "tcp://198.51.100.1:9001"
+	if got := daemonAIConfig(context.Background()); got != ai.DefaultConfig() {
+		t.Errorf("daemonAIConfig() для удаленного демона = %+v, ожидались настройки по умолчанию", got)
+	}
+}
@@ -9,8 +9,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/waste3d/forge/cmd/forge/cli/helpers"
 	pb "github.com/waste3d/forge/internal/gen/proto"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 var downCmd = &cobra.Command{
@@ -53,9 +51,9 @@ func runDownLogic(appName string) error {
 		return errors.New("демон 'forged' не запущен. Невозможно выполнить команду 'down'. Запустите окружение с помощью 'forge boot'")
 	}
 
-	conn, err := grpc.Dial(daemonAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := dialDaemon()
 	if err != nil {
-		return fmt.Errorf("не удалось подключиться к демону: %w", err)
+		return err
 	}
 	defer conn.Close()
 	client := pb.NewForgeClient(conn)
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events [appName]",
+	Short: "Показывает события узлов в реальном времени: запуск, готовность, падения, пересборку и удаление",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appName := ""
+		if len(args) > 0 {
+			appName = args[0]
+		}
+		serviceName, _ := cmd.Flags().GetString("service")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		if serviceName != "" && appName == "" {
+			errorLog(os.Stderr, "\n❌ Флаг '--service' требует указания appName.\n")
+			os.Exit(1)
+		}
+
+		if err := runEventsLogic(cmd.Context(), appName, serviceName, asJSON); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'events': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	eventsCmd.Flags().StringP("service", "s", "", "Показывать события только этого сервиса")
+	eventsCmd.Flags().Bool("json", false, "Выводить события в формате JSON, по одному объекту в строке")
+	rootCmd.AddCommand(eventsCmd)
+}
+
+// eventJSON — представление события для --json
+type eventJSON struct {
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	AppName     string    `json:"appName"`
+	ServiceName string    `json:"serviceName"`
+	ResourceID  string    `json:"resourceId,omitempty"`
+	ExitCode    *int32    `json:"exitCode,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Source      string    `json:"source"`
+}
+
+func runEventsLogic(ctx context.Context, appName, serviceName string, asJSON bool) error {
+	if !isDaemonRunning() {
+		return errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	// Поток бессрочный: Ctrl+C завершает команду без ошибки
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	stream, err := client.Events(ctx, &pb.EventsRequest{AppName: appName, ServiceName: serviceName})
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове Events: %w", err)
+	}
+
+	if !asJSON {
+		infoLog("Ожидание событий... Нажмите Ctrl+C для выхода.\n")
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("поток событий прерван: %w", err)
+		}
+
+		if asJSON {
+			if err := encoder.Encode(toEventJSON(event)); err != nil {
+				return err
+			}
+			continue
+		}
+		printEvent(event)
+	}
+}
+
+func toEventJSON(e *pb.Event) eventJSON {
+	out := eventJSON{
+		Time:        time.Unix(0, e.GetTime()),
+		Type:        e.GetType(),
+		AppName:     e.GetAppName(),
+		ServiceName: e.GetServiceName(),
+		ResourceID:  e.GetResourceId(),
+		Message:     e.GetMessage(),
+		Source:      e.GetSource(),
+	}
+	if e.GetType() == "exited" {
+		code := e.GetExitCode()
+		out.ExitCode = &code
+	}
+	return out
+}
+
+// eventColors выделяет события, требующие внимания
+var eventColors = map[string]*color.Color{
+	"ready":      color.New(color.FgGreen),
+	"unhealthy":  color.New(color.FgRed),
+	"exited":     color.New(color.FgRed),
+	"rebuilt":    color.New(color.FgCyan),
+	"removed":    color.New(color.FgYellow),
+	"restarting": color.New(color.FgYellow),
+	"crash-loop": color.New(color.FgRed, color.Bold),
+}
+
+func printEvent(e *pb.Event) {
+	kind := e.GetType()
+	c := eventColors[kind]
+	if kind == "exited" {
+		// Код 0 — штатное завершение, например при 'forge down'
+		if e.GetExitCode() == 0 {
+			c = nil
+		} else {
+			kind = fmt.Sprintf("exited (код %d)", e.GetExitCode())
+		}
+	}
+	if c != nil {
+		kind = c.Sprint(kind)
+	}
+
+	line := fmt.Sprintf("%s  %s/%s  %s", time.Unix(0, e.GetTime()).Format("15:04:05.000"), e.GetAppName(), e.GetServiceName(), kind)
+	if e.GetResourceId() != "" {
+		line += "  " + shortID(e.GetResourceId())
+	}
+	if e.GetMessage() != "" {
+		line += "  " + e.GetMessage()
+	}
+	fmt.Println(line)
+}
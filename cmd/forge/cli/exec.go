@@ -12,9 +12,7 @@ import (
 	"github.com/spf13/cobra"
 	pb "github.com/waste3d/forge/internal/gen/proto"
 	"golang.org/x/sync/errgroup"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 )
 
@@ -57,9 +55,9 @@ func runExec(cmd *cobra.Command, args []string) {
 }
 
 func runExecLogic(ctx context.Context, cancel context.CancelFunc, appName, serviceName string, command []string) error {
-	conn, err := grpc.Dial(daemonAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := dialDaemon()
 	if err != nil {
-		return fmt.Errorf("не удалось подключиться к серверу: %w", err)
+		return err
 	}
 	defer conn.Close()
 
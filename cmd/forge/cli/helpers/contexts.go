@@ -0,0 +1,113 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultContextName — встроенный контекст: локальный демон на адресе по умолчанию.
+// Его нельзя создать, изменить или удалить.
+const DefaultContextName = "default"
+
+// Context — именованный набор параметров подключения к демону
+type Context struct {
+	Name          string `yaml:"name"`
+	Description   string `yaml:"description,omitempty"`
+	Address       string `yaml:"address"`
+	Token         string `yaml:"token,omitempty"`
+	TLSCA         string `yaml:"tlsCA,omitempty"`
+	TLSCert       string `yaml:"tlsCert,omitempty"`
+	TLSKey        string `yaml:"tlsKey,omitempty"`
+	TLSServerName string `yaml:"tlsServerName,omitempty"`
+}
+
+// CLIConfig — содержимое ~/.forge/config.yaml
+type CLIConfig struct {
+	CurrentContext string    `yaml:"currentContext,omitempty"`
+	Contexts       []Context `yaml:"contexts,omitempty"`
+
+	path string
+}
+
+// LoadCLIConfig читает ~/.forge/config.yaml. Отсутствующий файл означает пустую конфигурацию.
+func LoadCLIConfig() (*CLIConfig, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось определить домашнюю директорию: %w", err)
+	}
+	cfg := &CLIConfig{path: filepath.Join(home, ".forge", "config.yaml")}
+
+	content, err := os.ReadFile(cfg.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", cfg.path, err)
+	}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга %s: %w", cfg.path, err)
+	}
+	return cfg, nil
+}
+
+// Save сохраняет конфигурацию. Файл может содержать токены, поэтому доступен только владельцу.
+func (c *CLIConfig) Save() error {
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать конфигурацию CLI: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return fmt.Errorf("не удалось сохранить %s: %w", c.path, err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Path возвращает путь к файлу конфигурации
+func (c *CLIConfig) Path() string {
+	return c.path
+}
+
+// Context возвращает контекст по имени
+func (c *CLIConfig) Context(name string) (Context, bool) {
+	for _, ctx := range c.Contexts {
+		if ctx.Name == name {
+			return ctx, true
+		}
+	}
+	return Context{}, false
+}
+
+// SetContext добавляет контекст или заменяет существующий с тем же именем
+func (c *CLIConfig) SetContext(ctx Context) {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == ctx.Name {
+			c.Contexts[i] = ctx
+			return
+		}
+	}
+	c.Contexts = append(c.Contexts, ctx)
+}
+
+// RemoveContext удаляет контекст и сообщает, был ли он найден. Если удаляется
+// текущий контекст, текущим становится контекст по умолчанию.
+func (c *CLIConfig) RemoveContext(name string) bool {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			c.Contexts = append(c.Contexts[:i], c.Contexts[i+1:]...)
+			if c.CurrentContext == name {
+				c.CurrentContext = ""
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestCLIConfigSaveLoad(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	cfg, err := LoadCLIConfig()
+	if err != nil {
+		t.Fatalf("LoadCLIConfig без файла: %v", err)
+	}
+	if cfg.CurrentContext != "" || len(cfg.Contexts) != 0 {
+		t.Fatalf("ожидалась пустая конфигурация, получено %+v", cfg)
+	}
+
+	cfg.SetContext(Context{Name: "prod", Address: "prod:9001", Token: "secret", TLSCA: "/ca.pem"})
+	cfg.CurrentContext = "prod"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		for path, want := range map[string]os.FileMode{
+			filepath.Join(home, ".forge"):                0700,
+			filepath.Join(home, ".forge", "config.yaml"): 0600,
+		} {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("Stat(%s): %v", path, err)
+			}
+			if got := info.Mode().Perm(); got != want {
+				t.Errorf("права %s = %o, ожидалось %o", path, got, want)
+			}
+		}
+	}
+
+	loaded, err := LoadCLIConfig()
+	if err != nil {
+		t.Fatalf("LoadCLIConfig: %v", err)
+	}
+	if loaded.CurrentContext != "prod" || !reflect.DeepEqual(loaded.Contexts, cfg.Contexts) {
+		t.Errorf("после перечитывания получено %+v, ожидалось %+v", loaded, cfg)
+	}
+}
+
+func TestCLIConfigContexts(t *testing.T) {
+	cfg := &CLIConfig{}
+	cfg.SetContext(Context{Name: "prod", Address: "prod:9001"})
+	cfg.SetContext(Context{Name: "stage", Address: "stage:9001"})
+	cfg.SetContext(Context{Name: "prod", Address: "prod:9443"})
+
+	tests := []struct {
+		name    string
+		found   bool
+		address string
+	}{
+		{name: "prod", found: true, address: "prod:9443"},
+		{name: "stage", found: true, address: "stage:9001"},
+		{name: "dev", found: false},
+	}
+	for _, tt := range tests {
+		ctx, ok := cfg.Context(tt.name)
+		if ok != tt.found || ctx.Address != tt.address {
+			t.Errorf("Context(%q) = %+v, %v; ожидалось адрес %q, найден %v", tt.name, ctx, ok, tt.address, tt.found)
+		}
+	}
+	if len(cfg.Contexts) != 2 {
+		t.Errorf("SetContext с существующим именем должен заменять контекст, получено %+v", cfg.Contexts)
+	}
+
+	cfg.CurrentContext = "prod"
+	if cfg.RemoveContext("dev") {
+		t.Error("RemoveContext(dev) сообщил об удалении несуществующего контекста")
+	}
+	if !cfg.RemoveContext("stage") || cfg.CurrentContext != "prod" {
+		t.Errorf("удаление нетекущего контекста изменило текущий: %q", cfg.CurrentContext)
+	}
+	if !cfg.RemoveContext("prod") || cfg.CurrentContext != "" {
+		t.Errorf("после удаления текущего контекста CurrentContext = %q, ожидалось пусто", cfg.CurrentContext)
+	}
+	if len(cfg.Contexts) != 0 {
+		t.Errorf("остались контексты: %+v", cfg.Contexts)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/waste3d/forge/pkg/parser"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 func GetAppNameFromConfig() (string, error) {
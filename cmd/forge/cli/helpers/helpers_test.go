@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/waste3d/forge/pkg/parser"
+)
+
+func TestLoadAndPrepareConfigPaths(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "forge.yaml")
+	content := `
+version: 1
+appName: shop
+services:
+  - name: api
+    path: ./api
+    port: auto
+    internalPort: 8080
+  - name: web
+    path: /srv/web
+  - name: cache
+    image: redis:7
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	prepared, err := LoadAndPrepareConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadAndPrepareConfig: %v", err)
+	}
+	config, err := parser.Parse(prepared)
+	if err != nil {
+		t.Fatalf("Parse: %v\n%s", err, prepared)
+	}
+
+	// Демон работает в другой директории, поэтому относительный путь
+	// переводится в абсолютный относительно forge.yaml
+	want := map[string]string{"api": filepath.Join(dir, "api"), "web": "/srv/web", "cache": ""}
+	for _, s := range config.Services {
+		if s.Path != want[s.Name] {
+			t.Errorf("путь сервиса %s = %q, ожидалось %q", s.Name, s.Path, want[s.Name])
+		}
+	}
+	if config.AppName != "shop" || config.Services[0].Port != parser.AutoPort || config.Services[2].Image != "redis:7" {
+		t.Errorf("остальные поля изменены при подготовке: %+v", config)
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <appName>",
+	Short: "Показывает историю запусков приложения",
+	Long:  "Показывает, когда, кем и из какой директории применялась конфигурация приложения, и чем закончился каждый запуск.",
+	Args:  cobra.ExactArgs(1),
+	Run:   runHistory,
+}
+
+func init() {
+	historyCmd.Flags().IntP("limit", "n", 20, "Максимальное число запусков (0 — все)")
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	if err := runHistoryLogic(cmd.Context(), args[0], limit); err != nil {
+		errorLog(os.Stderr, "\n❌ Ошибка выполнения 'history': %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runHistoryLogic(ctx context.Context, appName string, limit int) error {
+	if !isDaemonRunning() {
+		return errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	resp, err := client.History(ctx, &pb.HistoryRequest{AppName: appName, Limit: int32(limit)})
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове History: %w", err)
+	}
+
+	if len(resp.GetRuns()) == 0 {
+		infoLog("Для приложения '%s' нет запусков.\n", appName)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "RUN ID\tSTARTED\tDURATION\tSTATUS\tUSER\tCONFIG\tWORKING DIR")
+
+	for _, run := range resp.GetRuns() {
+		started := time.Unix(run.GetStartedAt(), 0)
+		duration := "-"
+		if run.GetFinishedAt() > 0 {
+			duration = time.Unix(run.GetFinishedAt(), 0).Sub(started).String()
+		}
+
+		fmt.Fprintf(w, "%s\t%s ago\t%s\t%s\t%s\t%s\t%s\n",
+			shortID(run.GetId()),
+			units.HumanDuration(time.Since(started)),
+			duration,
+			run.GetStatus(),
+			run.GetClientUser(),
+			shortID(run.GetConfigHash()),
+			run.GetWorkingDir(),
+		)
+	}
+
+	return w.Flush()
+}
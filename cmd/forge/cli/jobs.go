@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs [appName]",
+	Short: "Показывает задания демона: выполняемые и недавно завершенные 'up' и 'build'",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appName := ""
+		if len(args) > 0 {
+			appName = args[0]
+		}
+		if err := runJobsLogic(cmd.Context(), appName); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'jobs': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <jobID>",
+	Short: "Подключается к выводу задания: сначала уже выведенные сообщения, затем новые",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		detached, err := runAttachLogic(cmd.Context(), args[0])
+		if err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'attach': %v\n", err)
+			os.Exit(1)
+		}
+		if !detached {
+			successLog("\n✅ Задание успешно завершено.\n")
+		}
+	},
+}
+
+var cancelCmd = &cobra.Command{
+	Use:   "cancel <jobID>",
+	Short: "Отменяет задание и удаляет созданные им ресурсы",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runCancelLogic(cmd.Context(), args[0]); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'cancel': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+	rootCmd.AddCommand(attachCmd)
+	rootCmd.AddCommand(cancelCmd)
+}
+
+func runJobsLogic(ctx context.Context, appName string) error {
+	if !isDaemonRunning() {
+		return errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	resp, err := client.ListJobs(ctx, &pb.ListJobsRequest{AppName: appName})
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове ListJobs: %w", err)
+	}
+
+	if len(resp.GetJobs()) == 0 {
+		infoLog("Заданий нет.\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "JOB ID\tKIND\tAPP NAME\tSTATUS\tCREATED\tDURATION\tRUN ID\tERROR")
+	for _, job := range resp.GetJobs() {
+		created := time.Unix(job.GetCreatedAt(), 0)
+		finished := time.Now()
+		if job.GetFinishedAt() > 0 {
+			finished = time.Unix(job.GetFinishedAt(), 0)
+		}
+		runID := "-"
+		if job.GetRunId() != "" {
+			runID = shortID(job.GetRunId())
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s ago\t%s\t%s\t%s\n",
+			job.GetId(),
+			job.GetKind(),
+			job.GetAppName(),
+			job.GetStatus(),
+			units.HumanDuration(time.Since(created)),
+			finished.Sub(created).Round(time.Second),
+			runID,
+			job.GetError(),
+		)
+	}
+	return w.Flush()
+}
+
+func runAttachLogic(ctx context.Context, jobID string) (bool, error) {
+	if !isDaemonRunning() {
+		return false, errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	return followJob(ctx, func(ctx context.Context) (pb.Forge_UpClient, error) {
+		stream, err := client.AttachJob(ctx, &pb.AttachJobRequest{JobId: jobID})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при вызове AttachJob: %w", err)
+		}
+		return stream, nil
+	})
+}
+
+func runCancelLogic(ctx context.Context, jobID string) error {
+	if !isDaemonRunning() {
+		return errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	resp, err := client.CancelJob(ctx, &pb.CancelJobRequest{JobId: jobID})
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове CancelJob: %w", err)
+	}
+
+	job := resp.GetJob()
+	successLog("✅ Задание %s (%s %s) отменяется.\n", job.GetId(), job.GetKind(), job.GetAppName())
+	if job.GetKind() == "up" {
+		infoLog("Демон удалит ресурсы, которые задание успело создать. Ход отмены: 'forge attach %s'.\n", job.GetId())
+	}
+	return nil
+}
+
+// jobIDRecorder запоминает идентификатор задания из сообщений потока
+type jobIDRecorder struct {
+	pb.Forge_UpClient
+	jobID string
+}
+
+func (r *jobIDRecorder) Recv() (*pb.LogEntry, error) {
+	entry, err := r.Forge_UpClient.Recv()
+	if entry != nil && entry.GetJobId() != "" {
+		r.jobID = entry.GetJobId()
+	}
+	return entry, err
+}
+
+// followJob печатает вывод задания демона. По Ctrl+C CLI отключается, а задание
+// продолжает выполняться в демоне. Возвращает true, если клиент отключился до
+// завершения задания.
+func followJob(ctx context.Context, open func(ctx context.Context) (pb.Forge_UpClient, error)) (bool, error) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	stream, err := open(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	recorder := &jobIDRecorder{Forge_UpClient: stream}
+	err = PrintLogs(recorder)
+	if err != nil && ctx.Err() != nil && recorder.jobID != "" {
+		infoLog("\nОтключено от задания %s, оно продолжает выполняться в демоне.\n", recorder.jobID)
+		infoLog("Вернуться к выводу: 'forge attach %s', отменить: 'forge cancel %s'.\n", recorder.jobID, recorder.jobID)
+		return true, nil
+	}
+	return false, err
+}
+
+// printDetachedJob сообщает, как следить за заданием, запущенным с --detach
+func printDetachedJob(stream pb.Forge_UpClient) error {
+	recorder := &jobIDRecorder{Forge_UpClient: stream}
+	if err := PrintLogs(recorder); err != nil {
+		return err
+	}
+	if recorder.jobID == "" {
+		return errors.New("демон не сообщил идентификатор задания")
+	}
+	infoLog("Задание %s выполняется в демоне. Вывод: 'forge attach %s', отмена: 'forge cancel %s'.\n", recorder.jobID, recorder.jobID, recorder.jobID)
+	return nil
+}
@@ -6,15 +6,20 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/charmbracelet/glamour"
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	pb "github.com/waste3d/forge/internal/gen/proto"
 	ai "github.com/waste3d/forge/openai"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 )
 
 var logsCmd = &cobra.Command{
@@ -28,7 +33,18 @@ var logsCmd = &cobra.Command{
 func init() {
 	logsCmd.Flags().BoolP("follow", "f", false, "Следить за логами в реальном времени")
 	logsCmd.Flags().Bool("ai", false, "Анализировать логи с помощью ИИ для поиска корневой причины ошибок")
+	logsCmd.Flags().Bool("offline", false, "Анализировать логи локальными правилами, без ИИ, API-ключа и сети")
 	logsCmd.Flags().String("output", "", "Сохранить результат анализа в файл")
+	logsCmd.Flags().String("tail", "100", "Сколько последних строк каждого сервиса показать, 'all' — все")
+	logsCmd.Flags().String("since", "", "Показать записи начиная с момента: RFC3339, Unix-время или длительность (например, 10m)")
+	logsCmd.Flags().String("until", "", "Показать записи до момента: RFC3339, Unix-время или длительность (например, 10m)")
+	logsCmd.Flags().BoolP("timestamps", "t", false, "Показывать время каждой записи")
+	logsCmd.Flags().String("grep", "", "Показывать только строки, подходящие под регулярное выражение")
+	logsCmd.Flags().String("level", "", "Показывать только записи с этим уровнем и выше: debug, info, warn, error (для сервисов с logFormat)")
+	logsCmd.Flags().StringArray("field", nil, "Показывать только записи с полем key=value, флаг можно повторять (для сервисов с logFormat)")
+	logsCmd.Flags().Bool("raw", false, "Выводить структурированные записи исходными строками")
+	logsCmd.Flags().Int("previous", 0, "Показать сохраненные логи предыдущего контейнера, удаленного при 'forge down' или пересоздании: --previous — последнего, --previous=N — N-го с конца")
+	logsCmd.Flags().Lookup("previous").NoOptDefVal = "1"
 	rootCmd.AddCommand(logsCmd)
 }
 
@@ -42,53 +58,136 @@ func runLogs(cmd *cobra.Command, args []string) {
 
 	follow, _ := cmd.Flags().GetBool("follow")
 	ai, _ := cmd.Flags().GetBool("ai")
+	offline, _ := cmd.Flags().GetBool("offline")
 	output, _ := cmd.Flags().GetString("output")
 
-	if ai && follow {
-		errorLog(os.Stderr, "\n❌ Флаги '--ai' и '--follow' нельзя использовать одновременно.\n")
+	if (ai || offline) && follow {
+		errorLog(os.Stderr, "\n❌ Анализ логов ('--ai', '--offline') нельзя совмещать с '--follow'.\n")
 		os.Exit(1)
 	}
 
-	if output != "" && !ai {
-		errorLog(os.Stderr, "\n❌ Флаг '--output' требует указания '--ai' для сохранения результата.\n")
+	if previous, _ := cmd.Flags().GetInt("previous"); previous != 0 && follow {
+		errorLog(os.Stderr, "\n❌ Флаги '--previous' и '--follow' нельзя использовать одновременно.\n")
 		os.Exit(1)
 	}
 
-	if err := runLogsLogic(cmd.Context(), appName, serviceName, follow, ai, output); err != nil {
+	if output != "" && !ai && !offline {
+		errorLog(os.Stderr, "\n❌ Флаг '--output' требует указания '--ai' или '--offline' для сохранения результата.\n")
+		os.Exit(1)
+	}
+
+	req, err := logRequest(cmd, appName, serviceName)
+	if err != nil {
+		errorLog(os.Stderr, "\n❌ %v\n", err)
+		os.Exit(1)
+	}
+	// Для анализа нужны сами сообщения: время в начале строки мешает правилам,
+	// которые проверяют ее начало
+	if ai || offline {
+		req.Timestamps = false
+	}
+
+	if err := runLogsLogic(cmd.Context(), req, ai, offline, output); err != nil {
 		errorLog(os.Stderr, "\n❌ Ошибка выполнения 'logs': %v\n", err)
 		os.Exit(1)
 	}
 	successLog("\n✅ Команда 'logs' успешно завершена.\n")
 }
 
-func runLogsLogic(ctx context.Context, appName, serviceName string, follow, useAI bool, output string) error {
+// logRequest собирает запрос логов из флагов команды. Время и длительности в
+// --since/--until переводятся в Unix-время здесь: в часовом поясе и по часам
+// клиента, а не удаленного демона.
+func logRequest(cmd *cobra.Command, appName, serviceName string) (*pb.LogRequest, error) {
+	req := &pb.LogRequest{
+		AppName:     appName,
+		ServiceName: serviceName,
+	}
+	req.Follow, _ = cmd.Flags().GetBool("follow")
+	req.Timestamps, _ = cmd.Flags().GetBool("timestamps")
+	req.Grep, _ = cmd.Flags().GetString("grep")
+	previous, _ := cmd.Flags().GetInt("previous")
+	if previous < 0 {
+		return nil, fmt.Errorf("флаг '--previous' ожидает положительный номер контейнера, получено %d", previous)
+	}
+	req.Previous = previous > 0
+	req.PreviousIndex = int32(previous)
+	req.Level, _ = cmd.Flags().GetString("level")
+	req.Raw, _ = cmd.Flags().GetBool("raw")
+
+	fields, _ := cmd.Flags().GetStringArray("field")
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("флаг '--field' ожидает значение вида key=value, получено '%s'", field)
+		}
+		if req.Fields == nil {
+			req.Fields = make(map[string]string)
+		}
+		req.Fields[key] = value
+	}
+
+	tail, _ := cmd.Flags().GetString("tail")
+	n := int64(-1)
+	if tail != "all" {
+		var err error
+		n, err = strconv.ParseInt(tail, 10, 32)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("флаг '--tail' должен быть неотрицательным числом или 'all', получено '%s'", tail)
+		}
+	}
+	req.Tail = proto.Int32(int32(n))
+
+	now := time.Now()
+	for name, dst := range map[string]*string{"since": &req.Since, "until": &req.Until} {
+		value, _ := cmd.Flags().GetString(name)
+		if value == "" {
+			continue
+		}
+		ts, err := timetypes.GetTimestamp(value, now)
+		if err != nil {
+			return nil, fmt.Errorf("некорректное значение '--%s %s': ожидалось время RFC3339, Unix-время или длительность, например 10m", name, value)
+		}
+		*dst = ts
+	}
+
+	if req.Grep != "" {
+		if _, err := regexp.Compile(req.Grep); err != nil {
+			return nil, fmt.Errorf("некорректное регулярное выражение '--grep': %v", err)
+		}
+	}
+	return req, nil
+}
+
+// runLogsLogic выводит логи или, с useAI либо offline, анализирует их. Анализ
+// выполняется локальными правилами, если задан offline или провайдер ИИ 'offline'.
+func runLogsLogic(ctx context.Context, req *pb.LogRequest, useAI, offline bool, output string) error {
 	if !isDaemonRunning() {
 		return errors.New("демон 'forged' не запущен. Невозможно получить логи")
 	}
 
-	conn, err := grpc.Dial(daemonAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := dialDaemon()
 	if err != nil {
-		return fmt.Errorf("не удалось подключиться к демону: %w", err)
+		return err
 	}
 	defer conn.Close()
 
 	client := pb.NewForgeClient(conn)
 
-	req := &pb.LogRequest{
-		AppName:     appName,
-		ServiceName: serviceName,
-		Follow:      follow,
-	}
-
-	infoLog("Получаем логи для %s/%s...\n", appName, serviceName)
+	infoLog("Получаем логи для %s/%s...\n", req.GetAppName(), req.GetServiceName())
 	stream, err := client.Logs(ctx, req)
 	if err != nil {
 		return fmt.Errorf("ошибка при получении логов: %w", err)
 	}
 
-	if useAI {
+	if useAI || offline {
+		aiConfig := daemonAIConfig(ctx)
+		offline = offline || aiConfig.Provider == ai.ProviderOffline
+
 		s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
 		s.Suffix = " Анализирую логи с помощью ИИ... (может занять до 30 секунд)"
+		if offline {
+			s.Suffix = " Анализирую логи локальными правилами..."
+		}
 		s.Start()
 
 		// Собираем логи вместо их печати
@@ -102,11 +201,15 @@ func runLogsLogic(ctx context.Context, appName, serviceName string, follow, useA
 				s.Stop()
 				return fmt.Errorf("ошибка при чтении потока логов: %w", err)
 			}
-			collectedLogs[logEntry.GetServiceName()] = append(collectedLogs[logEntry.GetServiceName()], logEntry.GetMessage())
+			collectedLogs[logEntry.GetServiceName()] = append(collectedLogs[logEntry.GetServiceName()], formatLogEntry(logEntry, false))
 		}
 
-		// Вызываем нашу новую функцию
-		aiResponse, err := ai.AnalyzeLogsWithAI(ctx, collectedLogs)
+		var aiResponse string
+		if offline {
+			aiResponse = ai.AnalyzeLogsOffline(collectedLogs, containerStates(ctx, client, req))
+		} else {
+			aiResponse, err = ai.AnalyzeLogsWithAI(ctx, aiConfig, collectedLogs)
+		}
 		s.Stop() // Останавливаем спиннер
 		if err != nil {
 			return err
@@ -142,3 +245,78 @@ func runLogsLogic(ctx context.Context, appName, serviceName string, follow, useA
 		return err
 	}
 }
+
+// levelColors выделяет уровни структурированных записей
+var levelColors = map[string]*color.Color{
+	"trace": color.New(color.Faint),
+	"debug": color.New(color.Faint),
+	"info":  color.New(color.FgCyan),
+	"warn":  color.New(color.FgYellow),
+	"error": color.New(color.FgRed),
+	"fatal": color.New(color.FgRed, color.Bold),
+}
+
+// formatLogEntry возвращает текст записи. Структурированная запись выводится как
+// "УРОВЕНЬ сообщение key=value ...", поля — в алфавитном порядке.
+func formatLogEntry(e *pb.LogEntry, colored bool) string {
+	if e.GetLevel() == "" && len(e.GetFields()) == 0 {
+		return e.GetMessage()
+	}
+
+	var parts []string
+	if level := e.GetLevel(); level != "" {
+		text := fmt.Sprintf("%-5s", strings.ToUpper(level))
+		if c := levelColors[level]; colored && c != nil {
+			text = c.Sprint(text)
+		}
+		parts = append(parts, text)
+	}
+	if e.GetMessage() != "" {
+		parts = append(parts, e.GetMessage())
+	}
+
+	keys := make([]string, 0, len(e.GetFields()))
+	for key := range e.GetFields() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := e.GetFields()[key]
+		if value == "" || strings.ContainsAny(value, " \t\"=") {
+			value = strconv.Quote(value)
+		}
+		field := key + "=" + value
+		if colored {
+			field = color.New(color.Faint).Sprint(field)
+		}
+		parts = append(parts, field)
+	}
+	return strings.Join(parts, " ")
+}
+
+// containerStates возвращает состояние контейнеров приложения для локального
+// анализа логов. Если состояние получить не удалось, анализ идет только по логам.
+func containerStates(ctx context.Context, client pb.ForgeClient, req *pb.LogRequest) []ai.ContainerState {
+	resp, err := client.Status(ctx, &pb.StatusRequest{AppName: req.GetAppName()})
+	if err != nil {
+		return nil
+	}
+
+	var states []ai.ContainerState
+	for _, svc := range resp.GetServices() {
+		if svc.GetResourceType() != "container" {
+			continue
+		}
+		if req.GetServiceName() != "" && svc.GetServiceName() != req.GetServiceName() {
+			continue
+		}
+		states = append(states, ai.ContainerState{
+			ServiceName:  svc.GetServiceName(),
+			Running:      svc.GetRunning(),
+			ExitCode:     int(svc.GetExitCode()),
+			OOMKilled:    svc.GetOomKilled(),
+			RestartCount: int(svc.GetRestartCount()),
+		})
+	}
+	return states
+}
@@ -55,7 +55,7 @@ func runOptimize(cmd *cobra.Command, args []string) {
 
 	prompt := prompts.DockerfilePrompt(string(content))
 
-	aiResponse, err := ai.AnalyzeDockerfileWithAI(cmd.Context(), prompt)
+	aiResponse, err := ai.AnalyzeDockerfileWithAI(cmd.Context(), daemonAIConfig(cmd.Context()), prompt)
 	if err != nil {
 		errorLog(os.Stderr, "Ошибка при анализе Dockerfile: %v\n", err)
 		os.Exit(1)
@@ -10,8 +10,6 @@ import (
 	"github.com/docker/go-units"
 	"github.com/spf13/cobra"
 	pb "github.com/waste3d/forge/internal/gen/proto"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 var psCmd = &cobra.Command{
@@ -39,9 +37,9 @@ func runPs(cmd *cobra.Command, args []string) {
 }
 
 func runPsLogic(ctx context.Context, appName string) error {
-	conn, err := grpc.Dial(daemonAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := dialDaemon()
 	if err != nil {
-		return fmt.Errorf("не удалось подключиться к демону: %w", err)
+		return err
 	}
 	defer conn.Close()
 
@@ -63,7 +61,7 @@ func runPsLogic(ctx context.Context, appName string) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "APP NAME\tNAME\tSTATUS\tAGE\tPORTS")
+	fmt.Fprintln(w, "APP NAME\tNAME\tSTATUS\tAGE\tRESTARTS\tLIMITS\tPORTS")
 
 	for _, s := range resp.GetServices() {
 		age := "N/A"
@@ -72,7 +70,17 @@ func runPsLogic(ctx context.Context, appName string) error {
 			age = units.HumanDuration(time.Since(createdTime))
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.GetAppName(), s.GetServiceName(), s.GetStatus(), age, s.GetPorts())
+		restarts := fmt.Sprintf("%d", s.GetRestartCount())
+		if policy := s.GetRestartPolicy(); policy != "" && policy != "no" {
+			restarts = fmt.Sprintf("%d (%s)", s.GetRestartCount(), policy)
+		}
+
+		limits := s.GetLimits()
+		if limits == "" {
+			limits = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.GetAppName(), s.GetServiceName(), s.GetStatus(), age, restarts, limits, s.GetPorts())
 	}
 
 	return w.Flush()
@@ -36,10 +36,13 @@ func runRestart(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	if err := runUpLogic(); err != nil {
+	detached, err := runUpLogic(cmd.Context(), false)
+	if err != nil {
 		errorLog(os.Stderr, "\n❌ Ошибка при запуске: %v\n", err)
 		os.Exit(1)
 	}
 
-	successLog("\n✅ Команда 'restart' успешно завершена.\n")
+	if !detached {
+		successLog("\n✅ Команда 'restart' успешно завершена.\n")
+	}
 }
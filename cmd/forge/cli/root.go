@@ -1,10 +1,10 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,20 +12,115 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/waste3d/forge/cmd/forge/cli/helpers"
 	"github.com/waste3d/forge/internal/constants"
 	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
 	infoLog       = color.New(color.FgYellow).Printf
 	successLog    = color.New(color.FgGreen).Printf
 	errorLog      = color.New(color.FgRed).Fprintf
+	warnLog       = color.New(color.FgYellow).Fprintf
 	daemonAddress string
+	// daemonSecurity — настройки TLS и токен для подключения к удаленному демону
+	daemonSecurity transport.ClientSecurity
+	// contextName — контекст, выбранный флагом --context или FORGE_CONTEXT
+	contextName string
+	// activeContext — контекст, примененный к текущей команде
+	activeContext = helpers.DefaultContextName
+	// localDaemonAddress и localDaemonSecurity — параметры подключения без учета
+	// контекста: по ним 'forge system start' управляет локальным демоном, даже
+	// если выбран контекст удаленного
+	localDaemonAddress  string
+	localDaemonSecurity transport.ClientSecurity
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "forge",
 	Short: "Forge - оркестратор сред разработки",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := applyContext(cmd); err != nil {
+			errorLog(os.Stderr, "\n❌ %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// applyContext подставляет параметры подключения из выбранного контекста.
+// Явно заданные флаги и переменные окружения FORGE_DAEMON_ADDR и FORGE_TOKEN
+// имеют приоритет над контекстом, а явный адрес отключает контекст целиком.
+func applyContext(cmd *cobra.Command) error {
+	localDaemonAddress, localDaemonSecurity = daemonAddress, daemonSecurity
+
+	// Команды управления контекстами должны работать, даже если текущий контекст сломан
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == contextCmd {
+			return nil
+		}
+	}
+
+	cfg, err := helpers.LoadCLIConfig()
+	if err != nil {
+		return err
+	}
+
+	name := contextName
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name == "" || name == helpers.DefaultContextName {
+		return nil
+	}
+
+	ctx, ok := cfg.Context(name)
+	if !ok {
+		return fmt.Errorf("контекст '%s' не найден. Список контекстов: 'forge context ls'", name)
+	}
+	activeContext = name
+	mergeContext(ctx, cmd.Flags(), os.Getenv)
+	return nil
+}
+
+// mergeContext переносит в daemonAddress и daemonSecurity параметры контекста,
+// которые не заданы флагами или переменными окружения. Если адрес задан явно,
+// контекст не применяется: его токен и сертификаты выданы для другого демона.
+func mergeContext(ctx helpers.Context, flags *pflag.FlagSet, getenv func(string) string) {
+	if flags.Changed("daemon-addr") || getenv(constants.DaemonAddrEnvVar) != "" {
+		return
+	}
+	daemonAddress = ctx.Address
+	if !flags.Changed("token") && getenv(constants.TokenEnvVar) == "" {
+		daemonSecurity.Token = ctx.Token
+	}
+	if !flags.Changed("tls-ca") {
+		daemonSecurity.CAFile = ctx.TLSCA
+	}
+	if !flags.Changed("tls-cert") {
+		daemonSecurity.CertFile = ctx.TLSCert
+	}
+	if !flags.Changed("tls-key") {
+		daemonSecurity.KeyFile = ctx.TLSKey
+	}
+	if !flags.Changed("tls-server-name") {
+		daemonSecurity.ServerName = ctx.TLSServerName
+	}
+}
+
+// useLocalDaemon переключает команду на локальный демон, если контекст указывает
+// на другой адрес. Возвращает true, если переключение произошло.
+func useLocalDaemon() bool {
+	if daemonAddress == localDaemonAddress {
+		return false
+	}
+	daemonAddress, daemonSecurity = localDaemonAddress, localDaemonSecurity
+	activeContext = helpers.DefaultContextName
+	return true
 }
 
 func Execute() error {
@@ -33,10 +128,7 @@ func Execute() error {
 }
 
 func PrintLogs(stream pb.Forge_UpClient) error {
-	cDaemon := color.New(color.FgCyan)
-	cDB := color.New(color.FgGreen)
-	cService := color.New(color.FgMagenta) // Новый цвет для сервисов
-
+	var colors sessionColors
 	for {
 		logEntry, err := stream.Recv()
 		if err == io.EOF {
@@ -47,32 +139,105 @@ func PrintLogs(stream pb.Forge_UpClient) error {
 		}
 
 		serviceName := logEntry.GetServiceName()
-		message := logEntry.GetMessage()
-
-		// Выбираем цвет в зависимости от имени сервиса
-		switch serviceName {
-		case "forged-daemon":
-			cDaemon.Printf("[%s] %s\n", serviceName, message)
-		case "main-db": // Можно оставить специфичный цвет для БД
-			cDB.Printf("[%s] %s\n", serviceName, message)
-		default:
-			// Все остальные сервисы будут одного цвета
-			cService.Printf("[%s] %s\n", serviceName, message)
+		c := colors.serviceColor(serviceName)
+		if logEntry.GetLevel() == "" && len(logEntry.GetFields()) == 0 {
+			c.Printf("[%s] %s\n", serviceName, logEntry.GetMessage())
+			continue
 		}
+		// В структурированной записи уровень и поля выделяются отдельно от сервиса
+		fmt.Println(c.Sprintf("[%s]", serviceName), formatLogEntry(logEntry, true))
 	}
 }
 
+// serviceColors — цвета сервисов в логах. Голубой зарезервирован за сообщениями демона.
+var serviceColors = []*color.Color{
+	color.New(color.FgGreen),
+	color.New(color.FgMagenta),
+	color.New(color.FgBlue),
+	color.New(color.FgYellow),
+	color.New(color.FgHiGreen),
+	color.New(color.FgHiMagenta),
+	color.New(color.FgHiBlue),
+	color.New(color.FgHiYellow),
+}
+
+// sessionColors раздает цвета сервисам в порядке их первого появления в выводе
+// команды: пока сервисов не больше, чем цветов, у каждого свой цвет
+type sessionColors struct {
+	assigned map[string]*color.Color
+}
+
+// serviceColor возвращает цвет сервиса
+func (c *sessionColors) serviceColor(serviceName string) *color.Color {
+	if serviceName == "forged-daemon" {
+		return color.New(color.FgCyan)
+	}
+	if col, ok := c.assigned[serviceName]; ok {
+		return col
+	}
+	if c.assigned == nil {
+		c.assigned = make(map[string]*color.Color)
+	}
+	col := serviceColors[len(c.assigned)%len(serviceColors)]
+	c.assigned[serviceName] = col
+	return col
+}
+
+// isDaemonRunning проверяет, отвечает ли демон на запрос DaemonInfo. Ошибки
+// аутентификации и прочие ответы демона означают, что он работает: их покажет
+// сама команда.
 func isDaemonRunning() bool {
-	conn, err := net.DialTimeout("tcp", daemonAddress, 1*time.Second)
-	if err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err := fetchDaemonInfo(ctx)
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
 		return false
+	default:
+		return true
+	}
+}
+
+// fetchDaemonInfo запрашивает сведения о демоне
+func fetchDaemonInfo(ctx context.Context) (*pb.DaemonInfoResponse, error) {
+	conn, err := dialDaemon()
+	if err != nil {
+		return nil, err
 	}
 	defer conn.Close()
-	return true
+
+	return pb.NewForgeClient(conn).DaemonInfo(ctx, &pb.DaemonInfoRequest{})
+}
+
+// dialDaemon открывает gRPC-соединение с демоном по адресу из --daemon-addr.
+// При первом подключении за запуск CLI сверяет версию демона со своей.
+func dialDaemon() (*grpc.ClientConn, error) {
+	target, err := transport.GRPCTarget(daemonAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := transport.DialOptions(daemonAddress, daemonSecurity)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подключиться к демону: %w", err)
+	}
+	checkDaemonVersion(conn)
+	return conn, nil
 }
 
-// startDaemon ищет forged в PATH и запускает его в фоне.
+// startDaemon ищет forged в PATH и запускает его в фоне. Демон выбранного
+// контекста работает на другой машине, и запустить его отсюда нельзя.
 func startDaemon() error {
+	if daemonAddress != localDaemonAddress {
+		return fmt.Errorf("демон контекста '%s' (%s) недоступен и не может быть запущен с этой машины", activeContext, daemonAddress)
+	}
+
 	path, err := exec.LookPath("forged")
 	if err != nil {
 		return errors.New("не удалось найти 'forged' в вашем PATH. Убедитесь, что демон установлен и доступен")
@@ -83,7 +248,7 @@ func startDaemon() error {
 		return fmt.Errorf("не удалось определить домашнюю директорию: %w", err)
 	}
 	logDir := filepath.Join(home, ".forge")
-	if err := os.MkdirAll(logDir, 0755); err != nil {
+	if err := os.MkdirAll(logDir, 0700); err != nil {
 		return fmt.Errorf("не удалось создать директорию для логов: %w", err)
 	}
 	logFilePath := filepath.Join(logDir, "forged.log")
@@ -93,7 +258,8 @@ func startDaemon() error {
 		return fmt.Errorf("не удалось открыть лог-файл: %w", err)
 	}
 
-	cmd := exec.Command(path)
+	// Демон должен слушать тот же адрес, по которому к нему будет обращаться CLI
+	cmd := exec.Command(path, "-addr", daemonAddress)
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
 
@@ -107,8 +273,14 @@ func startDaemon() error {
 func init() {
 	defaultAddr := os.Getenv(constants.DaemonAddrEnvVar)
 	if defaultAddr == "" {
-		defaultAddr = constants.DefaultDemonAddress
+		defaultAddr = transport.DefaultAddress()
 	}
 
-	rootCmd.PersistentFlags().StringVarP(&daemonAddress, "daemon-addr", "a", defaultAddr, "адрес для соединения с демоном")
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", os.Getenv(constants.ContextEnvVar), "контекст подключения к демону (по умолчанию текущий из 'forge context use')")
+	rootCmd.PersistentFlags().StringVarP(&daemonAddress, "daemon-addr", "a", defaultAddr, "адрес демона: unix:///путь/к/сокету или host:port для TCP")
+	rootCmd.PersistentFlags().StringVar(&daemonSecurity.CAFile, "tls-ca", "", "CA-сертификат для проверки демона (включает TLS)")
+	rootCmd.PersistentFlags().StringVar(&daemonSecurity.CertFile, "tls-cert", "", "клиентский сертификат для mTLS")
+	rootCmd.PersistentFlags().StringVar(&daemonSecurity.KeyFile, "tls-key", "", "ключ клиентского сертификата")
+	rootCmd.PersistentFlags().StringVar(&daemonSecurity.ServerName, "tls-server-name", "", "имя, по которому проверяется сертификат демона")
+	rootCmd.PersistentFlags().StringVar(&daemonSecurity.Token, "token", os.Getenv(constants.TokenEnvVar), "токен доступа к демону (по умолчанию из FORGE_TOKEN)")
 }
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/spf13/pflag"
+	"github.com/waste3d/forge/cmd/forge/cli/helpers"
+	"github.com/waste3d/forge/internal/constants"
+	"github.com/waste3d/forge/internal/transport"
+)
+
+func TestMergeContext(t *testing.T) {
+	ctx := helpers.Context{
+		Name:    "prod",
+		Address: "prod:9001",
+		Token:   "context-token",
+		TLSCA:   "/context/ca.pem",
+	}
+
+	tests := []struct {
+		name      string
+		flags     []string
+		env       map[string]string
+		wantAddr  string
+		wantToken string
+		wantCA    string
+	}{
+		{
+			name:      "контекст",
+			wantAddr:  "prod:9001",
+			wantToken: "context-token",
+			wantCA:    "/context/ca.pem",
+		},
+		{
+			name:      "переменные окружения важнее контекста",
+			env:       map[string]string{constants.DaemonAddrEnvVar: "env:9001", constants.TokenEnvVar: "env-token"},
+			wantAddr:  "env:9001",
+			wantToken: "env-token",
+		},
+		{
+			name:      "флаги важнее контекста",
+			flags:     []string{"--daemon-addr", "flag:9001", "--token", "flag-token", "--tls-ca", "/flag/ca.pem"},
+			wantAddr:  "flag:9001",
+			wantToken: "flag-token",
+			wantCA:    "/flag/ca.pem",
+		},
+		{
+			name:     "флаги важнее переменных окружения",
+			flags:    []string{"--daemon-addr", "flag:9001"},
+			env:      map[string]string{constants.DaemonAddrEnvVar: "env:9001"},
+			wantAddr: "flag:9001",
+		},
+		{
+			// Токен контекста выдан для его демона и не уходит на явно указанный адрес
+			name:     "явный адрес без учетных данных контекста",
+			env:      map[string]string{constants.DaemonAddrEnvVar: "other:9001"},
+			wantAddr: "other:9001",
+		},
+		{
+			name:      "токен из окружения для адреса контекста",
+			env:       map[string]string{constants.TokenEnvVar: "env-token"},
+			wantAddr:  "prod:9001",
+			wantToken: "env-token",
+			wantCA:    "/context/ca.pem",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldAddr, oldSecurity := daemonAddress, daemonSecurity
+			defer func() { daemonAddress, daemonSecurity = oldAddr, oldSecurity }()
+
+			// Значения по умолчанию флагов берутся из окружения, как в init()
+			defaultAddr, defaultToken := tt.env[constants.DaemonAddrEnvVar], tt.env[constants.TokenEnvVar]
+			if defaultAddr == "" {
+				defaultAddr = "unix:///default.sock"
+			}
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.StringVar(&daemonAddress, "daemon-addr", defaultAddr, "")
+			flags.StringVar(&daemonSecurity.Token, "token", defaultToken, "")
+			flags.StringVar(&daemonSecurity.CAFile, "tls-ca", "", "")
+			flags.StringVar(&daemonSecurity.CertFile, "tls-cert", "", "")
+			flags.StringVar(&daemonSecurity.KeyFile, "tls-key", "", "")
+			flags.StringVar(&daemonSecurity.ServerName, "tls-server-name", "", "")
+			if err := flags.Parse(tt.flags); err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			mergeContext(ctx, flags, func(key string) string { return tt.env[key] })
+
+			if daemonAddress != tt.wantAddr {
+				t.Errorf("адрес = %q, ожидалось %q", daemonAddress, tt.wantAddr)
+			}
+			if daemonSecurity.Token != tt.wantToken {
+				t.Errorf("токен = %q, ожидалось %q", daemonSecurity.Token, tt.wantToken)
+			}
+			if daemonSecurity.CAFile != tt.wantCA {
+				t.Errorf("CA = %q, ожидалось %q", daemonSecurity.CAFile, tt.wantCA)
+			}
+		})
+	}
+}
+
+func TestUseLocalDaemon(t *testing.T) {
+	oldAddr, oldSecurity, oldLocalAddr, oldLocalSecurity, oldActive := daemonAddress, daemonSecurity, localDaemonAddress, localDaemonSecurity, activeContext
+	defer func() {
+		daemonAddress, daemonSecurity, localDaemonAddress, localDaemonSecurity, activeContext = oldAddr, oldSecurity, oldLocalAddr, oldLocalSecurity, oldActive
+	}()
+
+	localDaemonAddress, localDaemonSecurity = "unix:///local.sock", transport.ClientSecurity{}
+	daemonAddress, daemonSecurity, activeContext = "prod:9001", transport.ClientSecurity{Token: "secret", CAFile: "/ca.pem"}, "prod"
+
+	if !useLocalDaemon() {
+		t.Fatal("useLocalDaemon() = false для удаленного контекста")
+	}
+	if daemonAddress != "unix:///local.sock" || daemonSecurity != (transport.ClientSecurity{}) || activeContext != helpers.DefaultContextName {
+		t.Errorf("после переключения: адрес %q, безопасность %+v, контекст %q", daemonAddress, daemonSecurity, activeContext)
+	}
+	if useLocalDaemon() {
+		t.Error("повторный useLocalDaemon() = true, хотя адрес уже локальный")
+	}
+
+	daemonAddress = "prod:9001"
+	if err := startDaemon(); err == nil || !strings.Contains(err.Error(), "не может быть запущен") {
+		t.Errorf("startDaemon для удаленного адреса: ожидался отказ, получено %v", err)
+	}
+}
+
+func TestSessionColors(t *testing.T) {
+	var colors sessionColors
+	seen := make(map[*color.Color]string)
+	for i := range serviceColors {
+		name := fmt.Sprintf("service-%d", i)
+		c := colors.serviceColor(name)
+		if other, ok := seen[c]; ok {
+			t.Fatalf("%s и %s получили один цвет", name, other)
+		}
+		seen[c] = name
+	}
+
+	if colors.serviceColor("service-0") != serviceColors[0] {
+		t.Error("цвет сервиса изменился при повторном появлении")
+	}
+	if colors.serviceColor("forged-daemon") == serviceColors[0] {
+		t.Error("сообщения демона окрашены цветом сервиса")
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+)
+
+// systemStateCmd - для переноса состояния демона между машинами
+var systemStateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Экспорт и импорт состояния демона",
+}
+
+var systemStateExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Сохраняет состояние демона (приложения, ресурсы, история запусков) в JSON",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		if err := runStateExportLogic(cmd.Context(), output); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'state export': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var systemStateImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Добавляет в состояние демона записи из файла, созданного 'forge system state export'",
+	Long:  "Добавляет в состояние демона записи из снимка. Существующие записи не изменяются, поэтому импорт можно безопасно повторять. Укажите '-', чтобы читать снимок из stdin.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runStateImportLogic(cmd.Context(), args[0]); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'state import': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	systemStateExportCmd.Flags().StringP("output", "o", "", "Файл для сохранения снимка (по умолчанию stdout)")
+
+	systemStateCmd.AddCommand(systemStateExportCmd)
+	systemStateCmd.AddCommand(systemStateImportCmd)
+	systemCmd.AddCommand(systemStateCmd)
+}
+
+func runStateExportLogic(ctx context.Context, output string) error {
+	if !isDaemonRunning() {
+		return errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	resp, err := client.ExportState(ctx, &pb.ExportStateRequest{})
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове ExportState: %w", err)
+	}
+
+	if output == "" {
+		_, err := os.Stdout.Write(resp.GetSnapshot())
+		return err
+	}
+
+	// Снимок содержит примененные конфигурации, в том числе пароли из env
+	if err := os.WriteFile(output, resp.GetSnapshot(), 0600); err != nil {
+		return fmt.Errorf("не удалось сохранить снимок: %w", err)
+	}
+	successLog("✅ Состояние сохранено в %s\n", output)
+	return nil
+}
+
+func runStateImportLogic(ctx context.Context, path string) error {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать снимок: %w", err)
+	}
+
+	if !isDaemonRunning() {
+		return errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	resp, err := client.ImportState(ctx, &pb.ImportStateRequest{Snapshot: content})
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове ImportState: %w", err)
+	}
+
+	successLog("✅ Импортировано: ресурсов %d, портов %d, запусков %d.\n", resp.GetResources(), resp.GetPortAllocations(), resp.GetRuns())
+	infoLog("Выполните 'forge system reconcile', чтобы сверить импортированные ресурсы с Docker на этой машине.\n")
+	return nil
+}
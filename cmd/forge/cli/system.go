@@ -1,11 +1,16 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
-	"os/exec"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
+	pb "github.com/waste3d/forge/internal/gen/proto"
 )
 
 var systemCmd = &cobra.Command{
@@ -17,6 +22,9 @@ var systemStartCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Запускает демон 'forged' в фоновом режиме",
 	Run: func(cmd *cobra.Command, args []string) {
+		if useLocalDaemon() {
+			infoLog("Контекст указывает на другой демон: запускается локальный демон на %s\n", daemonAddress)
+		}
 		if isDaemonRunning() {
 			infoLog("Демон 'forged' уже запущен.\n")
 			return
@@ -54,31 +62,63 @@ var systemStartCmd = &cobra.Command{
 var systemStopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Останавливает демон 'forged'",
+	Long:  "Демон перестает принимать новые запросы и завершается после окончания текущих операций 'up', 'down' и 'build'.",
 	Run: func(cmd *cobra.Command, args []string) {
-		if !isDaemonRunning() {
-			infoLog("Демон 'forged' не запущен.\n")
-			return
-		}
-		infoLog("Останавливаем демон 'forged'...\n")
-		// Самый простой и надежный способ для начала - pkill
-		cmdKill := exec.Command("pkill", "forged")
-		if err := cmdKill.Run(); err != nil {
-			errorLog(os.Stderr, "❌ Не удалось остановить демон (возможно, pkill не установлен или демон уже остановлен): %v\n", err)
+		if err := runStopLogic(cmd.Context()); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'stop': %v\n", err)
 			os.Exit(1)
 		}
-		successLog("✅ Демон успешно остановлен.\n")
 	},
 }
 
+func runStopLogic(ctx context.Context) error {
+	if !isDaemonRunning() {
+		infoLog("Демон 'forged' не запущен.\n")
+		return nil
+	}
+
+	infoCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	info, err := fetchDaemonInfo(infoCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове DaemonInfo: %w", err)
+	}
+
+	infoLog("Останавливаем демон 'forged' (PID %d)...\n", info.GetPid())
+	if ops := info.GetOperations(); len(ops) > 0 {
+		infoLog("Ожидаем завершения операций: %s\n", formatOperations(ops))
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := pb.NewForgeClient(conn).Shutdown(ctx, &pb.ShutdownRequest{}); err != nil {
+		return fmt.Errorf("ошибка при вызове Shutdown: %w", err)
+	}
+
+	// После ответа демону остается только закрыть соединения и хранилище состояния
+	const maxRetries = 10
+	for i := 0; i < maxRetries && isDaemonRunning(); i++ {
+		time.Sleep(500 * time.Millisecond)
+	}
+	if isDaemonRunning() {
+		return errors.New("демон принял запрос на остановку, но продолжает отвечать")
+	}
+
+	successLog("✅ Демон успешно остановлен.\n")
+	return nil
+}
+
 var systemRestartCmd = &cobra.Command{
 	Use:   "restart",
 	Short: "Перезапускает демон 'forged'",
 	Run: func(cmd *cobra.Command, args []string) {
 		systemStopCmd.Run(cmd, args)
-		time.Sleep(2 * time.Second)
 		systemStartCmd.Run(cmd, args)
 	},
-	Aliases: []string{"restart"},
 }
 
 // systemStatusCmd - для проверки статуса демона
@@ -86,20 +126,135 @@ var systemStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Проверяет статус демона 'forged'",
 	Run: func(cmd *cobra.Command, args []string) {
-		if isDaemonRunning() {
-			successLog("✅ Демон 'forged' запущен и работает.\n")
-		} else {
-			infoLog("ℹ️ Демон 'forged' не запущен.\n")
+		if err := runStatusLogic(cmd.Context()); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'system status': %v\n", err)
+			os.Exit(1)
 		}
 	},
 }
 
+func runStatusLogic(ctx context.Context) error {
+	fmt.Printf("Контекст: %s (%s)\n", activeContext, daemonAddress)
+	if !isDaemonRunning() {
+		infoLog("ℹ️ Демон 'forged' не запущен.\n")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	info, err := fetchDaemonInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("демон отвечает, но не вернул сведения о себе: %w", err)
+	}
+
+	successLog("✅ Демон 'forged' запущен и работает.\n")
+
+	apps := "нет"
+	if len(info.GetActiveApps()) > 0 {
+		apps = strings.Join(info.GetActiveApps(), ", ")
+	}
+	operations := "нет"
+	if len(info.GetOperations()) > 0 {
+		operations = formatOperations(info.GetOperations())
+	}
+	statePath := info.GetStatePath()
+	if statePath == "" {
+		statePath = "в памяти процесса"
+	}
+	uptime := time.Duration(info.GetUptimeSeconds()) * time.Second
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Версия:\t%s\n", info.GetVersion())
+	fmt.Fprintf(w, "PID:\t%d\n", info.GetPid())
+	fmt.Fprintf(w, "Адрес:\t%s\n", info.GetListenAddress())
+	fmt.Fprintf(w, "Время работы:\t%s (с %s)\n", uptime, time.Unix(info.GetStartedAt(), 0).Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Runtime:\t%s\n", info.GetRuntime())
+	fmt.Fprintf(w, "Состояние:\t%s, %s\n", info.GetStateBackend(), statePath)
+	fmt.Fprintf(w, "Приложения:\t%s\n", apps)
+	fmt.Fprintf(w, "Операции:\t%s\n", operations)
+	return w.Flush()
+}
+
+func formatOperations(ops []*pb.DaemonOperation) string {
+	parts := make([]string, 0, len(ops))
+	for _, op := range ops {
+		parts = append(parts, fmt.Sprintf("%s (%s)", op.GetAppName(), op.GetOperation()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// systemReconcileCmd - для сверки состояния демона с Docker
+var systemReconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Сверяет состояние демона с контейнерами, сетями и томами Docker",
+	Long:  "Находит ресурсы с метками Forge, которых нет в состоянии, и принимает их под управление, а также удаляет записи о ресурсах, удаленных в обход Forge.",
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if err := runReconcileLogic(cmd.Context(), dryRun); err != nil {
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'reconcile': %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func runReconcileLogic(ctx context.Context, dryRun bool) error {
+	if !isDaemonRunning() {
+		return errors.New("демон 'forged' не запущен. Запустите его с помощью 'forge system start'")
+	}
+
+	conn, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewForgeClient(conn)
+
+	resp, err := client.Reconcile(ctx, &pb.ReconcileRequest{DryRun: dryRun})
+	if err != nil {
+		return fmt.Errorf("ошибка при вызове Reconcile: %w", err)
+	}
+
+	if len(resp.GetAdopted()) == 0 && len(resp.GetPruned()) == 0 {
+		successLog("✅ Состояние демона совпадает с Docker.\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tAPP NAME\tNAME\tTYPE\tID")
+	for _, r := range resp.GetAdopted() {
+		fmt.Fprintf(w, "adopted\t%s\t%s\t%s\t%s\n", r.GetAppName(), r.GetServiceName(), r.GetResourceType(), shortID(r.GetResourceId()))
+	}
+	for _, r := range resp.GetPruned() {
+		fmt.Fprintf(w, "pruned\t%s\t%s\t%s\t%s\n", r.GetAppName(), r.GetServiceName(), r.GetResourceType(), shortID(r.GetResourceId()))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if dryRun {
+		infoLog("\nРежим --dry-run: изменения не применены.\n")
+	} else {
+		successLog("\n✅ Состояние сверено: принято %d, удалено %d.\n", len(resp.GetAdopted()), len(resp.GetPruned()))
+	}
+	return nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
 func init() {
+	systemReconcileCmd.Flags().Bool("dry-run", false, "Только показать изменения, не применяя их")
+
 	// Добавляем дочерние команды к 'system'
 	systemCmd.AddCommand(systemStartCmd)
 	systemCmd.AddCommand(systemStopCmd)
 	systemCmd.AddCommand(systemRestartCmd)
 	systemCmd.AddCommand(systemStatusCmd)
+	systemCmd.AddCommand(systemReconcileCmd)
 
 	// Добавляем команду 'system' к корневой команде
 	rootCmd.AddCommand(systemCmd)
@@ -4,13 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/user"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/waste3d/forge/cmd/forge/cli/helpers"
 	pb "github.com/waste3d/forge/internal/gen/proto"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 var upCmd = &cobra.Command{
@@ -21,24 +20,31 @@ var upCmd = &cobra.Command{
 }
 
 func init() {
+	upCmd.Flags().BoolP("detach", "d", false, "Не ждать завершения: вывести идентификатор задания и вернуться")
 	rootCmd.AddCommand(upCmd)
 }
 
 func runUp(cmd *cobra.Command, args []string) {
-	if err := runUpLogic(); err != nil {
+	detach, _ := cmd.Flags().GetBool("detach")
+
+	detached, err := runUpLogic(cmd.Context(), detach)
+	if err != nil {
 		errorLog(os.Stderr, "\n❌ Ошибка выполнения 'up': %v\n", err)
 		os.Exit(1)
 	}
-	successLog("\n✅ Команда 'up' успешно завершена.\n")
+	if !detached {
+		successLog("\n✅ Команда 'up' успешно завершена.\n")
+	}
 }
 
-func runUpLogic() error {
+// runUpLogic возвращает true, если CLI не дождался завершения задания
+func runUpLogic(ctx context.Context, detach bool) (bool, error) {
 	if isDaemonRunning() {
 		infoLog("Демон 'forged' уже запущен.\n")
 	} else {
 		infoLog("Демон 'forged' не найден. Запускаем его в фоновом режиме...\n")
 		if err := startDaemon(); err != nil {
-			return fmt.Errorf("критическая ошибка запуска демона: %w", err)
+			return false, fmt.Errorf("критическая ошибка запуска демона: %w", err)
 		}
 		time.Sleep(2 * time.Second)
 	}
@@ -47,24 +53,50 @@ func runUpLogic() error {
 	configPath := "forge.yaml"
 	modifiedYamlContent, err := helpers.LoadAndPrepareConfig(configPath)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	conn, err := grpc.Dial(daemonAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := dialDaemon()
 	if err != nil {
-		return fmt.Errorf("не удалось подключиться к демону: %w", err)
+		return false, err
 	}
 	defer conn.Close()
 	client := pb.NewForgeClient(conn)
 
-	req := &pb.UpRequest{ConfigContent: string(modifiedYamlContent)}
+	req := &pb.UpRequest{
+		ConfigContent: string(modifiedYamlContent),
+		ClientUser:    currentUserName(),
+		Detach:        detach,
+	}
+	if wd, err := os.Getwd(); err == nil {
+		req.WorkingDir = wd
+	}
 
 	infoLog("Отправляем Up-запрос демону...\n")
-	stream, err := client.Up(context.Background(), req)
-	if err != nil {
-		return fmt.Errorf("ошибка при вызове Up: %w", err)
+	open := func(ctx context.Context) (pb.Forge_UpClient, error) {
+		stream, err := client.Up(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при вызове Up: %w", err)
+		}
+		return stream, nil
+	}
+
+	if detach {
+		stream, err := open(ctx)
+		if err != nil {
+			return false, err
+		}
+		return true, printDetachedJob(stream)
 	}
 
 	infoLog("Ожидание логов от демона...\n")
-	return PrintLogs(stream)
+	return followJob(ctx, open)
+}
+
+// currentUserName возвращает имя пользователя, запустившего команду, для истории запусков
+func currentUserName() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
 }
@@ -1,20 +1,118 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	pb "github.com/waste3d/forge/internal/gen/proto"
 	"github.com/waste3d/forge/version"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var versionCmd = &cobra.Command{
 	Use:   "version",
-	Short: "Выводит версию приложения",
+	Short: "Выводит версии forge и демона 'forged'",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("forge version %s\n", version.Version)
+		// Расхождение версий эта команда показывает сама
+		versionChecked = true
+
+		fmt.Printf("forge:  %s (API %d, %s %s/%s)\n", version.Version, version.APIVersion, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+		if !isDaemonRunning() {
+			fmt.Printf("forged: не запущен (контекст %s, %s)\n", activeContext, daemonAddress)
+			return
+		}
+
+		resp, err := fetchDaemonVersion(cmd.Context())
+		switch {
+		case status.Code(err) == codes.Unimplemented:
+			fmt.Printf("forged: версия неизвестна: демон старше forge %s\n", version.Version)
+			return
+		case err != nil:
+			errorLog(os.Stderr, "\n❌ Ошибка выполнения 'version': %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("forged: %s (API %d, %s %s), контекст %s\n", resp.GetVersion(), resp.GetApiVersion(), resp.GetGoVersion(), resp.GetPlatform(), activeContext)
+		for _, warning := range compatibilityWarnings(resp) {
+			warnLog(os.Stderr, "⚠️ %s\n", warning)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(versionCmd)
 }
+
+// versionChecked — версия демона сверяется один раз за запуск CLI
+var versionChecked bool
+
+// checkDaemonVersion предупреждает, если демон собран из другой версии Forge.
+// Проверка не должна мешать команде, поэтому ошибки подключения пропускаются:
+// о них сообщит сам запрос.
+func checkDaemonVersion(conn *grpc.ClientConn) {
+	if versionChecked {
+		return
+	}
+	versionChecked = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp, err := pb.NewForgeClient(conn).Version(ctx, &pb.VersionRequest{
+		ClientVersion:    version.Version,
+		ClientApiVersion: version.APIVersion,
+	})
+	if status.Code(err) == codes.Unimplemented {
+		warnLog(os.Stderr, "⚠️ Демон 'forged' не сообщает свою версию: вероятно, он старше forge %s. Обновите демон и выполните 'forge system restart'.\n", version.Version)
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	for _, warning := range compatibilityWarnings(resp) {
+		warnLog(os.Stderr, "⚠️ %s\n", warning)
+	}
+}
+
+func fetchDaemonVersion(ctx context.Context) (*pb.VersionResponse, error) {
+	conn, err := dialDaemon()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return pb.NewForgeClient(conn).Version(ctx, &pb.VersionRequest{
+		ClientVersion:    version.Version,
+		ClientApiVersion: version.APIVersion,
+	})
+}
+
+// compatibilityWarnings описывает расхождения между CLI и демоном
+func compatibilityWarnings(resp *pb.VersionResponse) []string {
+	c := version.Compare(resp.GetVersion(), int(resp.GetApiVersion()), resp.GetFeatures())
+
+	var warnings []string
+	if !c.APICompatible {
+		outdated := "демон"
+		if int(resp.GetApiVersion()) > version.APIVersion {
+			outdated = "forge"
+		}
+		warnings = append(warnings, fmt.Sprintf("API демона несовместимо с CLI: forge %s (API %d), forged %s (API %d). Обновите %s.",
+			version.Version, version.APIVersion, resp.GetVersion(), resp.GetApiVersion(), outdated))
+	} else if !c.SameVersion {
+		warnings = append(warnings, fmt.Sprintf("Версии различаются: forge %s, forged %s.", version.Version, resp.GetVersion()))
+	}
+	if len(c.MissingFeatures) > 0 {
+		warnings = append(warnings, fmt.Sprintf("Демон не поддерживает: %s. Команды, которые их используют, завершатся ошибкой.", strings.Join(c.MissingFeatures, ", ")))
+	}
+	return warnings
+}
@@ -6,25 +6,93 @@ import (
 	"log/slog"
 	"os"
 
+	"github.com/waste3d/forge/internal/config"
 	"github.com/waste3d/forge/internal/constants"
+	"github.com/waste3d/forge/internal/pidfile"
 	"github.com/waste3d/forge/internal/server"
 )
 
 func main() {
-	addrFlag := flag.String("addr", "", "Address for the daemon to listen on. Overrides FORGE_DAEMON_ADDR.")
+	configFlag := flag.String("config", "", "Daemon configuration file. Defaults to ~/.forge/forged.yaml; a missing default file means built-in defaults.")
+	addrFlag := flag.String("addr", "", "Address for the daemon to listen on: unix:///path/to/socket (default ~/.forge/forged.sock) or host:port to opt in to TCP. Overrides FORGE_DAEMON_ADDR.")
+	bindAddrFlag := flag.String("bind-address", "", "Default host address for published container ports (default 127.0.0.1).")
+	allowPublicFlag := flag.Bool("allow-public-bind", false, "Allow publishing container ports beyond the loopback interface for all apps.")
+	stateBackendFlag := flag.String("state-backend", "", "State storage backend: sqlite (requires cgo), file or memory.")
+	statePathFlag := flag.String("state-path", "", "Path to the state database or file. Defaults to ~/.forge/forge.db (sqlite) or ~/.forge/state.json (file).")
+	tlsCertFlag := flag.String("tls-cert", "", "Server TLS certificate (PEM). Enables TLS together with -tls-key.")
+	tlsKeyFlag := flag.String("tls-key", "", "Server TLS private key (PEM).")
+	tlsClientCAFlag := flag.String("tls-client-ca", "", "CA certificate used to verify client certificates. Enables mutual TLS.")
+	tokenFileFlag := flag.String("token-file", "", "File with accepted bearer tokens, one per line. Enables token authentication.")
+	pidFileFlag := flag.String("pid-file", "", "PID file locked while the daemon runs. Defaults to ~/.forge/forged.pid.")
 	flag.Parse()
 
-	listenAddr := *addrFlag
-	if listenAddr == "" {
-		listenAddr = os.Getenv(constants.DaemonAddrEnvVar)
-		if listenAddr == "" {
-			listenAddr = constants.DefaultDemonAddress
+	log.SetFlags(0)
+
+	configPath := *configFlag
+	if configPath == "" {
+		var err error
+		if configPath, err = config.DefaultPath(); err != nil {
+			slog.Error("ошибка инициализации сервера", "error", err)
+			os.Exit(1)
 		}
 	}
 
-	log.SetFlags(0)
+	// Явно заданные флаги и FORGE_DAEMON_ADDR перекрывают файл настроек,
+	// в том числе при его перезагрузке по SIGHUP
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	loadConfig := func() (*config.Config, error) {
+		cfg, err := config.Load(configPath, *configFlag != "")
+		if err != nil {
+			return nil, err
+		}
+
+		if addr := os.Getenv(constants.DaemonAddrEnvVar); addr != "" {
+			cfg.Listen.Address = addr
+		}
+		overrides := map[string]func(){
+			"addr":              func() { cfg.Listen.Address = *addrFlag },
+			"bind-address":      func() { cfg.Ports.BindAddress = *bindAddrFlag },
+			"allow-public-bind": func() { cfg.Ports.AllowPublicBind = *allowPublicFlag },
+			"state-backend":     func() { cfg.State.Backend = *stateBackendFlag },
+			"state-path":        func() { cfg.State.Path = *statePathFlag },
+			"tls-cert":          func() { cfg.Listen.TLSCert = *tlsCertFlag },
+			"tls-key":           func() { cfg.Listen.TLSKey = *tlsKeyFlag },
+			"tls-client-ca":     func() { cfg.Listen.TLSClientCA = *tlsClientCAFlag },
+			"token-file":        func() { cfg.Listen.TokenFile = *tokenFileFlag },
+			"pid-file":          func() { cfg.PIDFile = *pidFileFlag },
+		}
+		for name, apply := range overrides {
+			if setFlags[name] {
+				apply()
+			}
+		}
+
+		if err := cfg.SetDefaults(); err != nil {
+			return nil, err
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		slog.Error("некорректные настройки демона", "error", err)
+		os.Exit(1)
+	}
+
+	pid, err := pidfile.Acquire(cfg.PIDFile)
+	if err != nil {
+		slog.Error("ошибка инициализации сервера", "error", err)
+		os.Exit(1)
+	}
 
-	if err := server.InitializeServer(listenAddr); err != nil {
+	err = server.InitializeServer(cfg, loadConfig)
+	pid.Release()
+	if err != nil {
 		slog.Error("ошибка инициализации сервера", "error", err)
 		os.Exit(1)
 	}
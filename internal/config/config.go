@@ -0,0 +1,348 @@
+// Package config описывает файл настроек демона ~/.forge/forged.yaml.
+// Флаги командной строки forged имеют приоритет над значениями из файла.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/waste3d/forge/internal/logstore"
+	"github.com/waste3d/forge/internal/orchestrator"
+	"github.com/waste3d/forge/internal/pidfile"
+	"github.com/waste3d/forge/internal/state"
+	"github.com/waste3d/forge/internal/transport"
+	ai "github.com/waste3d/forge/openai"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName — имя файла настроек демона в ~/.forge
+const FileName = "forged.yaml"
+
+// Форматы логов демона
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// Config — настройки демона. Изменения в секциях log.level, ports, parallelism и
+// ai применяются по SIGHUP, остальные — только после перезапуска.
+type Config struct {
+	Listen  ListenConfig  `yaml:"listen"`
+	Log     LogConfig     `yaml:"log"`
+	State   StateConfig   `yaml:"state"`
+	Runtime RuntimeConfig `yaml:"runtime"`
+	Ports   PortsConfig   `yaml:"ports"`
+	// Parallelism ограничивает число узлов, образов и контейнеров, которые обрабатываются одновременно. 0 — без ограничений.
+	Parallelism int       `yaml:"parallelism"`
+	AI          ai.Config `yaml:"ai"`
+	PIDFile     string    `yaml:"pidFile"`
+	// ContainerLogs — сохранение логов контейнеров на диск
+	ContainerLogs ContainerLogsConfig `yaml:"containerLogs"`
+
+	path   string // файл, из которого загружены настройки
+	loaded bool   // false, если файла нет и используются значения по умолчанию
+}
+
+type ListenConfig struct {
+	// Address — unix:///path/to/socket или host:port
+	Address     string `yaml:"address"`
+	TLSCert     string `yaml:"tlsCert"`
+	TLSKey      string `yaml:"tlsKey"`
+	TLSClientCA string `yaml:"tlsClientCA"`
+	TokenFile   string `yaml:"tokenFile"`
+}
+
+type LogConfig struct {
+	Format string `yaml:"format"` // json или text
+	Level  string `yaml:"level"`  // debug, info, warn или error
+}
+
+type StateConfig struct {
+	Backend string `yaml:"backend"`
+	Path    string `yaml:"path"`
+}
+
+type RuntimeConfig struct {
+	// Socket — адрес Docker API. Пустое значение означает DOCKER_HOST или сокет по умолчанию.
+	Socket string `yaml:"socket"`
+}
+
+type PortsConfig struct {
+	BindAddress     string `yaml:"bindAddress"`
+	AllowPublicBind bool   `yaml:"allowPublicBind"`
+}
+
+type ContainerLogsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"`
+	// MaxFileSizeMB — размер файла, после которого он сжимается и начинается новый
+	MaxFileSizeMB int `yaml:"maxFileSizeMB"`
+	// MaxServiceSizeMB — сколько сжатых логов хранится для одного сервиса, 0 — без ограничений
+	MaxServiceSizeMB int `yaml:"maxServiceSizeMB"`
+	// MaxAge — сколько хранятся логи, например 168h. 0 — без ограничений.
+	MaxAge time.Duration `yaml:"maxAge"`
+}
+
+// Default возвращает настройки, которыми демон пользуется без файла
+func Default() *Config {
+	return &Config{
+		Log:   LogConfig{Format: LogFormatJSON, Level: "info"},
+		State: StateConfig{Backend: state.DefaultBackend},
+		Ports: PortsConfig{BindAddress: orchestrator.DefaultBindAddress},
+		AI:    ai.DefaultConfig(),
+		ContainerLogs: ContainerLogsConfig{
+			Enabled:          true,
+			MaxFileSizeMB:    10,
+			MaxServiceSizeMB: 100,
+			MaxAge:           7 * 24 * time.Hour,
+		},
+	}
+}
+
+// DefaultPath возвращает путь к файлу настроек по умолчанию: ~/.forge/forged.yaml
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("не удалось определить домашнюю директорию: %w", err)
+	}
+	return filepath.Join(home, ".forge", FileName), nil
+}
+
+// Load читает настройки из файла поверх значений по умолчанию. Если файла нет,
+// возвращаются значения по умолчанию, а при required — ошибка.
+func Load(path string, required bool) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		cfg := Default()
+		cfg.path = path
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл настроек демона: %w", err)
+	}
+
+	cfg, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.path = path
+	cfg.loaded = true
+	return cfg, nil
+}
+
+// Parse разбирает YAML поверх значений по умолчанию. Неизвестные поля — ошибка,
+// чтобы опечатка в имени настройки не проходила незамеченной.
+func Parse(content []byte) (*Config, error) {
+	cfg := Default()
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("ошибка разбора настроек демона: %w", err)
+	}
+	return cfg, nil
+}
+
+// Path возвращает путь к файлу настроек
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Loaded сообщает, прочитаны ли настройки из файла
+func (c *Config) Loaded() bool {
+	return c.loaded
+}
+
+// SetDefaults подставляет значения, которые зависят от окружения: адрес сокета,
+// пути к хранилищу состояния, PID-файлу и логам контейнеров.
+func (c *Config) SetDefaults() error {
+	if c.Listen.Address == "" {
+		c.Listen.Address = transport.DefaultAddress()
+	}
+	if c.State.Backend == "" {
+		c.State.Backend = state.DefaultBackend
+	}
+	if c.State.Path == "" && c.State.Backend != state.BackendMemory {
+		path, err := state.DefaultPath(c.State.Backend)
+		if err != nil {
+			return err
+		}
+		c.State.Path = path
+	}
+	if c.PIDFile == "" {
+		path, err := pidfile.DefaultPath()
+		if err != nil {
+			return err
+		}
+		c.PIDFile = path
+	}
+	if c.ContainerLogs.Dir == "" {
+		dir, err := logstore.DefaultDir()
+		if err != nil {
+			return err
+		}
+		c.ContainerLogs.Dir = dir
+	}
+	return nil
+}
+
+// Validate проверяет настройки и возвращает все найденные ошибки разом
+func (c *Config) Validate() error {
+	var errs []error
+
+	if _, _, err := transport.Parse(c.Listen.Address); err != nil {
+		errs = append(errs, fmt.Errorf("listen.address: %w", err))
+	}
+	if (c.Listen.TLSCert == "") != (c.Listen.TLSKey == "") {
+		errs = append(errs, errors.New("listen.tlsCert и listen.tlsKey задаются только вместе"))
+	}
+	if c.Listen.TLSClientCA != "" && c.Listen.TLSCert == "" {
+		errs = append(errs, errors.New("listen.tlsClientCA требует listen.tlsCert и listen.tlsKey"))
+	}
+	if c.Listen.TokenFile != "" && c.Listen.TLSCert == "" && !transport.IsUnix(c.Listen.Address) {
+		errs = append(errs, errors.New("listen.tokenFile на TCP-адресе требует listen.tlsCert и listen.tlsKey: без TLS токены передаются в открытом виде"))
+	}
+
+	switch c.Log.Format {
+	case LogFormatJSON, LogFormatText:
+	default:
+		errs = append(errs, fmt.Errorf("log.format: неизвестный формат '%s' (доступны: %s, %s)", c.Log.Format, LogFormatJSON, LogFormatText))
+	}
+	if _, err := c.Log.SlogLevel(); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+
+	switch c.State.Backend {
+	case state.BackendSQLite, state.BackendFile, state.BackendMemory:
+	default:
+		errs = append(errs, fmt.Errorf("state.backend: неизвестный бэкенд '%s' (доступны: %s, %s, %s)", c.State.Backend, state.BackendSQLite, state.BackendFile, state.BackendMemory))
+	}
+
+	if c.Runtime.Socket != "" && !strings.Contains(c.Runtime.Socket, "://") {
+		errs = append(errs, fmt.Errorf("runtime.socket: ожидается адрес вида unix:///var/run/docker.sock или tcp://host:port, получено '%s'", c.Runtime.Socket))
+	}
+
+	if net.ParseIP(c.Ports.BindAddress) == nil {
+		errs = append(errs, fmt.Errorf("ports.bindAddress: некорректный IP-адрес '%s'", c.Ports.BindAddress))
+	}
+
+	if c.Parallelism < 0 {
+		errs = append(errs, fmt.Errorf("parallelism: значение не может быть отрицательным (%d)", c.Parallelism))
+	}
+
+	if c.ContainerLogs.MaxFileSizeMB <= 0 {
+		errs = append(errs, fmt.Errorf("containerLogs.maxFileSizeMB: значение должно быть положительным (%d)", c.ContainerLogs.MaxFileSizeMB))
+	}
+	if c.ContainerLogs.MaxServiceSizeMB < 0 {
+		errs = append(errs, fmt.Errorf("containerLogs.maxServiceSizeMB: значение не может быть отрицательным (%d)", c.ContainerLogs.MaxServiceSizeMB))
+	}
+	if c.ContainerLogs.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("containerLogs.maxAge: значение не может быть отрицательным (%s)", c.ContainerLogs.MaxAge))
+	}
+
+	if err := c.AI.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("ai: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Marshal сериализует настройки в YAML
+func (c *Config) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, fmt.Errorf("не удалось сериализовать настройки демона: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// StructuralChanges возвращает секции, которые отличаются в next и не могут
+// быть применены без перезапуска демона
+func (c *Config) StructuralChanges(next *Config) []string {
+	var changed []string
+	if c.Listen != next.Listen {
+		changed = append(changed, "listen")
+	}
+	if c.Log.Format != next.Log.Format {
+		changed = append(changed, "log.format")
+	}
+	if c.State != next.State {
+		changed = append(changed, "state")
+	}
+	if c.Runtime != next.Runtime {
+		changed = append(changed, "runtime")
+	}
+	if c.PIDFile != next.PIDFile {
+		changed = append(changed, "pidFile")
+	}
+	if c.ContainerLogs != next.ContainerLogs {
+		changed = append(changed, "containerLogs")
+	}
+	return changed
+}
+
+// WithReloadable возвращает копию настроек, в которой значения, применимые без
+// перезапуска, взяты из next
+func (c *Config) WithReloadable(next *Config) *Config {
+	merged := *c
+	merged.Log.Level = next.Log.Level
+	merged.Ports = next.Ports
+	merged.Parallelism = next.Parallelism
+	merged.AI = next.AI
+	merged.loaded = next.loaded
+	return &merged
+}
+
+// SlogLevel возвращает уровень логирования для slog
+func (l LogConfig) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return 0, fmt.Errorf("неизвестный уровень '%s' (доступны: debug, info, warn, error)", l.Level)
+	}
+	return level, nil
+}
+
+// StateConfig возвращает настройки хранилища состояния
+func (c *Config) StateConfig() state.Config {
+	return state.Config{Backend: c.State.Backend, Path: c.State.Path}
+}
+
+// Security возвращает настройки TLS и аутентификации gRPC-сервера
+func (c *Config) Security() transport.ServerSecurity {
+	return transport.ServerSecurity{
+		CertFile:     c.Listen.TLSCert,
+		KeyFile:      c.Listen.TLSKey,
+		ClientCAFile: c.Listen.TLSClientCA,
+		TokenFile:    c.Listen.TokenFile,
+	}
+}
+
+// LogStoreConfig возвращает настройки хранилища логов контейнеров
+func (c *Config) LogStoreConfig() logstore.Config {
+	const mb = 1 << 20
+	return logstore.Config{
+		Dir:          c.ContainerLogs.Dir,
+		MaxFileSize:  int64(c.ContainerLogs.MaxFileSizeMB) * mb,
+		MaxTotalSize: int64(c.ContainerLogs.MaxServiceSizeMB) * mb,
+		MaxAge:       c.ContainerLogs.MaxAge,
+	}
+}
+
+// OrchestratorOptions возвращает настройки оркестратора
+func (c *Config) OrchestratorOptions() orchestrator.Options {
+	return orchestrator.Options{
+		BindAddress:     c.Ports.BindAddress,
+		AllowPublicBind: c.Ports.AllowPublicBind,
+		DockerHost:      c.Runtime.Socket,
+		Parallelism:     c.Parallelism,
+	}
+}
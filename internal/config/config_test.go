@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := Load(filepath.Join(dir, FileName), false)
+	if err != nil {
+		t.Fatalf("отсутствующий файл по умолчанию не должен быть ошибкой: %v", err)
+	}
+	if cfg.Loaded() || cfg.Log.Format != LogFormatJSON || cfg.Ports.BindAddress != "127.0.0.1" {
+		t.Errorf("ожидались значения по умолчанию, получено %+v", cfg)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.yaml"), true); err == nil {
+		t.Errorf("ожидалась ошибка для отсутствующего файла, заданного явно")
+	}
+
+	path := filepath.Join(dir, "custom.yaml")
+	content := "log:\n  level: debug\nparallelism: 4\nai:\n  provider: openai\n  model: gpt-4o-mini\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load(path, true)
+	if err != nil {
+		t.Fatalf("не удалось загрузить настройки: %v", err)
+	}
+	if !cfg.Loaded() || cfg.Log.Level != "debug" || cfg.Log.Format != LogFormatJSON || cfg.Parallelism != 4 || cfg.AI.Provider != "openai" {
+		t.Errorf("настройки из файла применены неверно: %+v", cfg)
+	}
+	if cfg.AI.APIKeyEnv == "" {
+		t.Errorf("незаданные в файле поля секции ai должны сохранять значения по умолчанию")
+	}
+
+	if _, err := Parse([]byte("paralelism: 4\n")); err == nil {
+		t.Errorf("ожидалась ошибка для неизвестного поля")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	if err := cfg.SetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("настройки по умолчанию не прошли проверку: %v", err)
+	}
+
+	cfg.Log.Format = "xml"
+	cfg.Listen.TLSCert = "server.pem"
+	cfg.Ports.BindAddress = "localhost"
+	cfg.Parallelism = -1
+	cfg.ContainerLogs.MaxFileSizeMB = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("ожидалась ошибка проверки")
+	}
+	for _, field := range []string{"log.format", "listen.tlsCert", "ports.bindAddress", "parallelism", "containerLogs.maxFileSizeMB"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("в ошибке нет поля %s: %v", field, err)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	current := Default()
+	next := Default()
+	next.Log.Level = "debug"
+	next.Log.Format = LogFormatText
+	next.Parallelism = 2
+	next.State.Path = "/tmp/other.db"
+
+	changed := current.StructuralChanges(next)
+	if strings.Join(changed, ",") != "log.format,state" {
+		t.Errorf("структурные изменения = %v, ожидались log.format и state", changed)
+	}
+
+	merged := current.WithReloadable(next)
+	if merged.Log.Level != "debug" || merged.Parallelism != 2 {
+		t.Errorf("изменения без перезапуска не применены: %+v", merged)
+	}
+	if merged.Log.Format != LogFormatJSON || merged.State.Path != "" {
+		t.Errorf("структурные настройки не должны меняться при перезагрузке: %+v", merged)
+	}
+}
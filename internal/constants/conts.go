@@ -1,6 +1,7 @@
 package constants
 
 const (
-	DefaultDemonAddress = "localhost:9001"
-	DaemonAddrEnvVar    = "FORGE_DAEMON_ADDR"
+	DaemonAddrEnvVar = "FORGE_DAEMON_ADDR"
+	TokenEnvVar      = "FORGE_TOKEN"
+	ContextEnvVar    = "FORGE_CONTEXT"
 )
@@ -0,0 +1,145 @@
+// Package events рассылает события жизненного цикла узлов приложений: запуск,
+// готовность, падение, пересборку и удаление. События публикуют оркестратор и
+// наблюдатель за Docker, а получают подписчики 'forge events'.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Типы событий
+const (
+	TypeStarting  = "starting"  // узел запускается
+	TypeReady     = "ready"     // узел прошел проверку готовности
+	TypeUnhealthy = "unhealthy" // узел не прошел проверку готовности или healthcheck Docker
+	TypeExited    = "exited"    // контейнер узла завершился
+	TypeRebuilt   = "rebuilt"   // образ сервиса пересобран
+	TypeRemoved   = "removed"   // ресурс узла удален
+	// TypeRestarting — контейнер упал, Docker перезапустит его по политике узла
+	TypeRestarting = "restarting"
+	// TypeCrashLoop — контейнер падает несколько раз подряд или исчерпал лимит перезапусков on-failure:N
+	TypeCrashLoop = "crash-loop"
+)
+
+// Источники событий
+const (
+	SourceOrchestrator = "orchestrator"
+	SourceDocker       = "docker"
+)
+
+// subscriberBuffer — сколько событий может ожидать медленного подписчика.
+// Лишние события отбрасываются, чтобы подписчик не задерживал оркестрацию.
+const subscriberBuffer = 256
+
+// Event — событие жизненного цикла узла
+type Event struct {
+	Type        string
+	AppName     string
+	ServiceName string
+	ResourceID  string // идентификатор контейнера, сети или тома
+	ExitCode    int    // код выхода, только для TypeExited
+	// Stopped — контейнер остановлен по запросу (forge down, docker stop), а не упал
+	Stopped bool
+	Message string
+	Source  string
+	Time    time.Time
+}
+
+// Publisher принимает события для рассылки
+type Publisher interface {
+	Publish(Event)
+}
+
+// Filter отбирает события по приложению и сервису. Пустое поле подходит под любое значение.
+type Filter struct {
+	AppName     string
+	ServiceName string
+}
+
+// Match сообщает, подходит ли событие под фильтр
+func (f Filter) Match(e Event) bool {
+	if f.AppName != "" && f.AppName != e.AppName {
+		return false
+	}
+	if f.ServiceName != "" && f.ServiceName != e.ServiceName {
+		return false
+	}
+	return true
+}
+
+type subscriber struct {
+	filter Filter
+	ch     chan Event
+}
+
+// Bus рассылает опубликованные события всем подходящим подписчикам
+type Bus struct {
+	mu     sync.Mutex
+	next   int
+	subs   map[int]*subscriber
+	closed bool
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]*subscriber)}
+}
+
+// Publish отправляет событие подписчикам, не блокируясь на медленных
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe возвращает канал событий, подходящих под фильтр, и функцию отписки.
+// Канал закрывается после отписки или закрытия шины.
+func (b *Bus) Subscribe(filter Filter) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	id := b.next
+	b.next++
+	b.subs[id] = &subscriber{filter: filter, ch: ch}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if s, ok := b.subs[id]; ok {
+				delete(b.subs, id)
+				close(s.ch)
+			}
+		})
+	}
+}
+
+// Close закрывает каналы всех подписчиков. Последующие публикации игнорируются.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for id, s := range b.subs {
+		delete(b.subs, id)
+		close(s.ch)
+	}
+}
@@ -0,0 +1,48 @@
+package events
+
+import "testing"
+
+func TestBusFilter(t *testing.T) {
+	bus := NewBus()
+	all, unsubscribeAll := bus.Subscribe(Filter{})
+	defer unsubscribeAll()
+	api, unsubscribeAPI := bus.Subscribe(Filter{AppName: "shop", ServiceName: "api"})
+
+	bus.Publish(Event{Type: TypeStarting, AppName: "shop", ServiceName: "api"})
+	bus.Publish(Event{Type: TypeStarting, AppName: "shop", ServiceName: "db"})
+	bus.Publish(Event{Type: TypeStarting, AppName: "blog", ServiceName: "api"})
+
+	if len(all) != 3 {
+		t.Errorf("подписчик без фильтра получил %d событий, ожидалось 3", len(all))
+	}
+	if len(api) != 1 {
+		t.Fatalf("подписчик shop/api получил %d событий, ожидалось 1", len(api))
+	}
+	if e := <-api; e.ServiceName != "api" || e.Time.IsZero() {
+		t.Errorf("получено событие %+v, ожидался api с заполненным временем", e)
+	}
+
+	unsubscribeAPI()
+	unsubscribeAPI()
+	if _, ok := <-api; ok {
+		t.Errorf("после отписки канал должен быть закрыт")
+	}
+	bus.Publish(Event{Type: TypeReady, AppName: "shop", ServiceName: "api"})
+}
+
+func TestBusDropsForSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	ch, unsubscribe := bus.Subscribe(Filter{})
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer+10; i++ {
+		bus.Publish(Event{Type: TypeExited})
+	}
+	if len(ch) != subscriberBuffer {
+		t.Errorf("в буфере %d событий, ожидалось %d", len(ch), subscriberBuffer)
+	}
+
+	bus.Close()
+	for range ch {
+	}
+}
@@ -19,12 +19,25 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Forge_Up_FullMethodName     = "/forge.Forge/Up"
-	Forge_Down_FullMethodName   = "/forge.Forge/Down"
-	Forge_Logs_FullMethodName   = "/forge.Forge/Logs"
-	Forge_Status_FullMethodName = "/forge.Forge/Status"
-	Forge_Exec_FullMethodName   = "/forge.Forge/Exec"
-	Forge_Build_FullMethodName  = "/forge.Forge/Build"
+	Forge_Up_FullMethodName               = "/forge.Forge/Up"
+	Forge_Down_FullMethodName             = "/forge.Forge/Down"
+	Forge_Logs_FullMethodName             = "/forge.Forge/Logs"
+	Forge_Status_FullMethodName           = "/forge.Forge/Status"
+	Forge_Exec_FullMethodName             = "/forge.Forge/Exec"
+	Forge_Build_FullMethodName            = "/forge.Forge/Build"
+	Forge_Reconcile_FullMethodName        = "/forge.Forge/Reconcile"
+	Forge_History_FullMethodName          = "/forge.Forge/History"
+	Forge_GetAppliedConfig_FullMethodName = "/forge.Forge/GetAppliedConfig"
+	Forge_ExportState_FullMethodName      = "/forge.Forge/ExportState"
+	Forge_ImportState_FullMethodName      = "/forge.Forge/ImportState"
+	Forge_DaemonInfo_FullMethodName       = "/forge.Forge/DaemonInfo"
+	Forge_Shutdown_FullMethodName         = "/forge.Forge/Shutdown"
+	Forge_GetDaemonConfig_FullMethodName  = "/forge.Forge/GetDaemonConfig"
+	Forge_Version_FullMethodName          = "/forge.Forge/Version"
+	Forge_ListJobs_FullMethodName         = "/forge.Forge/ListJobs"
+	Forge_AttachJob_FullMethodName        = "/forge.Forge/AttachJob"
+	Forge_CancelJob_FullMethodName        = "/forge.Forge/CancelJob"
+	Forge_Events_FullMethodName           = "/forge.Forge/Events"
 )
 
 // ForgeClient is the client API for Forge service.
@@ -43,6 +56,32 @@ type ForgeClient interface {
 	Exec(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ExecPayload, ExecOutput], error)
 	// Сборка образов для сервисов без запуска контейнеров
 	Build(ctx context.Context, in *BuildRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogEntry], error)
+	// Сверка состояния демона с ресурсами Docker, помеченными метками Forge
+	Reconcile(ctx context.Context, in *ReconcileRequest, opts ...grpc.CallOption) (*ReconcileResponse, error)
+	// История запусков приложения
+	History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error)
+	// Конфигурация, примененная последним успешным запуском приложения
+	GetAppliedConfig(ctx context.Context, in *AppliedConfigRequest, opts ...grpc.CallOption) (*AppliedConfigResponse, error)
+	// Снимок всего состояния демона в формате JSON
+	ExportState(ctx context.Context, in *ExportStateRequest, opts ...grpc.CallOption) (*ExportStateResponse, error)
+	// Добавление в состояние записей из снимка, сделанного ExportState
+	ImportState(ctx context.Context, in *ImportStateRequest, opts ...grpc.CallOption) (*ImportStateResponse, error)
+	// Сведения о работающем демоне: версия, время работы, runtime и активные приложения
+	DaemonInfo(ctx context.Context, in *DaemonInfoRequest, opts ...grpc.CallOption) (*DaemonInfoResponse, error)
+	// Корректная остановка демона: новые запросы не принимаются, текущие операции завершаются
+	Shutdown(ctx context.Context, in *ShutdownRequest, opts ...grpc.CallOption) (*ShutdownResponse, error)
+	// Действующие настройки демона (forged.yaml с учетом флагов)
+	GetDaemonConfig(ctx context.Context, in *DaemonConfigRequest, opts ...grpc.CallOption) (*DaemonConfigResponse, error)
+	// Версия демона и возможности API: CLI сверяет их со своими при подключении
+	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error)
+	// Задания демона: долгие операции up и build, которые продолжаются после отключения клиента
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+	// Повторное подключение к выводу задания: сначала буферизованные сообщения, затем новые
+	AttachJob(ctx context.Context, in *AttachJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogEntry], error)
+	// Отмена задания с удалением созданных им ресурсов
+	CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error)
+	// Поток событий жизненного цикла узлов: запуск, готовность, падение, пересборка, удаление
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
 }
 
 type forgeClient struct {
@@ -143,6 +182,154 @@ func (c *forgeClient) Build(ctx context.Context, in *BuildRequest, opts ...grpc.
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Forge_BuildClient = grpc.ServerStreamingClient[LogEntry]
 
+func (c *forgeClient) Reconcile(ctx context.Context, in *ReconcileRequest, opts ...grpc.CallOption) (*ReconcileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReconcileResponse)
+	err := c.cc.Invoke(ctx, Forge_Reconcile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HistoryResponse)
+	err := c.cc.Invoke(ctx, Forge_History_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) GetAppliedConfig(ctx context.Context, in *AppliedConfigRequest, opts ...grpc.CallOption) (*AppliedConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppliedConfigResponse)
+	err := c.cc.Invoke(ctx, Forge_GetAppliedConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) ExportState(ctx context.Context, in *ExportStateRequest, opts ...grpc.CallOption) (*ExportStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportStateResponse)
+	err := c.cc.Invoke(ctx, Forge_ExportState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) ImportState(ctx context.Context, in *ImportStateRequest, opts ...grpc.CallOption) (*ImportStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportStateResponse)
+	err := c.cc.Invoke(ctx, Forge_ImportState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) DaemonInfo(ctx context.Context, in *DaemonInfoRequest, opts ...grpc.CallOption) (*DaemonInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DaemonInfoResponse)
+	err := c.cc.Invoke(ctx, Forge_DaemonInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) Shutdown(ctx context.Context, in *ShutdownRequest, opts ...grpc.CallOption) (*ShutdownResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShutdownResponse)
+	err := c.cc.Invoke(ctx, Forge_Shutdown_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) GetDaemonConfig(ctx context.Context, in *DaemonConfigRequest, opts ...grpc.CallOption) (*DaemonConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DaemonConfigResponse)
+	err := c.cc.Invoke(ctx, Forge_GetDaemonConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VersionResponse)
+	err := c.cc.Invoke(ctx, Forge_Version_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, Forge_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) AttachJob(ctx context.Context, in *AttachJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogEntry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Forge_ServiceDesc.Streams[4], Forge_AttachJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AttachJobRequest, LogEntry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forge_AttachJobClient = grpc.ServerStreamingClient[LogEntry]
+
+func (c *forgeClient) CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelJobResponse)
+	err := c.cc.Invoke(ctx, Forge_CancelJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forgeClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Forge_ServiceDesc.Streams[5], Forge_Events_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forge_EventsClient = grpc.ServerStreamingClient[Event]
+
 // ForgeServer is the server API for Forge service.
 // All implementations must embed UnimplementedForgeServer
 // for forward compatibility.
@@ -159,6 +346,32 @@ type ForgeServer interface {
 	Exec(grpc.BidiStreamingServer[ExecPayload, ExecOutput]) error
 	// Сборка образов для сервисов без запуска контейнеров
 	Build(*BuildRequest, grpc.ServerStreamingServer[LogEntry]) error
+	// Сверка состояния демона с ресурсами Docker, помеченными метками Forge
+	Reconcile(context.Context, *ReconcileRequest) (*ReconcileResponse, error)
+	// История запусков приложения
+	History(context.Context, *HistoryRequest) (*HistoryResponse, error)
+	// Конфигурация, примененная последним успешным запуском приложения
+	GetAppliedConfig(context.Context, *AppliedConfigRequest) (*AppliedConfigResponse, error)
+	// Снимок всего состояния демона в формате JSON
+	ExportState(context.Context, *ExportStateRequest) (*ExportStateResponse, error)
+	// Добавление в состояние записей из снимка, сделанного ExportState
+	ImportState(context.Context, *ImportStateRequest) (*ImportStateResponse, error)
+	// Сведения о работающем демоне: версия, время работы, runtime и активные приложения
+	DaemonInfo(context.Context, *DaemonInfoRequest) (*DaemonInfoResponse, error)
+	// Корректная остановка демона: новые запросы не принимаются, текущие операции завершаются
+	Shutdown(context.Context, *ShutdownRequest) (*ShutdownResponse, error)
+	// Действующие настройки демона (forged.yaml с учетом флагов)
+	GetDaemonConfig(context.Context, *DaemonConfigRequest) (*DaemonConfigResponse, error)
+	// Версия демона и возможности API: CLI сверяет их со своими при подключении
+	Version(context.Context, *VersionRequest) (*VersionResponse, error)
+	// Задания демона: долгие операции up и build, которые продолжаются после отключения клиента
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	// Повторное подключение к выводу задания: сначала буферизованные сообщения, затем новые
+	AttachJob(*AttachJobRequest, grpc.ServerStreamingServer[LogEntry]) error
+	// Отмена задания с удалением созданных им ресурсов
+	CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error)
+	// Поток событий жизненного цикла узлов: запуск, готовность, падение, пересборка, удаление
+	Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error
 	mustEmbedUnimplementedForgeServer()
 }
 
@@ -187,6 +400,45 @@ func (UnimplementedForgeServer) Exec(grpc.BidiStreamingServer[ExecPayload, ExecO
 func (UnimplementedForgeServer) Build(*BuildRequest, grpc.ServerStreamingServer[LogEntry]) error {
 	return status.Errorf(codes.Unimplemented, "method Build not implemented")
 }
+func (UnimplementedForgeServer) Reconcile(context.Context, *ReconcileRequest) (*ReconcileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reconcile not implemented")
+}
+func (UnimplementedForgeServer) History(context.Context, *HistoryRequest) (*HistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method History not implemented")
+}
+func (UnimplementedForgeServer) GetAppliedConfig(context.Context, *AppliedConfigRequest) (*AppliedConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAppliedConfig not implemented")
+}
+func (UnimplementedForgeServer) ExportState(context.Context, *ExportStateRequest) (*ExportStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportState not implemented")
+}
+func (UnimplementedForgeServer) ImportState(context.Context, *ImportStateRequest) (*ImportStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportState not implemented")
+}
+func (UnimplementedForgeServer) DaemonInfo(context.Context, *DaemonInfoRequest) (*DaemonInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DaemonInfo not implemented")
+}
+func (UnimplementedForgeServer) Shutdown(context.Context, *ShutdownRequest) (*ShutdownResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Shutdown not implemented")
+}
+func (UnimplementedForgeServer) GetDaemonConfig(context.Context, *DaemonConfigRequest) (*DaemonConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDaemonConfig not implemented")
+}
+func (UnimplementedForgeServer) Version(context.Context, *VersionRequest) (*VersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
+}
+func (UnimplementedForgeServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedForgeServer) AttachJob(*AttachJobRequest, grpc.ServerStreamingServer[LogEntry]) error {
+	return status.Errorf(codes.Unimplemented, "method AttachJob not implemented")
+}
+func (UnimplementedForgeServer) CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (UnimplementedForgeServer) Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
 func (UnimplementedForgeServer) mustEmbedUnimplementedForgeServer() {}
 func (UnimplementedForgeServer) testEmbeddedByValue()               {}
 
@@ -284,6 +536,226 @@ func _Forge_Build_Handler(srv interface{}, stream grpc.ServerStream) error {
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Forge_BuildServer = grpc.ServerStreamingServer[LogEntry]
 
+func _Forge_Reconcile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconcileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).Reconcile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_Reconcile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).Reconcile(ctx, req.(*ReconcileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_History_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).History(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_GetAppliedConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppliedConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).GetAppliedConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_GetAppliedConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).GetAppliedConfig(ctx, req.(*AppliedConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_ExportState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).ExportState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_ExportState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).ExportState(ctx, req.(*ExportStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_ImportState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).ImportState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_ImportState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).ImportState(ctx, req.(*ImportStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_DaemonInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DaemonInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).DaemonInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_DaemonInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).DaemonInfo(ctx, req.(*DaemonInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_Shutdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShutdownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).Shutdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_Shutdown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).Shutdown(ctx, req.(*ShutdownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_GetDaemonConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DaemonConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).GetDaemonConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_GetDaemonConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).GetDaemonConfig(ctx, req.(*DaemonConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_Version_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).Version(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_AttachJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AttachJobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ForgeServer).AttachJob(m, &grpc.GenericServerStream[AttachJobRequest, LogEntry]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forge_AttachJobServer = grpc.ServerStreamingServer[LogEntry]
+
+func _Forge_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForgeServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Forge_CancelJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForgeServer).CancelJob(ctx, req.(*CancelJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forge_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ForgeServer).Events(m, &grpc.GenericServerStream[EventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forge_EventsServer = grpc.ServerStreamingServer[Event]
+
 // Forge_ServiceDesc is the grpc.ServiceDesc for Forge service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -299,6 +771,50 @@ var Forge_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Status",
 			Handler:    _Forge_Status_Handler,
 		},
+		{
+			MethodName: "Reconcile",
+			Handler:    _Forge_Reconcile_Handler,
+		},
+		{
+			MethodName: "History",
+			Handler:    _Forge_History_Handler,
+		},
+		{
+			MethodName: "GetAppliedConfig",
+			Handler:    _Forge_GetAppliedConfig_Handler,
+		},
+		{
+			MethodName: "ExportState",
+			Handler:    _Forge_ExportState_Handler,
+		},
+		{
+			MethodName: "ImportState",
+			Handler:    _Forge_ImportState_Handler,
+		},
+		{
+			MethodName: "DaemonInfo",
+			Handler:    _Forge_DaemonInfo_Handler,
+		},
+		{
+			MethodName: "Shutdown",
+			Handler:    _Forge_Shutdown_Handler,
+		},
+		{
+			MethodName: "GetDaemonConfig",
+			Handler:    _Forge_GetDaemonConfig_Handler,
+		},
+		{
+			MethodName: "Version",
+			Handler:    _Forge_Version_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _Forge_ListJobs_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _Forge_CancelJob_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -322,6 +838,16 @@ var Forge_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Forge_Build_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "AttachJob",
+			Handler:       _Forge_AttachJob_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Events",
+			Handler:       _Forge_Events_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "forge.proto",
 }
@@ -0,0 +1,317 @@
+// Package jobs выполняет долгие операции демона (up, build) независимо от
+// клиентского соединения. Сообщения задания буферизуются, поэтому клиент может
+// отключиться и позже снова подключиться к выводу через 'forge attach'.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/waste3d/forge/internal/gen/proto"
+)
+
+// Статусы задания
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+const (
+	// maxBufferedEntries — сколько последних сообщений задания хранится для attach
+	maxBufferedEntries = 10000
+	// retention — сколько хранятся сведения о завершенных заданиях
+	retention = time.Hour
+	// maxFinished — сколько завершенных заданий хранится не дольше retention
+	maxFinished = 50
+)
+
+// ErrNotFound возвращается, если задания с таким идентификатором нет
+var ErrNotFound = errors.New("задание не найдено")
+
+// Info — сведения о задании в момент запроса
+type Info struct {
+	ID         string
+	Kind       string // up или build
+	AppName    string
+	RunID      string // запуск 'forge up', связанный с заданием
+	Status     string
+	Err        error
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Job — выполняемая или завершенная операция демона. Job реализует отправку
+// сообщений оркестратора: все сообщения попадают в буфер задания.
+type Job struct {
+	id        string
+	kind      string
+	appName   string
+	createdAt time.Time
+	cancel    context.CancelFunc
+
+	mu         sync.Mutex
+	entries    []*pb.LogEntry
+	dropped    int           // сколько сообщений вытеснено из буфера
+	changed    chan struct{} // закрывается при каждом новом сообщении и при завершении
+	status     string
+	err        error
+	finishedAt time.Time
+	runID      string
+}
+
+// ID возвращает идентификатор задания
+func (j *Job) ID() string {
+	return j.id
+}
+
+// SetRunID связывает задание с запуском 'forge up'
+func (j *Job) SetRunID(runID string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.runID = runID
+}
+
+// Send добавляет сообщение в буфер задания и будит подключенных клиентов
+func (j *Job) Send(entry *pb.LogEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry.JobId = j.id
+	j.entries = append(j.entries, entry)
+	if len(j.entries) > maxBufferedEntries {
+		drop := len(j.entries) - maxBufferedEntries
+		j.entries = append([]*pb.LogEntry(nil), j.entries[drop:]...)
+		j.dropped += drop
+	}
+	j.notifyLocked()
+	return nil
+}
+
+// Follow передает в send все буферизованные сообщения задания, затем новые,
+// пока задание не завершится или не будет отменен ctx. Ошибку самого задания
+// возвращает Err.
+func (j *Job) Follow(ctx context.Context, send func(*pb.LogEntry) error) error {
+	next := 0 // номер следующего сообщения с учетом вытесненных
+	for {
+		j.mu.Lock()
+		if next < j.dropped {
+			next = j.dropped
+		}
+		batch := append([]*pb.LogEntry(nil), j.entries[next-j.dropped:]...)
+		next += len(batch)
+		finished := j.status != StatusRunning
+		changed := j.changed
+		j.mu.Unlock()
+
+		for _, entry := range batch {
+			if err := send(entry); err != nil {
+				return err
+			}
+		}
+		if finished {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Cancel отменяет контекст задания. Возвращает false, если задание уже завершено.
+func (j *Job) Cancel() bool {
+	j.mu.Lock()
+	running := j.status == StatusRunning
+	j.mu.Unlock()
+
+	if running {
+		j.cancel()
+	}
+	return running
+}
+
+// Err возвращает ошибку завершенного задания
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Info возвращает сведения о задании
+func (j *Job) Info() Info {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return Info{
+		ID:         j.id,
+		Kind:       j.kind,
+		AppName:    j.appName,
+		RunID:      j.runID,
+		Status:     j.status,
+		Err:        j.err,
+		CreatedAt:  j.createdAt,
+		FinishedAt: j.finishedAt,
+	}
+}
+
+func (j *Job) finish(ctx context.Context, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	switch {
+	case err == nil:
+		j.status = StatusSucceeded
+	case ctx.Err() != nil:
+		j.status = StatusCanceled
+	default:
+		j.status = StatusFailed
+	}
+	j.err = err
+	j.finishedAt = time.Now()
+	j.notifyLocked()
+}
+
+func (j *Job) notifyLocked() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// Manager хранит задания демона
+type Manager struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+	wg   sync.WaitGroup
+}
+
+func NewManager() *Manager {
+	return &Manager{jobs: make(map[string]*Job)}
+}
+
+// Start запускает run в отдельной горутине как задание kind над приложением
+// appName. Контекст run отменяется только через Cancel, а не при отключении клиента.
+func (m *Manager) Start(kind, appName string, run func(ctx context.Context, job *Job) error) *Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		id:        newID(),
+		kind:      kind,
+		appName:   appName,
+		createdAt: time.Now(),
+		cancel:    cancel,
+		changed:   make(chan struct{}),
+		status:    StatusRunning,
+	}
+
+	m.mu.Lock()
+	m.pruneLocked()
+	m.jobs[job.id] = job
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer cancel()
+		job.finish(ctx, run(ctx, job))
+	}()
+	return job
+}
+
+// Get ищет задание по идентификатору или его однозначному префиксу
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if job, ok := m.jobs[id]; ok {
+		return job, nil
+	}
+
+	var found *Job
+	for jobID, job := range m.jobs {
+		if id == "" || !strings.HasPrefix(jobID, id) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("идентификатору '%s' соответствует несколько заданий, укажите его полностью", id)
+		}
+		found = job
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return found, nil
+}
+
+// List возвращает сведения о заданиях, начиная с последнего
+func (m *Manager) List() []Info {
+	m.mu.Lock()
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	m.mu.Unlock()
+
+	infos := make([]Info, 0, len(jobs))
+	for _, job := range jobs {
+		infos = append(infos, job.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].CreatedAt.After(infos[j].CreatedAt) })
+	return infos
+}
+
+// CancelAll отменяет все выполняемые задания
+func (m *Manager) CancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		job.Cancel()
+	}
+}
+
+// Wait ждет завершения всех заданий или отмены ctx
+func (m *Manager) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pruneLocked удаляет сведения о давно завершенных заданиях
+func (m *Manager) pruneLocked() {
+	var finished []Info
+	for _, job := range m.jobs {
+		if info := job.Info(); info.Status != StatusRunning {
+			finished = append(finished, info)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].FinishedAt.After(finished[j].FinishedAt) })
+
+	for i, info := range finished {
+		if i >= maxFinished || time.Since(info.FinishedAt) > retention {
+			delete(m.jobs, info.ID)
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
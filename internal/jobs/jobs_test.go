@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/waste3d/forge/internal/gen/proto"
+)
+
+func TestFollowReplaysBufferedEntries(t *testing.T) {
+	m := NewManager()
+	proceed := make(chan struct{})
+
+	job := m.Start("up", "shop", func(ctx context.Context, job *Job) error {
+		job.Send(&pb.LogEntry{Message: "первое"})
+		<-proceed
+		job.Send(&pb.LogEntry{Message: "второе"})
+		return errors.New("порт занят")
+	})
+
+	var first []string
+	ctx, cancel := context.WithCancel(context.Background())
+	err := job.Follow(ctx, func(e *pb.LogEntry) error {
+		first = append(first, e.GetMessage())
+		cancel() // клиент отключился после первого сообщения
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || len(first) != 1 {
+		t.Fatalf("первое подключение: %v, сообщения %v", err, first)
+	}
+
+	close(proceed)
+	var replay []string
+	if err := job.Follow(context.Background(), func(e *pb.LogEntry) error {
+		if e.GetJobId() != job.ID() {
+			t.Errorf("у сообщения не указано задание: %+v", e)
+		}
+		replay = append(replay, e.GetMessage())
+		return nil
+	}); err != nil {
+		t.Fatalf("повторное подключение: %v", err)
+	}
+	if len(replay) != 2 || replay[0] != "первое" || replay[1] != "второе" {
+		t.Errorf("повторное подключение получило %v, ожидались оба сообщения", replay)
+	}
+
+	info := job.Info()
+	if info.Status != StatusFailed || info.Err == nil || info.Err.Error() != "порт занят" || info.FinishedAt.IsZero() {
+		t.Errorf("сведения о задании: %+v", info)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	m := NewManager()
+	cleaned := make(chan struct{})
+
+	job := m.Start("up", "shop", func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		close(cleaned)
+		return ctx.Err()
+	})
+
+	found, err := m.Get(job.ID()[:4])
+	if err != nil || found != job {
+		t.Fatalf("поиск по префиксу: %v", err)
+	}
+	if _, err := m.Get("нет-такого"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ожидалась ErrNotFound, получено %v", err)
+	}
+
+	if !job.Cancel() {
+		t.Fatal("выполняемое задание не отменилось")
+	}
+	<-cleaned
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.Wait(ctx); err != nil {
+		t.Fatalf("задание не завершилось после отмены: %v", err)
+	}
+	if status := job.Info().Status; status != StatusCanceled {
+		t.Errorf("статус = %s, ожидался %s", status, StatusCanceled)
+	}
+	if job.Cancel() {
+		t.Errorf("завершенное задание не должно отменяться повторно")
+	}
+	if jobs := m.List(); len(jobs) != 1 || jobs[0].ID != job.ID() {
+		t.Errorf("список заданий: %+v", jobs)
+	}
+}
@@ -0,0 +1,541 @@
+// Package logstore хранит вывод контейнеров на диске, чтобы логи переживали
+// удаление контейнера: 'forge down', пересоздание при 'forge up' или падение.
+//
+// Каждый экземпляр контейнера пишется в свою директорию:
+//
+//	<dir>/<app>/<service>/<время создания>-<id>/current.log
+//	<dir>/<app>/<service>/<время создания>-<id>/000001.log.gz
+//
+// current.log дописывается, пока контейнер работает. Когда файл превышает
+// MaxFileSize или вывод контейнера заканчивается, он сжимается в очередной
+// сегмент. Старые сегменты удаляются по возрасту и общему размеру сервиса.
+package logstore
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	currentFile = "current.log"
+	// formatFile хранит формат логов контейнера, чтобы сохраненные записи
+	// разбирались так же, как записи работающего контейнера
+	formatFile    = "format"
+	segmentSuffix = ".log.gz"
+	// instanceTimeLayout — формат времени создания контейнера в имени директории.
+	// Имена сортируются так же, как время.
+	instanceTimeLayout = "20060102T150405.000000000Z"
+	// idLength — сколько символов идентификатора контейнера входит в имя директории
+	idLength = 12
+)
+
+// Config — настройки хранения логов
+type Config struct {
+	Dir string
+	// MaxFileSize — размер current.log в байтах, после которого он сжимается в сегмент
+	MaxFileSize int64
+	// MaxTotalSize — сколько байт логов хранится для одного сервиса, 0 — без ограничений
+	MaxTotalSize int64
+	// MaxAge — сколько хранятся сегменты, 0 — без ограничений
+	MaxAge time.Duration
+}
+
+// Record — строка вывода контейнера
+type Record struct {
+	Time    time.Time
+	Stream  string // stdout или stderr
+	Message string
+}
+
+// Instance — сохраненные логи одного экземпляра контейнера
+type Instance struct {
+	AppName     string
+	ServiceName string
+	ContainerID string // первые 12 символов идентификатора
+	Created     time.Time
+	// LogFormat — формат логов контейнера (json, logfmt или text), пусто — не указан
+	LogFormat string
+	dir       string
+}
+
+// Store — хранилище логов в директории Config.Dir
+type Store struct {
+	cfg Config
+
+	mu sync.Mutex
+	// active — директории экземпляров, в которые сейчас идет запись
+	active map[string]bool
+}
+
+// Open создает директорию хранилища, если ее еще нет
+func Open(cfg Config) (*Store, error) {
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию логов %s: %w", cfg.Dir, err)
+	}
+	return &Store{cfg: cfg, active: make(map[string]bool)}, nil
+}
+
+// DefaultDir возвращает директорию логов по умолчанию: ~/.forge/logs
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("не удалось определить домашнюю директорию: %w", err)
+	}
+	return filepath.Join(home, ".forge", "logs"), nil
+}
+
+// safeName делает имя приложения или сервиса пригодным для имени директории
+func safeName(name string) string {
+	name = strings.NewReplacer("/", "_", `\`, "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		return "_" + name
+	}
+	return name
+}
+
+func shortID(id string) string {
+	if len(id) > idLength {
+		return id[:idLength]
+	}
+	return id
+}
+
+func (s *Store) serviceDir(appName, serviceName string) string {
+	return filepath.Join(s.cfg.Dir, safeName(appName), safeName(serviceName))
+}
+
+// Services возвращает сервисы приложения, для которых сохранены логи
+func (s *Store) Services(appName string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.cfg.Dir, safeName(appName)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать логи приложения: %w", err)
+	}
+	var services []string
+	for _, e := range entries {
+		if e.IsDir() {
+			services = append(services, e.Name())
+		}
+	}
+	return services, nil
+}
+
+// Instances возвращает экземпляры контейнеров сервиса от старых к новым
+func (s *Store) Instances(appName, serviceName string) ([]Instance, error) {
+	dir := s.serviceDir(appName, serviceName)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать логи сервиса: %w", err)
+	}
+
+	var instances []Instance
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		created, id, ok := strings.Cut(e.Name(), "-")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(instanceTimeLayout, created)
+		if err != nil {
+			continue
+		}
+		inst := Instance{
+			AppName:     appName,
+			ServiceName: serviceName,
+			ContainerID: id,
+			Created:     t,
+			dir:         filepath.Join(dir, e.Name()),
+		}
+		if format, err := os.ReadFile(filepath.Join(inst.dir, formatFile)); err == nil {
+			inst.LogFormat = strings.TrimSpace(string(format))
+		}
+		instances = append(instances, inst)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].dir < instances[j].dir })
+	return instances, nil
+}
+
+// segments возвращает сжатые сегменты экземпляра по порядку записи
+func segments(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Read передает fn записи экземпляра по порядку: сначала сегменты, затем current.log
+func (s *Store) Read(inst Instance, fn func(Record) error) error {
+	paths, err := segments(inst.dir)
+	if err != nil {
+		return err
+	}
+	paths = append(paths, filepath.Join(inst.dir, currentFile))
+
+	for _, path := range paths {
+		if err := readFile(path, fn); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("не удалось прочитать %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func readFile(path string, fn func(Record) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, segmentSuffix) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		rec, ok := parseRecord(scanner.Text())
+		if !ok {
+			continue
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// formatRecord и parseRecord задают формат строки файла: "<время RFC3339Nano> <поток> <сообщение>"
+func formatRecord(r Record) string {
+	return r.Time.UTC().Format(time.RFC3339Nano) + " " + r.Stream + " " + r.Message + "\n"
+}
+
+func parseRecord(line string) (Record, bool) {
+	ts, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return Record{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return Record{}, false
+	}
+	stream, message, _ := strings.Cut(rest, " ")
+	return Record{Time: t, Stream: stream, Message: message}, true
+}
+
+// Writer дописывает вывод одного экземпляра контейнера
+type Writer struct {
+	store    *Store
+	dir      string
+	file     *os.File
+	size     int64
+	lastTime time.Time
+}
+
+// Create открывает запись логов контейнера. Если логи этого контейнера уже
+// есть, например после перезапуска демона или контейнера, запись продолжается.
+func (s *Store) Create(appName, serviceName, containerID string, created time.Time, logFormat string) (*Writer, error) {
+	serviceDir := s.serviceDir(appName, serviceName)
+	dir := filepath.Join(serviceDir, created.UTC().Format(instanceTimeLayout)+"-"+shortID(containerID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[dir] {
+		return nil, fmt.Errorf("логи контейнера %s уже записываются", shortID(containerID))
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию логов: %w", err)
+	}
+	if logFormat != "" {
+		if err := os.WriteFile(filepath.Join(dir, formatFile), []byte(logFormat+"\n"), 0600); err != nil {
+			return nil, fmt.Errorf("не удалось сохранить формат логов: %w", err)
+		}
+	}
+
+	w := &Writer{store: s, dir: dir}
+	if err := w.findLastTime(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, currentFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл логов: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.file = f
+	w.size = info.Size()
+	s.active[dir] = true
+	return w, nil
+}
+
+// findLastTime определяет время последней записанной строки: с него
+// продолжается чтение вывода контейнера, чтобы не записать строки дважды
+func (w *Writer) findLastTime() error {
+	paths, err := segments(w.dir)
+	if err != nil {
+		return err
+	}
+	current := filepath.Join(w.dir, currentFile)
+	if info, err := os.Stat(current); err == nil && info.Size() > 0 {
+		paths = []string{current}
+	} else if len(paths) > 0 {
+		paths = paths[len(paths)-1:]
+	} else {
+		return nil
+	}
+
+	err = readFile(paths[0], func(r Record) error {
+		w.lastTime = r.Time
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("не удалось прочитать сохраненные логи: %w", err)
+	}
+	return nil
+}
+
+// LastTime возвращает время последней записанной строки, нулевое — если строк нет
+func (w *Writer) LastTime() time.Time {
+	return w.lastTime
+}
+
+// Write дописывает строку и сжимает файл в сегмент, когда он превышает MaxFileSize
+func (w *Writer) Write(r Record) error {
+	n, err := w.file.WriteString(formatRecord(r))
+	if err != nil {
+		return fmt.Errorf("не удалось записать лог: %w", err)
+	}
+	w.size += int64(n)
+	w.lastTime = r.Time
+
+	if w.store.cfg.MaxFileSize > 0 && w.size >= w.store.cfg.MaxFileSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(filepath.Join(w.dir, currentFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("не удалось открыть файл логов: %w", err)
+		}
+		w.file = f
+		w.size = 0
+	}
+	return nil
+}
+
+// rotate закрывает current.log и сжимает его в очередной сегмент
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	return compressCurrent(w.dir)
+}
+
+// Close прекращает запись, оставляя current.log: контейнер еще работает, и
+// запись продолжится после перезапуска демона
+func (w *Writer) Close() error {
+	defer w.release()
+	return w.file.Close()
+}
+
+// Finish прекращает запись и сжимает current.log: вывод контейнера закончился
+func (w *Writer) Finish() error {
+	defer w.release()
+	return w.rotate()
+}
+
+func (w *Writer) release() {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	delete(w.store.active, w.dir)
+}
+
+// compressCurrent сжимает current.log экземпляра в сегмент со следующим номером
+func compressCurrent(dir string) error {
+	current := filepath.Join(dir, currentFile)
+	info, err := os.Stat(current)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return os.Remove(current)
+	}
+
+	paths, err := segments(dir)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if len(paths) > 0 {
+		fmt.Sscanf(filepath.Base(paths[len(paths)-1]), "%06d", &next)
+		next++
+	}
+	segment := filepath.Join(dir, fmt.Sprintf("%06d%s", next, segmentSuffix))
+
+	if err := gzipFile(current, segment); err != nil {
+		os.Remove(segment)
+		return fmt.Errorf("не удалось сжать файл логов: %w", err)
+	}
+	return os.Remove(current)
+}
+
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Prune сжимает логи контейнеров, запись которых прервалась, и удаляет
+// сегменты старше MaxAge, а затем самые старые сегменты каждого сервиса,
+// пока их общий размер превышает MaxTotalSize
+func (s *Store) Prune() error {
+	apps, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать директорию логов: %w", err)
+	}
+
+	var errs []error
+	for _, app := range apps {
+		if !app.IsDir() {
+			continue
+		}
+		services, err := os.ReadDir(filepath.Join(s.cfg.Dir, app.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, service := range services {
+			if !service.IsDir() {
+				continue
+			}
+			if err := s.pruneService(filepath.Join(s.cfg.Dir, app.Name(), service.Name())); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) pruneService(serviceDir string) error {
+	entries, err := os.ReadDir(serviceDir)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type segmentFile struct {
+		path string
+		size int64
+	}
+	var files []segmentFile
+	var total int64
+	deadline := time.Now().Add(-s.cfg.MaxAge)
+
+	// Имена директорий и сегментов сортируются по времени, поэтому files — от старых к новым
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(serviceDir, e.Name())
+		if s.active[dir] {
+			if info, err := os.Stat(filepath.Join(dir, currentFile)); err == nil {
+				total += info.Size()
+			}
+		} else if err := compressCurrent(dir); err != nil {
+			return err
+		}
+
+		paths, err := segments(dir)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if s.cfg.MaxAge > 0 && info.ModTime().Before(deadline) {
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+				continue
+			}
+			files = append(files, segmentFile{path: path, size: info.Size()})
+			total += info.Size()
+		}
+	}
+
+	for _, f := range files {
+		if s.cfg.MaxTotalSize <= 0 || total <= s.cfg.MaxTotalSize {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			return err
+		}
+		total -= f.size
+	}
+
+	// Директории экземпляров без логов больше не нужны
+	for _, e := range entries {
+		dir := filepath.Join(serviceDir, e.Name())
+		if !e.IsDir() || s.active[dir] {
+			continue
+		}
+		if paths, err := segments(dir); err == nil && len(paths) == 0 {
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package logstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriterRotateAndRead(t *testing.T) {
+	store, err := Open(Config{Dir: t.TempDir(), MaxFileSize: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	start := created.Add(time.Second)
+
+	w, err := store.Create("shop", "api", "0123456789abcdef", created, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create("shop", "api", "0123456789abcdef", created, "json"); err == nil {
+		t.Error("ожидалась ошибка при повторной записи логов того же контейнера")
+	}
+	for i := 0; i < 10; i++ {
+		if err := w.Write(Record{Time: start.Add(time.Duration(i) * time.Second), Stream: "stdout", Message: fmt.Sprintf("строка %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Демон остановлен, пока контейнер работает: current.log остается, запись продолжается
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w, err = store.Create("shop", "api", "0123456789abcdef", created, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := start.Add(9 * time.Second); !w.LastTime().Equal(want) {
+		t.Errorf("LastTime = %s, ожидалось %s", w.LastTime(), want)
+	}
+	if err := w.Write(Record{Time: start.Add(10 * time.Second), Stream: "stderr", Message: "последняя"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	instances, err := store.Instances("shop", "api")
+	if err != nil || len(instances) != 1 {
+		t.Fatalf("экземпляры = %+v, %v", instances, err)
+	}
+	inst := instances[0]
+	if inst.ContainerID != "0123456789ab" || !inst.Created.Equal(created) || inst.LogFormat != "json" {
+		t.Errorf("экземпляр разобран неверно: %+v", inst)
+	}
+	if _, err := os.Stat(filepath.Join(inst.dir, currentFile)); !os.IsNotExist(err) {
+		t.Errorf("после Finish current.log должен быть сжат")
+	}
+	if paths, _ := segments(inst.dir); len(paths) < 2 {
+		t.Errorf("ожидалось несколько сегментов после ротации, получено %v", paths)
+	}
+
+	var got []Record
+	if err := store.Read(inst, func(r Record) error {
+		got = append(got, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 11 || got[0].Message != "строка 0" || got[10].Message != "последняя" || got[10].Stream != "stderr" {
+		t.Errorf("прочитано %+v", got)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	store, err := Open(Config{Dir: t.TempDir(), MaxTotalSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb"} {
+		w, err := store.Create("shop", "api", id, created.Add(time.Duration(i)*time.Hour), "logfmt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(Record{Time: created, Stream: "stdout", Message: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			// Запись прервалась без Finish: Prune должен сжать current.log
+			w.Close()
+		} else {
+			defer w.Close()
+		}
+	}
+
+	if err := store.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	instances, err := store.Instances("shop", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Логи старого контейнера удалены по размеру, логи активного остаются
+	if len(instances) != 1 || instances[0].ContainerID != "bbbbbbbbbbbb" {
+		t.Errorf("после очистки остались %+v", instances)
+	}
+}
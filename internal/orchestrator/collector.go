@@ -0,0 +1,203 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/waste3d/forge/internal/logstore"
+)
+
+// logPruneInterval — как часто удаляются логи сверх лимитов хранения
+const logPruneInterval = time.Hour
+
+// LogCollector сохраняет вывод контейнеров Forge в хранилище логов, чтобы он
+// оставался доступен после удаления контейнера. О запусках контейнеров он
+// узнает от демона через ContainerStarted.
+type LogCollector struct {
+	store  *logstore.Store
+	docker client.APIClient
+	logger *slog.Logger
+	// starts сообщает Run, что в pending появились контейнеры
+	starts chan struct{}
+
+	mu sync.Mutex
+	// pending — запущенные контейнеры, запись логов которых еще не начата.
+	// Очередь не ограничена: потерянный запуск означал бы потерянные логи.
+	pending []string
+	// capturing — контейнеры, вывод которых записывается. true — контейнер
+	// запущен снова, и после окончания записи ее нужно начать заново.
+	capturing map[string]bool
+	wg        sync.WaitGroup
+}
+
+func NewLogCollector(store *logstore.Store, docker client.APIClient, logger *slog.Logger) *LogCollector {
+	return &LogCollector{
+		store:     store,
+		docker:    docker,
+		logger:    logger.With("component", "log-collector"),
+		starts:    make(chan struct{}, 1),
+		capturing: make(map[string]bool),
+	}
+}
+
+// ContainerStarted сообщает о запуске контейнера Forge, вывод которого нужно сохранять
+func (c *LogCollector) ContainerStarted(containerID string) {
+	c.mu.Lock()
+	c.pending = append(c.pending, containerID)
+	c.mu.Unlock()
+
+	select {
+	case c.starts <- struct{}{}:
+	default:
+	}
+}
+
+// Run начинает запись логов работающих контейнеров и продолжает ее для
+// запускаемых, пока не будет отменен ctx
+func (c *LogCollector) Run(ctx context.Context) {
+	defer c.wg.Wait()
+
+	// Вывод, появившийся, пока демон не работал, дописывается с места остановки,
+	// в том числе у контейнеров, которые за это время завершились
+	containers, err := c.docker.ContainerList(ctx, container.ListOptions{All: true, Filters: managedFilter()})
+	if err != nil {
+		c.logger.Warn("не удалось получить список контейнеров для записи логов", "error", err)
+	}
+	for _, ctr := range containers {
+		c.capture(ctx, ctr.ID)
+	}
+	c.prune()
+
+	ticker := time.NewTicker(logPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.starts:
+			c.mu.Lock()
+			ids := c.pending
+			c.pending = nil
+			c.mu.Unlock()
+			for _, id := range ids {
+				c.capture(ctx, id)
+			}
+		case <-ticker.C:
+			c.prune()
+		}
+	}
+}
+
+func (c *LogCollector) prune() {
+	if err := c.store.Prune(); err != nil {
+		c.logger.Error("не удалось очистить старые логи", "error", err)
+	}
+}
+
+// capture записывает вывод контейнера в отдельной горутине. Если запись уже
+// идет, она будет начата заново после окончания текущей: контейнер перезапущен.
+func (c *LogCollector) capture(ctx context.Context, containerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.capturing[containerID]; ok {
+		c.capturing[containerID] = true
+		return
+	}
+	c.capturing[containerID] = false
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			if err := c.copyLogs(ctx, containerID); err != nil && ctx.Err() == nil {
+				c.logger.Error("не удалось сохранить логи контейнера", "containerID", containerID, "error", err)
+			}
+
+			c.mu.Lock()
+			again := c.capturing[containerID] && ctx.Err() == nil
+			if !again {
+				delete(c.capturing, containerID)
+			} else {
+				c.capturing[containerID] = false
+			}
+			c.mu.Unlock()
+			if !again {
+				return
+			}
+		}
+	}()
+}
+
+// copyLogs дописывает вывод контейнера в хранилище, пока контейнер работает
+func (c *LogCollector) copyLogs(ctx context.Context, containerID string) error {
+	inspect, err := c.docker.ContainerInspect(ctx, containerID)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	appName := inspect.Config.Labels[LabelApp]
+	serviceName := inspect.Config.Labels[LabelService]
+	if appName == "" || serviceName == "" {
+		return nil
+	}
+	created, err := time.Parse(time.RFC3339Nano, inspect.Created)
+	if err != nil {
+		return fmt.Errorf("некорректное время создания контейнера '%s': %w", inspect.Created, err)
+	}
+
+	w, err := c.store.Create(appName, serviceName, containerID, created, inspect.Config.Labels[LabelLogFormat])
+	if err != nil {
+		return err
+	}
+
+	options := container.LogsOptions{ShowStdout: true, ShowStderr: true, Follow: true, Timestamps: true}
+	if last := w.LastTime(); !last.IsZero() {
+		since := last.Add(time.Nanosecond)
+		options.Since = fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond())
+	}
+
+	reader, err := c.docker.ContainerLogs(ctx, containerID, options)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	defer reader.Close()
+	c.logger.Debug("запись логов контейнера начата", "appName", appName, "service", serviceName, "containerID", containerID)
+
+	emit := func(streamName, line string) error {
+		entry := parseLogLine(line)
+		t := time.Unix(0, entry.GetTimeUnixNano())
+		if entry.GetTimeUnixNano() == 0 {
+			t = time.Now()
+		}
+		return w.Write(logstore.Record{Time: t, Stream: streamName, Message: entry.GetMessage()})
+	}
+	stdout := &logLineWriter{stream: StreamStdout, emit: emit}
+	stderr := &logLineWriter{stream: StreamStderr, emit: emit}
+	_, copyErr := stdcopy.StdCopy(stdout, stderr, reader)
+	if copyErr == nil {
+		if err := stdout.Flush(); err != nil {
+			copyErr = err
+		} else {
+			copyErr = stderr.Flush()
+		}
+	}
+
+	// Демон останавливается, а контейнер работает: запись продолжится после запуска демона
+	if ctx.Err() != nil {
+		return w.Close()
+	}
+	if err := w.Finish(); err != nil {
+		return err
+	}
+	c.logger.Debug("запись логов контейнера завершена", "appName", appName, "service", serviceName, "containerID", containerID)
+	return copyErr
+}
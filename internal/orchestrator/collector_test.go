@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/waste3d/forge/internal/logstore"
+)
+
+func newTestCollector(t *testing.T, docker *fakeDocker) (*LogCollector, *logstore.Store) {
+	t.Helper()
+	store, err := logstore.Open(logstore.Config{Dir: t.TempDir(), MaxFileSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("logstore.Open: %v", err)
+	}
+	return NewLogCollector(store, docker, slog.New(slog.NewTextHandler(io.Discard, nil))), store
+}
+
+// storedMessages возвращает сохраненные сообщения всех экземпляров сервиса
+func storedMessages(t *testing.T, store *logstore.Store, appName, serviceName string) []string {
+	t.Helper()
+	instances, err := store.Instances(appName, serviceName)
+	if err != nil {
+		t.Fatalf("Instances: %v", err)
+	}
+	var messages []string
+	for _, inst := range instances {
+		err := store.Read(inst, func(r logstore.Record) error {
+			messages = append(messages, r.Message)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+	}
+	return messages
+}
+
+func TestCopyLogsResume(t *testing.T) {
+	docker := &fakeDocker{
+		containers: []types.Container{{ID: "c1", State: "running", Created: 1714557000, Labels: managedLabels("shop", "api")}},
+		logs: map[string]string{"c1": "2024-05-01T10:00:00.000000001Z first\n" +
+			"2024-05-01T10:00:00.000000002Z second\n"},
+	}
+	c, store := newTestCollector(t, docker)
+
+	if err := c.copyLogs(context.Background(), "c1"); err != nil {
+		t.Fatalf("первая запись: %v", err)
+	}
+	// Демон перезапущен: Docker отдает только строки после Since
+	docker.logs["c1"] = "2024-05-01T10:00:01Z third\n"
+	if err := c.copyLogs(context.Background(), "c1"); err != nil {
+		t.Fatalf("повторная запись: %v", err)
+	}
+
+	options := docker.containerLogOptions("c1")
+	if len(options) != 2 {
+		t.Fatalf("ContainerLogs вызван %d раз, ожидалось 2", len(options))
+	}
+	if options[0].Since != "" {
+		t.Errorf("первая запись с Since = %q, ожидалось с начала", options[0].Since)
+	}
+	// Продолжение — со следующей наносекунды после последней сохраненной строки
+	if want := "1714557600.000000003"; options[1].Since != want {
+		t.Errorf("повторная запись с Since = %q, ожидалось %q", options[1].Since, want)
+	}
+
+	if got, want := storedMessages(t, store, "shop", "api"), []string{"first", "second", "third"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("сохранено %v, ожидалось %v", got, want)
+	}
+}
+
+func TestLogCollectorRun(t *testing.T) {
+	docker := &fakeDocker{logs: make(map[string]string)}
+	for i, state := range []string{"running", "exited"} {
+		id := fmt.Sprintf("c%d", i)
+		docker.containers = append(docker.containers, types.Container{ID: id, State: state, Created: 1714557000, Labels: managedLabels("shop", id)})
+		docker.logs[id] = "2024-05-01T10:00:00Z " + state + "\n"
+	}
+	c, store := newTestCollector(t, docker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+
+	// Логи завершившегося, пока демон не работал, контейнера тоже дописываются
+	deadline := time.Now().Add(5 * time.Second)
+	for len(docker.containerLogOptions("c0")) == 0 || len(docker.containerLogOptions("c1")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("запись логов начата не для всех контейнеров")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	for i, state := range []string{"running", "exited"} {
+		service := fmt.Sprintf("c%d", i)
+		if got := storedMessages(t, store, "shop", service); !reflect.DeepEqual(got, []string{state}) {
+			t.Errorf("логи %s: %v", service, got)
+		}
+	}
+}
+
+func TestContainerStartedNeverDrops(t *testing.T) {
+	c, _ := newTestCollector(t, &fakeDocker{})
+
+	// Run не запущен: запуски копятся в очереди, а не отбрасываются
+	const starts = 1000
+	for i := 0; i < starts; i++ {
+		c.ContainerStarted(fmt.Sprintf("c%d", i))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) != starts {
+		t.Errorf("в очереди %d запусков, ожидалось %d", len(c.pending), starts)
+	}
+}
@@ -5,20 +5,29 @@ package orchestrator
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/google/uuid"
+	"github.com/waste3d/forge/internal/state"
 	"github.com/waste3d/forge/pkg/parser"
+	"gopkg.in/yaml.v3"
 )
 
 type DockerBuildResponse struct {
@@ -31,9 +40,7 @@ type ErrorDetail struct {
 	Message string `json:"message"`
 }
 
-var buildError error
-
-func (o *Orchestrator) startDatabase(ctx context.Context, dbConfig *parser.DBConfig, networkID string) error {
+func (o *Orchestrator) startDatabase(ctx context.Context, dbConfig *parser.DBConfig, mappings []parser.PortMapping, networkID string) error {
 	o.sendLog(dbConfig.Name, "Starting database service...")
 
 	if dbConfig.Type == "" || dbConfig.Version == "" {
@@ -50,47 +57,40 @@ func (o *Orchestrator) startDatabase(ctx context.Context, dbConfig *parser.DBCon
 	io.Copy(io.Discard, reader)
 	reader.Close()
 
-	containerConfig := &container.Config{
-		Image: imageName,
-		Env:   dbConfig.Env,
+	if dbConfig.Port > 0 && dbConfig.InternalPort == 0 {
+		o.sendLog(dbConfig.Name, fmt.Sprintf("Warning: 'port' %d is specified, but 'internalPort' is not. Port will not be exposed.", dbConfig.Port))
 	}
 
-	hostConfig := &container.HostConfig{}
-
-	if dbConfig.Port > 0 && dbConfig.InternalPort > 0 {
-		o.sendLog(dbConfig.Name, fmt.Sprintf("Mapping host port %d to container port %d", dbConfig.Port, dbConfig.InternalPort))
-
-		hostConfig.PortBindings = nat.PortMap{
-			nat.Port(fmt.Sprintf("%d/tcp", dbConfig.InternalPort)): []nat.PortBinding{
-				{
-					HostIP:   "0.0.0.0",
-					HostPort: fmt.Sprintf("%d", dbConfig.Port),
-				},
-			},
+	exposedPorts, portBindings := o.portBindings(mappings)
+	for _, m := range mappings {
+		if m.Published() {
+			o.sendLog(dbConfig.Name, fmt.Sprintf("Mapping host port %s", m))
 		}
-	} else if dbConfig.Port > 0 {
-		o.sendLog(dbConfig.Name, fmt.Sprintf("Warning: 'port' %d is specified, but 'internalPort' is not. Port will not be exposed.", dbConfig.Port))
 	}
 
-	containerName := fmt.Sprintf("forge-%s-%s-%s", o.appName, dbConfig.Name, uuid.New().String()[:8])
+	containerConfig := &container.Config{
+		Image:        imageName,
+		Env:          append(append([]string{}, o.portEnv...), dbConfig.Env...),
+		ExposedPorts: exposedPorts,
+	}
 
-	resp, err := o.dockerClient.ContainerCreate(ctx, containerConfig, hostConfig, &network.NetworkingConfig{
-		EndpointsConfig: map[string]*network.EndpointSettings{
-			networkID: {Aliases: []string{dbConfig.Name}},
-		},
-	}, nil, containerName)
-	if err != nil {
-		return fmt.Errorf("не удалось создать контейнер для %s: %w", dbConfig.Name, err)
+	hostConfig := &container.HostConfig{
+		PortBindings: portBindings,
 	}
 
-	if err := o.dockerClient.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		return fmt.Errorf("не удалось запустить контейнер для %s: %w", dbConfig.Name, err)
+	if err := applyContainerOptions(&dbConfig.ContainerOptions, containerConfig, hostConfig); err != nil {
+		return fmt.Errorf("некорректные параметры контейнера для %s: %w", dbConfig.Name, err)
 	}
+	containerConfig.Labels = o.withResourceLabels(containerConfig.Labels, dbConfig.Name)
+	containerConfig.Labels = withSupervisionLabels(containerConfig.Labels, mappings, dbConfig.HealthCheckTimeout)
+
+	containerName := fmt.Sprintf("forge-%s-%s-%s", o.appName, dbConfig.Name, uuid.New().String()[:8])
 
-	return o.stateManager.AddResource(o.appName, "container", resp.ID, dbConfig.Name)
+	_, err = o.runContainer(ctx, dbConfig.Name, containerName, networkID, containerConfig, hostConfig, configHash(dbConfig), mappings)
+	return err
 }
 
-func (o *Orchestrator) startService(ctx context.Context, serviceConfig *parser.ServiceConfig, networkID string) error {
+func (o *Orchestrator) startService(ctx context.Context, serviceConfig *parser.ServiceConfig, mappings []parser.PortMapping, networkID string) error {
 	o.sendLog(serviceConfig.Name, "Начинаю запуск сервиса...")
 
 	var imageTag string
@@ -157,6 +157,7 @@ func (o *Orchestrator) startService(ctx context.Context, serviceConfig *parser.S
 		defer buildResp.Body.Close()
 
 		// Стримим логи сборки пользователю
+		var buildError error
 		scanner := bufio.NewScanner(buildResp.Body)
 		for scanner.Scan() {
 			live := scanner.Bytes()
@@ -186,39 +187,243 @@ func (o *Orchestrator) startService(ctx context.Context, serviceConfig *parser.S
 	o.sendLog(serviceConfig.Name, fmt.Sprintf("Создание и запуск контейнера из образа '%s'...", imageTag))
 	containerName := fmt.Sprintf("forge-%s-%s-%s", o.appName, serviceConfig.Name, uuid.New().String()[:8])
 
-	portMap := nat.PortMap{}
-	if serviceConfig.Port > 0 && serviceConfig.InternalPort > 0 {
-		portMap[nat.Port(fmt.Sprintf("%d/tcp", serviceConfig.InternalPort))] = []nat.PortBinding{
-			{HostIP: "0.0.0.0", HostPort: fmt.Sprintf("%d", serviceConfig.Port)},
-		}
+	exposedPorts, portBindings := o.portBindings(mappings)
+
+	containerConfig := &container.Config{
+		Image:        imageTag,
+		Env:          append(append([]string{}, o.portEnv...), serviceConfig.Env...),
+		ExposedPorts: exposedPorts,
+	}
+	hostConfig := &container.HostConfig{
+		PortBindings: portBindings,
 	}
 
-	resp, err := o.dockerClient.ContainerCreate(ctx,
-		&container.Config{
-			Image: imageTag,
-			Env:   serviceConfig.Env,
-		},
-		&container.HostConfig{
-			PortBindings: portMap,
+	if err := applyContainerOptions(&serviceConfig.ContainerOptions, containerConfig, hostConfig); err != nil {
+		return fmt.Errorf("некорректные параметры контейнера для %s: %w", serviceConfig.Name, err)
+	}
+	containerConfig.Labels = o.withResourceLabels(containerConfig.Labels, serviceConfig.Name)
+	containerConfig.Labels = withSupervisionLabels(containerConfig.Labels, mappings, serviceConfig.HealthCheckTimeout)
+
+	containerID, err := o.runContainer(ctx, serviceConfig.Name, containerName, networkID, containerConfig, hostConfig, configHash(serviceConfig), mappings)
+	if err != nil {
+		return err
+	}
+
+	o.sendLog(serviceConfig.Name, fmt.Sprintf("Контейнер %s запущен. ID: %s", containerName, containerID[:12]))
+	return nil
+}
+
+// runContainer создает и запускает контейнер узла. Запись о ресурсе сохраняется
+// до запуска: контейнер, который не удалось запустить, остается в состоянии,
+// получает статус failed и удаляется вместе с приложением.
+func (o *Orchestrator) runContainer(ctx context.Context, nodeName, containerName, networkID string, cfg *container.Config, hostCfg *container.HostConfig, hash string, mappings []parser.PortMapping) (string, error) {
+	resp, err := o.dockerClient.ContainerCreate(ctx, cfg, hostCfg, &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			networkID: {Aliases: []string{nodeName}},
 		},
-		&network.NetworkingConfig{
-			EndpointsConfig: map[string]*network.EndpointSettings{
-				networkID: {Aliases: []string{serviceConfig.Name}},
-			},
-		}, nil, containerName)
+	}, nil, containerName)
+	if err != nil {
+		return "", fmt.Errorf("не удалось создать контейнер для %s: %w", nodeName, err)
+	}
 
+	err = o.stateManager.AddResource(state.Resource{
+		AppName:      o.appName,
+		ResourceType: "container",
+		ID:           resp.ID,
+		ServiceName:  nodeName,
+		ImageID:      o.imageID(ctx, cfg.Image),
+		ConfigHash:   hash,
+		HostPorts:    formatPortMappings(mappings),
+		RunID:        o.runID,
+		Status:       state.StatusStarting,
+	})
 	if err != nil {
-		return fmt.Errorf("ошибка создания контейнера: %w", err)
+		o.dockerClient.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+		return "", fmt.Errorf("критическая ошибка: не удалось сохранить состояние для контейнера %s: %w", nodeName, err)
 	}
 
 	if err := o.dockerClient.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		return fmt.Errorf("ошибка запуска контейнера: %w", err)
+		return resp.ID, fmt.Errorf("не удалось запустить контейнер для %s: %w", nodeName, err)
+	}
+	return resp.ID, nil
+}
+
+// applyContainerOptions переносит параметры контейнера из forge.yaml в конфигурацию Docker.
+// Незаданные параметры оставляют значения по умолчанию из образа.
+func applyContainerOptions(opts *parser.ContainerOptions, cfg *container.Config, hostCfg *container.HostConfig) error {
+	if len(opts.Command) > 0 {
+		cfg.Cmd = strslice.StrSlice(opts.Command)
+	}
+	if len(opts.Entrypoint) > 0 {
+		cfg.Entrypoint = strslice.StrSlice(opts.Entrypoint)
+	}
+	cfg.WorkingDir = opts.WorkingDir
+	cfg.User = opts.User
+	cfg.Hostname = opts.Hostname
+
+	if len(opts.Labels) > 0 {
+		if cfg.Labels == nil {
+			cfg.Labels = make(map[string]string, len(opts.Labels))
+		}
+		for k, v := range opts.Labels {
+			cfg.Labels[k] = v
+		}
+	}
+
+	if opts.LogFormat != "" {
+		format, err := parseLogFormat(opts.LogFormat)
+		if err != nil {
+			return err
+		}
+		if cfg.Labels == nil {
+			cfg.Labels = make(map[string]string)
+		}
+		cfg.Labels[LabelLogFormat] = format
+	}
+
+	hostCfg.ExtraHosts = opts.ExtraHosts
+	if len(opts.CapAdd) > 0 {
+		hostCfg.CapAdd = strslice.StrSlice(opts.CapAdd)
 	}
 
-	o.sendLog(serviceConfig.Name, fmt.Sprintf("Контейнер %s запущен. ID: %s", containerName, resp.ID[:12]))
+	if len(opts.Tmpfs) > 0 {
+		hostCfg.Tmpfs = make(map[string]string, len(opts.Tmpfs))
+		for _, entry := range opts.Tmpfs {
+			path, options, _ := strings.Cut(entry, ":")
+			if path == "" {
+				return fmt.Errorf("некорректное значение tmpfs '%s'", entry)
+			}
+			hostCfg.Tmpfs[path] = options
+		}
+	}
+
+	if opts.ShmSize != "" {
+		size, err := units.RAMInBytes(opts.ShmSize)
+		if err != nil {
+			return fmt.Errorf("некорректное значение shmSize '%s': %w", opts.ShmSize, err)
+		}
+		hostCfg.ShmSize = size
+	}
+
+	if opts.Resources.CPUs < 0 || opts.Resources.Pids < 0 {
+		return fmt.Errorf("ограничения ресурсов не могут быть отрицательными")
+	}
+	hostCfg.NanoCPUs = int64(opts.Resources.CPUs * 1e9)
+	if opts.Resources.Memory != "" {
+		memory, err := units.RAMInBytes(opts.Resources.Memory)
+		if err != nil {
+			return fmt.Errorf("некорректное значение resources.memory '%s': %w", opts.Resources.Memory, err)
+		}
+		hostCfg.Memory = memory
+	}
+	if opts.Resources.Pids > 0 {
+		pids := opts.Resources.Pids
+		hostCfg.PidsLimit = &pids
+	}
+
+	// Упавшие контейнеры перезапускает Docker, в том числе когда демон не
+	// запущен и после перезагрузки машины. Supervisor следит за перезапусками
+	// и повторяет проверку готовности.
+	policy, err := parseRestartPolicy(opts.Restart)
+	if err != nil {
+		return err
+	}
+	hostCfg.RestartPolicy = policy
+
+	return nil
+}
+
+// parseRestartPolicy разбирает значение поля 'restart': no, on-failure[:N], always, unless-stopped
+func parseRestartPolicy(value string) (container.RestartPolicy, error) {
+	name, retries, hasRetries := strings.Cut(value, ":")
+	policy := container.RestartPolicy{Name: container.RestartPolicyMode(name)}
+
+	switch policy.Name {
+	case "", container.RestartPolicyDisabled:
+		policy.Name = container.RestartPolicyDisabled
+	case container.RestartPolicyAlways, container.RestartPolicyUnlessStopped:
+	case container.RestartPolicyOnFailure:
+		if hasRetries {
+			n, err := strconv.Atoi(retries)
+			if err != nil || n < 0 {
+				return policy, fmt.Errorf("некорректное число попыток в restart '%s'", value)
+			}
+			policy.MaximumRetryCount = n
+		}
+		return policy, nil
+	default:
+		return policy, fmt.Errorf("неизвестная политика перезапуска '%s': ожидалось no, on-failure, always или unless-stopped", value)
+	}
+
+	if hasRetries {
+		return policy, fmt.Errorf("число попыток допустимо только для политики on-failure, получено '%s'", value)
+	}
+	return policy, nil
+}
+
+// imageID возвращает идентификатор локального образа. Пустая строка означает,
+// что образ не удалось проинспектировать — это не мешает запуску контейнера.
+func (o *Orchestrator) imageID(ctx context.Context, imageRef string) string {
+	inspect, _, err := o.dockerClient.ImageInspectWithRaw(ctx, imageRef)
+	if err != nil {
+		o.logger.Warn("не удалось получить ID образа", "image", imageRef, "error", err)
+		return ""
+	}
+	return inspect.ID
+}
+
+// configHash возвращает хэш конфигурации узла, по которому можно понять,
+// изменилась ли она с момента создания контейнера
+func configHash(nodeConfig interface{}) string {
+	data, err := yaml.Marshal(nodeConfig)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// formatPortMappings возвращает опубликованные порты в формате Status
+func formatPortMappings(mappings []parser.PortMapping) string {
+	var ports []string
+	for _, m := range mappings {
+		for _, pair := range m.Pairs() {
+			if pair[0] == 0 {
+				continue
+			}
+			ports = append(ports, fmt.Sprintf("%s:%d->%d/%s", m.HostIP, pair[0], pair[1], m.Protocol))
+		}
+	}
+	sort.Strings(ports)
+	return strings.Join(ports, ", ")
+}
+
+// portBindings преобразует пробросы портов узла в формат Docker API.
+// Диапазоны раскладываются на отдельные порты.
+func (o *Orchestrator) portBindings(mappings []parser.PortMapping) (nat.PortSet, nat.PortMap) {
+	exposed := nat.PortSet{}
+	bindings := nat.PortMap{}
+
+	for _, m := range mappings {
+		hostIP := m.HostIP
+		if hostIP == "" {
+			hostIP = DefaultBindAddress
+		}
+
+		for _, pair := range m.Pairs() {
+			port := nat.Port(fmt.Sprintf("%d/%s", pair[1], m.Protocol))
+			exposed[port] = struct{}{}
+			if pair[0] == 0 {
+				continue
+			}
+			bindings[port] = append(bindings[port], nat.PortBinding{
+				HostIP:   hostIP,
+				HostPort: strconv.Itoa(pair[0]),
+			})
+		}
+	}
 
-	// Сохраняем информацию о созданном ресурсе
-	return o.stateManager.AddResource(o.appName, "container", resp.ID, serviceConfig.Name)
+	return exposed, bindings
 }
 
 func (o *Orchestrator) buildService(ctx context.Context, serviceConfig *parser.ServiceConfig) (string, error) {
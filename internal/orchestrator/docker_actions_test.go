@@ -0,0 +1,117 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/waste3d/forge/internal/events"
+	"github.com/waste3d/forge/internal/state"
+	"github.com/waste3d/forge/pkg/parser"
+)
+
+func TestParseRestartPolicy(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      container.RestartPolicy
+		expectErr bool
+	}{
+		{value: "", want: container.RestartPolicy{Name: container.RestartPolicyDisabled}},
+		{value: "no", want: container.RestartPolicy{Name: container.RestartPolicyDisabled}},
+		{value: "always", want: container.RestartPolicy{Name: container.RestartPolicyAlways}},
+		{value: "unless-stopped", want: container.RestartPolicy{Name: container.RestartPolicyUnlessStopped}},
+		{value: "on-failure", want: container.RestartPolicy{Name: container.RestartPolicyOnFailure}},
+		{value: "on-failure:5", want: container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 5}},
+		{value: "on-failure:0", want: container.RestartPolicy{Name: container.RestartPolicyOnFailure}},
+		{value: "on-failure:x", expectErr: true},
+		{value: "on-failure:-1", expectErr: true},
+		{value: "on-failure:", expectErr: true},
+		{value: "always:3", expectErr: true},
+		{value: "no:1", expectErr: true},
+		{value: "sometimes", expectErr: true},
+		{value: "Always", expectErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseRestartPolicy(tt.value)
+		if tt.expectErr {
+			if err == nil {
+				t.Errorf("parseRestartPolicy(%q): ожидалась ошибка, получено %+v", tt.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRestartPolicy(%q): неожиданная ошибка: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRestartPolicy(%q) = %+v, ожидалось %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRunContainerStartFailure(t *testing.T) {
+	docker := &fakeDocker{startErr: errors.New("port is already allocated")}
+	sm := state.NewMemoryManager()
+	o := &Orchestrator{appName: "shop", dockerClient: docker, stateManager: sm, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	id, err := o.runContainer(context.Background(), "api", "forge-shop-api", "net", &container.Config{Image: "api:latest"}, &container.HostConfig{}, "hash", nil)
+	if err == nil {
+		t.Fatal("ожидалась ошибка запуска контейнера")
+	}
+
+	// Созданный, но не запущенный контейнер остается в состоянии, чтобы его удалил 'forge down'
+	resources, _ := sm.GetResourceByApp("shop")
+	if len(resources) != 1 || resources[0].ID != id || resources[0].Status != state.StatusStarting {
+		t.Fatalf("ресурсы после ошибки запуска: %+v", resources)
+	}
+}
+
+func TestBuildPublishesRebuilt(t *testing.T) {
+	tests := []struct {
+		name        string
+		buildOutput string
+		wantErr     bool
+		want        []string
+	}{
+		{
+			name:        "успешная сборка",
+			buildOutput: `{"stream":"Successfully built"}` + "\n",
+			want:        []string{"api", "worker"},
+		},
+		{
+			name:        "ошибка сборки",
+			buildOutput: `{"error":"failed","errorDetail":{"message":"failed"}}` + "\n",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &eventRecorder{}
+			o := &Orchestrator{
+				appName:      "shop",
+				dockerClient: &fakeDocker{buildOutput: tt.buildOutput},
+				stateManager: state.NewMemoryManager(),
+				logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+				options:      Options{Events: recorder, Parallelism: 1},
+			}
+			config := &parser.Config{AppName: "shop", Services: []parser.ServiceConfig{
+				{Name: "api", Path: t.TempDir()},
+				{Name: "worker", Path: t.TempDir()},
+				{Name: "cache", Image: "redis:7"},
+			}}
+
+			err := o.Build(context.Background(), config, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build: ошибка %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if got := recorder.services(events.TypeRebuilt); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("события rebuilt для %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/waste3d/forge/internal/events"
+)
+
+// fakeDocker — Docker API в памяти для тестов. Методы, которые тест не
+// реализует, вызывают панику через встроенный nil-интерфейс.
+type fakeDocker struct {
+	client.APIClient
+
+	containers []types.Container
+	networks   []network.Summary
+	volumes    []*volume.Volume
+
+	// startErr возвращается из ContainerStart
+	startErr error
+	// networkErr возвращается из NetworkCreate
+	networkErr error
+	// buildOutput — поток ответа ImageBuild в формате Docker API
+	buildOutput string
+
+	// logs — вывод каждого контейнера в stdout, строки с временем, как при Timestamps
+	logs map[string]string
+	// logOptions — параметры вызовов ContainerLogs по контейнерам
+	logOptions map[string][]container.LogsOptions
+
+	// inspects подменяет ответ ContainerInspect. Тесты меняют его, пока
+	// Supervisor опрашивает контейнер, поэтому доступ защищен mu.
+	mu       sync.Mutex
+	inspects map[string]types.ContainerJSON
+}
+
+// setInspect задает ответ ContainerInspect для контейнера
+func (f *fakeDocker) setInspect(id string, inspect types.ContainerJSON) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.inspects == nil {
+		f.inspects = make(map[string]types.ContainerJSON)
+	}
+	f.inspects[id] = inspect
+}
+
+func notFound(kind, id string) error {
+	return errdefs.NotFound(fmt.Errorf("%s %s не найден", kind, id))
+}
+
+// matchLabels проверяет фильтры вида label=key=value
+func matchLabels(args filters.Args, labels map[string]string) bool {
+	for _, filter := range args.Get("label") {
+		key, value, hasValue := strings.Cut(filter, "=")
+		got, ok := labels[key]
+		if !ok || (hasValue && got != value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakeDocker) ContainerList(_ context.Context, options container.ListOptions) ([]types.Container, error) {
+	var list []types.Container
+	for _, c := range f.containers {
+		if !options.All && c.State != "running" {
+			continue
+		}
+		if matchLabels(options.Filters, c.Labels) {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeDocker) ContainerInspect(_ context.Context, id string) (types.ContainerJSON, error) {
+	f.mu.Lock()
+	inspect, ok := f.inspects[id]
+	f.mu.Unlock()
+	if ok {
+		return inspect, nil
+	}
+	for _, c := range f.containers {
+		if c.ID == id {
+			return types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{
+					ID:      c.ID,
+					Created: time.Unix(c.Created, 0).UTC().Format(time.RFC3339Nano),
+					State:   &types.ContainerState{Status: c.State, Running: c.State == "running"},
+				},
+				Config: &container.Config{Labels: c.Labels},
+			}, nil
+		}
+	}
+	return types.ContainerJSON{}, notFound("контейнер", id)
+}
+
+func (f *fakeDocker) ContainerLogs(_ context.Context, id string, options container.LogsOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.logOptions == nil {
+		f.logOptions = make(map[string][]container.LogsOptions)
+	}
+	f.logOptions[id] = append(f.logOptions[id], options)
+
+	var buf bytes.Buffer
+	stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(f.logs[id]))
+	return io.NopCloser(&buf), nil
+}
+
+// containerLogOptions возвращает параметры вызовов ContainerLogs для контейнера
+func (f *fakeDocker) containerLogOptions(id string) []container.LogsOptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logOptions[id]
+}
+
+func (f *fakeDocker) ContainerCreate(_ context.Context, cfg *container.Config, _ *container.HostConfig, _ *network.NetworkingConfig, _ *ocispec.Platform, name string) (container.CreateResponse, error) {
+	id := fmt.Sprintf("%s-%064d", name, len(f.containers))
+	f.containers = append(f.containers, types.Container{ID: id, Names: []string{"/" + name}, Image: cfg.Image, State: "created", Labels: cfg.Labels})
+	return container.CreateResponse{ID: id}, nil
+}
+
+func (f *fakeDocker) ContainerStart(_ context.Context, id string, _ container.StartOptions) error {
+	if f.startErr != nil {
+		return f.startErr
+	}
+	for i := range f.containers {
+		if f.containers[i].ID == id {
+			f.containers[i].State = "running"
+			return nil
+		}
+	}
+	return notFound("контейнер", id)
+}
+
+func (f *fakeDocker) ImageInspectWithRaw(_ context.Context, ref string) (types.ImageInspect, []byte, error) {
+	return types.ImageInspect{}, nil, notFound("образ", ref)
+}
+
+func (f *fakeDocker) ImageBuild(_ context.Context, buildContext io.Reader, _ types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+	io.Copy(io.Discard, buildContext)
+	return types.ImageBuildResponse{Body: io.NopCloser(strings.NewReader(f.buildOutput))}, nil
+}
+
+func (f *fakeDocker) NetworkCreate(_ context.Context, name string, _ types.NetworkCreate) (network.CreateResponse, error) {
+	if f.networkErr != nil {
+		return network.CreateResponse{}, f.networkErr
+	}
+	return network.CreateResponse{ID: "network-" + name}, nil
+}
+
+func (f *fakeDocker) NetworkList(_ context.Context, options network.ListOptions) ([]network.Summary, error) {
+	var list []network.Summary
+	for _, n := range f.networks {
+		if matchLabels(options.Filters, n.Labels) {
+			list = append(list, n)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeDocker) NetworkInspect(_ context.Context, id string, _ network.InspectOptions) (network.Inspect, error) {
+	for _, n := range f.networks {
+		if n.ID == id {
+			return n, nil
+		}
+	}
+	return network.Inspect{}, notFound("сеть", id)
+}
+
+func (f *fakeDocker) VolumeList(_ context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	var list volume.ListResponse
+	for _, v := range f.volumes {
+		if matchLabels(options.Filters, v.Labels) {
+			list.Volumes = append(list.Volumes, v)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeDocker) VolumeInspect(_ context.Context, name string) (volume.Volume, error) {
+	for _, v := range f.volumes {
+		if v.Name == name {
+			return *v, nil
+		}
+	}
+	return volume.Volume{}, notFound("том", name)
+}
+
+// managedLabels возвращает метки ресурса сервиса, созданного Forge
+func managedLabels(appName, serviceName string) map[string]string {
+	return map[string]string{LabelManaged: "true", LabelApp: appName, LabelService: serviceName}
+}
+
+// eventRecorder запоминает опубликованные события. Оркестратор публикует их
+// из нескольких горутин.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (r *eventRecorder) Publish(e events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// types возвращает типы опубликованных событий по порядку
+func (r *eventRecorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []string
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// services возвращает сервисы событий указанного типа
+func (r *eventRecorder) services(eventType string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var names []string
+	for _, e := range r.events {
+		if e.Type == eventType {
+			names = append(names, e.ServiceName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -48,3 +48,30 @@ func Sort(nodes []Node) ([]Node, error) {
 
 	return sorted, nil
 }
+
+// Levels группирует узлы по уровням зависимостей: узлы уровня зависят только
+// от узлов предыдущих уровней, поэтому их можно запускать одновременно.
+// Внутри уровня сохраняется порядок из Sort.
+func Levels(nodes []Node) ([][]Node, error) {
+	sorted, err := Sort(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := make(map[string]int)
+	var levels [][]Node
+	for _, node := range sorted {
+		level := 0
+		for _, depName := range node.GetDependencies() {
+			if d := depth[depName] + 1; d > level {
+				level = d
+			}
+		}
+		depth[node.GetName()] = level
+		if level == len(levels) {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], node)
+	}
+	return levels, nil
+}
@@ -15,8 +15,9 @@ type mockNode struct {
 	startCalled   bool
 }
 
-func (m *mockNode) GetName() string           { return m.name }
-func (m *mockNode) GetDependencies() []string { return m.deps }
+func (m *mockNode) GetName() string                { return m.name }
+func (m *mockNode) GetDependencies() []string      { return m.deps }
+func (m *mockNode) GetPorts() []parser.PortMapping { return nil }
 
 func (m *mockNode) Start(ctx context.Context, networkID string, orchestrator *Orchestrator) error {
 	m.startCalled = true
@@ -110,3 +111,36 @@ func TestSort(t *testing.T) {
 		})
 	}
 }
+
+func TestLevels(t *testing.T) {
+	nodes := []Node{
+		&mockNode{name: "frontend", deps: []string{"backend"}},
+		&mockNode{name: "backend", deps: []string{"auth", "db"}},
+		&mockNode{name: "cache"},
+		&mockNode{name: "db"},
+		&mockNode{name: "auth", deps: []string{"db"}},
+		&mockNode{name: "worker", deps: []string{"db"}},
+	}
+
+	levels, err := Levels(nodes)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	var names [][]string
+	for _, level := range levels {
+		var levelNames []string
+		for _, node := range level {
+			levelNames = append(levelNames, node.GetName())
+		}
+		names = append(names, levelNames)
+	}
+
+	expected := [][]string{{"db", "cache"}, {"auth", "worker"}, {"backend"}, {"frontend"}}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Неправильные уровни.\nОжидалось: %v\nПолучено:  %v", expected, names)
+	}
+
+	if _, err := Levels([]Node{&mockNode{name: "a", deps: []string{"b"}}, &mockNode{name: "b", deps: []string{"a"}}}); err == nil {
+		t.Error("Ожидалась ошибка цикла зависимостей")
+	}
+}
@@ -0,0 +1,67 @@
+package orchestrator
+
+import (
+	"strconv"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/waste3d/forge/pkg/parser"
+	"github.com/waste3d/forge/version"
+)
+
+// Метки, которыми помечается каждый ресурс Docker, созданный оркестратором.
+// По ним демон восстанавливает состояние, если база данных потеряна или
+// ресурсы были изменены в обход Forge.
+const (
+	LabelManaged = "com.forge.managed"
+	LabelApp     = "com.forge.app"
+	LabelService = "com.forge.service"
+	LabelVersion = "com.forge.version"
+)
+
+// Метки контейнера узла, по которым демон проверяет его готовность после
+// перезапуска Docker так же, как при 'forge up'.
+const (
+	LabelReadiness        = "com.forge.readiness"         // адрес проверки готовности на хосте
+	LabelReadinessTimeout = "com.forge.readiness-timeout" // таймаут проверки в секундах
+)
+
+// LabelLogFormat — формат логов контейнера, поле 'logFormat'. По нему демон
+// разбирает записи при выводе логов.
+const LabelLogFormat = "com.forge.log-format"
+
+// resourceLabels возвращает метки для ресурса приложения
+func (o *Orchestrator) resourceLabels(serviceName string) map[string]string {
+	return map[string]string{
+		LabelManaged: "true",
+		LabelApp:     o.appName,
+		LabelService: serviceName,
+		LabelVersion: version.Version,
+	}
+}
+
+// withResourceLabels дополняет пользовательские метки служебными. Служебные метки
+// имеют приоритет, чтобы их нельзя было случайно переопределить в forge.yaml.
+func (o *Orchestrator) withResourceLabels(labels map[string]string, serviceName string) map[string]string {
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	for k, v := range o.resourceLabels(serviceName) {
+		labels[k] = v
+	}
+	return labels
+}
+
+// withSupervisionLabels дополняет метки контейнера узла параметрами проверки
+// готовности. Порты "auto" к этому моменту уже выделены.
+func withSupervisionLabels(labels map[string]string, mappings []parser.PortMapping, timeout int) map[string]string {
+	if address := healthCheckAddress(mappings); address != "" {
+		labels[LabelReadiness] = address
+		labels[LabelReadinessTimeout] = strconv.Itoa(timeout)
+	}
+	return labels
+}
+
+// managedFilter отбирает ресурсы Docker, созданные Forge
+func managedFilter() filters.Args {
+	return filters.NewArgs(filters.Arg("label", LabelManaged+"=true"))
+}
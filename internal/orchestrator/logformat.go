@@ -0,0 +1,210 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	pb "github.com/waste3d/forge/internal/gen/proto"
+)
+
+// Форматы логов сервиса, поле 'logFormat'
+const (
+	LogFormatText   = "text" // по умолчанию: строки выводятся как есть
+	LogFormatJSON   = "json" // объект JSON в строке, например slog.JSONHandler
+	LogFormatLogfmt = "logfmt"
+)
+
+// Ключи, под которыми структурированные логи обычно пишут сообщение, уровень и время
+var (
+	messageKeys = []string{"msg", "message"}
+	levelKeys   = []string{"level", "lvl", "severity"}
+	timeKeys    = []string{"time", "ts", "timestamp"}
+)
+
+// levelRanks упорядочивает уровни записей для фильтра --level
+var levelRanks = map[string]int{
+	"trace": 0,
+	"debug": 1,
+	"info":  2,
+	"warn":  3,
+	"error": 4,
+	"fatal": 5,
+}
+
+// parseLogFormat проверяет значение поля 'logFormat'
+func parseLogFormat(value string) (string, error) {
+	switch value {
+	case "":
+		return LogFormatText, nil
+	case LogFormatText, LogFormatJSON, LogFormatLogfmt:
+		return value, nil
+	default:
+		return "", fmt.Errorf("неизвестный формат логов '%s': ожидалось %s, %s или %s", value, LogFormatJSON, LogFormatLogfmt, LogFormatText)
+	}
+}
+
+// NormalizeLevel приводит уровень записи к одному из debug, info, warn, error,
+// fatal или trace. Для неизвестного уровня возвращает пустую строку.
+func NormalizeLevel(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "trace":
+		return "trace"
+	case "debug", "dbg":
+		return "debug"
+	case "info", "inf", "notice":
+		return "info"
+	case "warn", "warning", "wrn":
+		return "warn"
+	case "error", "err", "eror":
+		return "error"
+	case "fatal", "panic", "critical", "crit", "dpanic":
+		return "fatal"
+	default:
+		return ""
+	}
+}
+
+// parseStructured разбирает сообщение записи в формате format: заполняет ее
+// уровень и поля, а в сообщении оставляет только текст. Строки, которые не
+// удалось разобрать, например трассировки паники, остаются без изменений.
+func parseStructured(format string, entry *pb.LogEntry) {
+	var fields map[string]string
+	switch format {
+	case LogFormatJSON:
+		fields = parseJSONFields(entry.GetMessage())
+	case LogFormatLogfmt:
+		fields = parseLogfmt(entry.GetMessage())
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	message := takeField(fields, messageKeys)
+	entry.Level = NormalizeLevel(takeField(fields, levelKeys))
+	takeField(fields, timeKeys)
+	entry.Fields = fields
+	entry.Message = message
+}
+
+// takeField удаляет из fields первый найденный ключ из keys и возвращает его значение
+func takeField(fields map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			delete(fields, key)
+			return value
+		}
+	}
+	return ""
+}
+
+// parseJSONFields разбирает объект JSON. Вложенные значения сохраняются как JSON.
+func parseJSONFields(line string) map[string]string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(line), &object); err != nil {
+		return nil
+	}
+
+	fields := make(map[string]string, len(object))
+	for key, value := range object {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			fields[key] = s
+		} else {
+			fields[key] = string(value)
+		}
+	}
+	return fields
+}
+
+// parseLogfmt разбирает строку вида key=value key2="value с пробелами" flag.
+// Строка без единой пары key=value не считается записью logfmt.
+func parseLogfmt(line string) map[string]string {
+	fields := make(map[string]string)
+	pairs := 0
+	for i := 0; i < len(line); {
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' {
+			i++
+		}
+		key := line[start:i]
+		if key == "" {
+			if i < len(line) {
+				// '=' без ключа — это не logfmt
+				return nil
+			}
+			break
+		}
+		if i >= len(line) || line[i] != '=' {
+			fields[key] = "true"
+			continue
+		}
+		i++ // '='
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			var b strings.Builder
+			i++
+			for i < len(line) && line[i] != '"' {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+					switch line[i] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(line[i])
+					}
+				} else {
+					b.WriteByte(line[i])
+				}
+				i++
+			}
+			if i >= len(line) {
+				return nil // незакрытая кавычка
+			}
+			i++
+			value = b.String()
+		} else {
+			start := i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			value = line[start:i]
+		}
+		fields[key] = value
+		pairs++
+	}
+	if pairs == 0 {
+		return nil
+	}
+	return fields
+}
+
+// matchLevel сообщает, не ниже ли уровень записи минимального. Записи без
+// уровня не проходят фильтр.
+func matchLevel(level, min string) bool {
+	if min == "" {
+		return true
+	}
+	rank, ok := levelRanks[level]
+	return ok && rank >= levelRanks[min]
+}
+
+// matchFields сообщает, равны ли поля записи всем заданным
+func matchFields(fields, want map[string]string) bool {
+	for key, value := range want {
+		if got, ok := fields[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"testing"
+
+	pb "github.com/waste3d/forge/internal/gen/proto"
+)
+
+func TestParseStructured(t *testing.T) {
+	tests := []struct {
+		format  string
+		line    string
+		level   string
+		message string
+		fields  map[string]string
+	}{
+		{
+			format:  LogFormatJSON,
+			line:    `{"time":"2024-05-01T10:00:00Z","level":"WARN","msg":"медленный запрос","user_id":42,"query":{"table":"orders"}}`,
+			level:   "warn",
+			message: "медленный запрос",
+			fields:  map[string]string{"user_id": "42", "query": `{"table":"orders"}`},
+		},
+		{
+			format:  LogFormatLogfmt,
+			line:    `ts=2024-05-01T10:00:00Z lvl=error msg="connection refused" addr=db:5432 retry`,
+			level:   "error",
+			message: "connection refused",
+			fields:  map[string]string{"addr": "db:5432", "retry": "true"},
+		},
+		// Строки, которые не разбираются, остаются как есть
+		{format: LogFormatJSON, line: "panic: runtime error", message: "panic: runtime error"},
+		{format: LogFormatLogfmt, line: "goroutine 1 [running]:", message: "goroutine 1 [running]:"},
+		{format: LogFormatLogfmt, line: `msg="незакрытая`, message: `msg="незакрытая`},
+		{format: LogFormatText, line: "level=info msg=hello", message: "level=info msg=hello"},
+	}
+
+	for _, tt := range tests {
+		entry := &pb.LogEntry{Message: tt.line}
+		parseStructured(tt.format, entry)
+		if entry.GetLevel() != tt.level || entry.GetMessage() != tt.message {
+			t.Errorf("%s %q: уровень %q, сообщение %q; ожидалось %q, %q", tt.format, tt.line, entry.GetLevel(), entry.GetMessage(), tt.level, tt.message)
+		}
+		if len(entry.GetFields()) != len(tt.fields) {
+			t.Errorf("%s %q: поля %v, ожидалось %v", tt.format, tt.line, entry.GetFields(), tt.fields)
+			continue
+		}
+		for key, value := range tt.fields {
+			if entry.GetFields()[key] != value {
+				t.Errorf("%s %q: поле %s = %q, ожидалось %q", tt.format, tt.line, key, entry.GetFields()[key], value)
+			}
+		}
+	}
+}
+
+func TestLogQueryMatch(t *testing.T) {
+	line := `{"level":"error","msg":"не удалось списать","user_id":"42"}`
+	q := LogQuery{Level: "warn", Fields: map[string]string{"user_id": "42"}}
+
+	entry := &pb.LogEntry{Message: line}
+	if !q.match(LogFormatJSON, entry) || entry.GetMessage() != "не удалось списать" {
+		t.Errorf("запись уровня error с user_id=42 должна пройти фильтр: %+v", entry)
+	}
+	if q.match(LogFormatJSON, &pb.LogEntry{Message: `{"level":"info","msg":"ok","user_id":"42"}`}) {
+		t.Error("запись уровня info не должна пройти фильтр --level warn")
+	}
+	if q.match(LogFormatJSON, &pb.LogEntry{Message: `{"level":"error","msg":"ok","user_id":"7"}`}) {
+		t.Error("запись с другим user_id не должна пройти фильтр")
+	}
+	if q.match(LogFormatText, &pb.LogEntry{Message: "error user_id=42"}) {
+		t.Error("запись без уровня не должна пройти фильтр --level")
+	}
+
+	q.Raw = true
+	entry = &pb.LogEntry{Message: line}
+	if !q.match(LogFormatJSON, entry) || entry.GetMessage() != line || entry.GetLevel() != "" || entry.GetFields() != nil {
+		t.Errorf("с Raw запись должна остаться исходной строкой: %+v", entry)
+	}
+}
@@ -0,0 +1,525 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/docker/docker/pkg/stdcopy"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/logstore"
+	"github.com/waste3d/forge/internal/state"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultLogTail — сколько последних строк каждого контейнера выводится, если не указано иное
+const DefaultLogTail = 100
+
+const (
+	// logMergeWindow — сколько запись в режиме --follow ждет более ранних записей других сервисов
+	logMergeWindow = 250 * time.Millisecond
+	// logSourceBuffer — сколько прочитанных записей контейнера может ждать слияния
+	logSourceBuffer = 256
+)
+
+// Потоки вывода контейнера
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// LogQuery — параметры выборки логов приложения
+type LogQuery struct {
+	ServiceName string // пусто — все сервисы
+	Follow      bool
+	// Tail — сколько последних строк каждого контейнера выводить, меньше нуля — все
+	Tail int
+	// Since и Until ограничивают время записей, формат как у 'docker logs':
+	// RFC3339, Unix-время или длительность относительно текущего момента, например 10m
+	Since string
+	Until string
+	// Timestamps добавляет время записи в начало сообщения
+	Timestamps bool
+	// Grep оставляет только строки, подходящие под выражение. nil — все строки.
+	Grep *regexp.Regexp
+	// Previous выводит сохраненные логи предыдущих экземпляров контейнеров:
+	// N — N-й с конца экземпляр каждого сервиса, 0 — логи работающих контейнеров
+	Previous int
+	// Level оставляет только записи с этим уровнем и выше, Fields — записи с
+	// этими значениями полей. Оба фильтра применимы к сервисам с logFormat json или logfmt.
+	Level  string
+	Fields map[string]string
+	// Raw передает структурированные записи исходными строками, без уровня и полей
+	Raw bool
+}
+
+// match разбирает запись сервиса с форматом логов format и сообщает, подходит ли
+// она под фильтры запроса. Grep применяется к исходной строке, чтобы искать и по полям.
+func (q LogQuery) match(format string, entry *pb.LogEntry) bool {
+	if q.Grep != nil && !q.Grep.MatchString(entry.GetMessage()) {
+		return false
+	}
+	line := entry.GetMessage()
+	parseStructured(format, entry)
+	if !matchLevel(entry.GetLevel(), q.Level) || !matchFields(entry.GetFields(), q.Fields) {
+		return false
+	}
+	if q.Raw {
+		entry.Message, entry.Level, entry.Fields = line, "", nil
+	}
+	return true
+}
+
+// dockerTail возвращает значение Tail для Docker API
+func (q LogQuery) dockerTail() string {
+	if q.Tail < 0 {
+		return "all"
+	}
+	return strconv.Itoa(q.Tail)
+}
+
+// Logs выводит логи контейнеров приложения, а с q.Previous — сохраненные логи
+// одного из их предыдущих экземпляров. Записи разных сервисов упорядочиваются по времени.
+func (o *Orchestrator) Logs(ctx context.Context, q LogQuery, stream pb.Forge_LogsServer) error {
+	resources, err := o.stateManager.GetResourceByApp(o.appName)
+	if err != nil {
+		o.logger.Error("не удалось получить ресурсы из state manager", "error", err)
+		return fmt.Errorf("не удалось получить ресурсы: %w", err)
+	}
+
+	var containers []state.Resource
+	for _, res := range resources {
+		if res.ResourceType != "container" {
+			continue
+		}
+		if q.ServiceName != "" && res.ServiceName != q.ServiceName {
+			continue
+		}
+		containers = append(containers, res)
+	}
+
+	g, gCtx := errgroup.WithContext(ctx)
+
+	// Каждый контейнер читается в свой канал, а отправляет записи клиенту
+	// только слияние: так записи разных сервисов идут по времени
+	var sources []<-chan *pb.LogEntry
+	if q.Previous > 0 {
+		instances, err := o.previousInstances(q, resources)
+		if err != nil {
+			return err
+		}
+		if len(instances) == 0 {
+			stream.Send(&pb.LogEntry{
+				ServiceName: "forged-daemon",
+				Message:     fmt.Sprintf("Сохраненных логов %d-го с конца предыдущего контейнера в приложении '%s' нет.", q.Previous, o.appName),
+			})
+			return nil
+		}
+		for _, inst := range instances {
+			inst := inst
+			ch := make(chan *pb.LogEntry, logSourceBuffer)
+			sources = append(sources, ch)
+			g.Go(func() error {
+				defer close(ch)
+				return o.readStoredLogs(gCtx, inst, q, ch)
+			})
+		}
+	} else {
+		if q.ServiceName != "" && len(containers) == 0 {
+			o.logger.Warn("сервис не найден", "serviceName", q.ServiceName)
+			stream.Send(&pb.LogEntry{
+				ServiceName: "forged-daemon",
+				Message:     fmt.Sprintf("Ошибка: сервис с именем '%s' не найден в приложении '%s'.", q.ServiceName, o.appName),
+			})
+			return nil
+		}
+		for _, res := range containers {
+			res := res
+			ch := make(chan *pb.LogEntry, logSourceBuffer)
+			sources = append(sources, ch)
+			g.Go(func() error {
+				defer close(ch)
+				return o.readContainerLogs(gCtx, res, q, ch)
+			})
+		}
+	}
+
+	send := func(entry *pb.LogEntry) error {
+		if q.Timestamps && entry.GetTimeUnixNano() != 0 {
+			entry.Message = time.Unix(0, entry.GetTimeUnixNano()).UTC().Format(time.RFC3339Nano) + " " + entry.GetMessage()
+		}
+		if err := stream.Send(entry); err != nil {
+			o.logger.Error("не удалось отправить лог клиенту", "error", err)
+			return err
+		}
+		return nil
+	}
+
+	g.Go(func() error {
+		if q.Follow {
+			return mergeLogsWindowed(gCtx, sources, logMergeWindow, send)
+		}
+		return mergeLogsSorted(gCtx, sources, send)
+	})
+
+	return g.Wait()
+}
+
+// previousInstances выбирает для каждого сервиса q.Previous-й с конца сохраненный
+// экземпляр контейнера среди тех, что уже не принадлежат приложению
+func (o *Orchestrator) previousInstances(q LogQuery, resources []state.Resource) ([]logstore.Instance, error) {
+	if o.options.LogStore == nil {
+		return nil, errors.New("сохранение логов контейнеров отключено в настройках демона (containerLogs.enabled)")
+	}
+
+	current := make(map[string]bool)
+	for _, res := range resources {
+		if res.ResourceType == "container" && len(res.ID) >= 12 {
+			current[res.ID[:12]] = true
+		}
+	}
+
+	services := []string{q.ServiceName}
+	if q.ServiceName == "" {
+		var err error
+		if services, err = o.options.LogStore.Services(o.appName); err != nil {
+			return nil, err
+		}
+	}
+
+	var previous []logstore.Instance
+	for _, service := range services {
+		instances, err := o.options.LogStore.Instances(o.appName, service)
+		if err != nil {
+			return nil, err
+		}
+		skip := q.Previous - 1
+		for i := len(instances) - 1; i >= 0; i-- {
+			if current[instances[i].ContainerID] {
+				continue
+			}
+			if skip == 0 {
+				previous = append(previous, instances[i])
+				break
+			}
+			skip--
+		}
+	}
+	return previous, nil
+}
+
+// readStoredLogs передает в out сохраненные записи экземпляра контейнера, подходящие под запрос
+func (o *Orchestrator) readStoredLogs(ctx context.Context, inst logstore.Instance, q LogQuery, out chan<- *pb.LogEntry) error {
+	now := time.Now()
+	since, err := queryTime(q.Since, now)
+	if err != nil {
+		return err
+	}
+	until, err := queryTime(q.Until, now)
+	if err != nil {
+		return err
+	}
+
+	var entries []*pb.LogEntry
+	err = o.options.LogStore.Read(inst, func(r logstore.Record) error {
+		if !since.IsZero() && r.Time.Before(since) || !until.IsZero() && r.Time.After(until) {
+			return nil
+		}
+		entry := &pb.LogEntry{
+			ServiceName:  inst.ServiceName,
+			Message:      r.Message,
+			Timestamp:    r.Time.Unix(),
+			TimeUnixNano: r.Time.UnixNano(),
+			Stream:       r.Stream,
+		}
+		if !q.match(inst.LogFormat, entry) {
+			return nil
+		}
+		entries = append(entries, entry)
+		// Хранится не больше Tail последних записей
+		if q.Tail >= 0 && len(entries) > q.Tail {
+			entries = entries[len(entries)-q.Tail:]
+		}
+		return nil
+	})
+	if err != nil {
+		o.logger.Error("не удалось прочитать сохраненные логи", "service", inst.ServiceName, "containerID", inst.ContainerID, "error", err)
+		return err
+	}
+
+	for _, entry := range entries {
+		select {
+		case out <- entry:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// queryTime разбирает значение Since или Until. Пустое значение — нулевое время.
+func queryTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	ts, err := timetypes.GetTimestamp(value, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, nsec, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// readContainerLogs читает логи контейнера и передает подходящие под запрос записи в out
+func (o *Orchestrator) readContainerLogs(ctx context.Context, res state.Resource, q LogQuery, out chan<- *pb.LogEntry) error {
+	logOptions := container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     q.Follow,
+		// Время нужно всегда: по нему заполняется LogEntry и упорядочиваются записи
+		Timestamps: true,
+		Tail:       q.dockerTail(),
+		Since:      q.Since,
+		Until:      q.Until,
+	}
+
+	inspect, err := o.dockerClient.ContainerInspect(ctx, res.ID)
+	if err != nil {
+		o.logger.Error("не удалось инспектировать контейнер", "containerID", res.ID, "error", err)
+		return err
+	}
+	format := inspect.Config.Labels[LabelLogFormat]
+
+	logReader, err := o.dockerClient.ContainerLogs(ctx, res.ID, logOptions)
+	if err != nil {
+		o.logger.Error("не удалось получить логи контейнера", "containerID", res.ID, "error", err)
+		return err
+	}
+	defer logReader.Close()
+
+	emit := func(streamName, line string) error {
+		entry := parseLogLine(line)
+		if !q.match(format, entry) {
+			return nil
+		}
+		entry.ServiceName = res.ServiceName
+		entry.Stream = streamName
+		select {
+		case out <- entry:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	stdout := &logLineWriter{stream: StreamStdout, emit: emit}
+	stderr := &logLineWriter{stream: StreamStderr, emit: emit}
+	if _, err := stdcopy.StdCopy(stdout, stderr, logReader); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		o.logger.Error("ошибка при демультиплексировании логов", "error", err)
+		return err
+	}
+	if err := stdout.Flush(); err != nil {
+		return err
+	}
+	return stderr.Flush()
+}
+
+// mergeLogsSorted сливает записи нескольких источников по времени. Записи каждого
+// источника уже упорядочены, поэтому следующей отправляется самая ранняя из
+// первых записей источников: памяти нужно по одной записи на источник.
+// Запись без времени остается на своем месте в источнике: она идет сразу за
+// предыдущей записью источника.
+func mergeLogsSorted(ctx context.Context, sources []<-chan *pb.LogEntry, send func(*pb.LogEntry) error) error {
+	heads := make([]*pb.LogEntry, len(sources))
+	// times — время, по которому упорядочивается первая запись источника
+	times := make([]int64, len(sources))
+	next := func(i int) error {
+		select {
+		case entry, ok := <-sources[i]:
+			if !ok {
+				heads[i] = nil
+			} else {
+				heads[i] = entry
+				if t := entry.GetTimeUnixNano(); t != 0 {
+					times[i] = t
+				}
+			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for i := range sources {
+		if err := next(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		earliest := -1
+		for i, head := range heads {
+			if head != nil && (earliest < 0 || times[i] < times[earliest]) {
+				earliest = i
+			}
+		}
+		if earliest < 0 {
+			return nil
+		}
+		if err := send(heads[earliest]); err != nil {
+			return err
+		}
+		if err := next(earliest); err != nil {
+			return err
+		}
+	}
+}
+
+// mergeLogsWindowed упорядочивает записи бесконечных источников (--follow): каждая
+// запись ждет window, не придет ли из другого источника запись с более ранним
+// временем, и затем отправляется по порядку времени. Запись без времени
+// упорядочивается по времени предыдущей записи своего источника, а если ее нет —
+// по времени получения.
+func mergeLogsWindowed(ctx context.Context, sources []<-chan *pb.LogEntry, window time.Duration, send func(*pb.LogEntry) error) error {
+	type pendingEntry struct {
+		entry    *pb.LogEntry
+		received time.Time
+		at       int64 // время, по которому упорядочивается запись
+	}
+
+	merged := make(chan pendingEntry)
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		src := src
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last int64
+			for entry := range src {
+				p := pendingEntry{entry: entry, received: time.Now(), at: entry.GetTimeUnixNano()}
+				if p.at == 0 {
+					p.at = last
+					if p.at == 0 {
+						p.at = p.received.UnixNano()
+					}
+				}
+				last = p.at
+				select {
+				case merged <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	var pending []pendingEntry
+	// flush отправляет по порядку времени записи, которые ждали не меньше window.
+	// Если среди более ранних записей есть недождавшаяся, отправка останавливается на ней.
+	flush := func(all bool) error {
+		sort.SliceStable(pending, func(i, j int) bool {
+			return pending[i].at < pending[j].at
+		})
+		deadline := time.Now().Add(-window)
+		sent := 0
+		for _, p := range pending {
+			if !all && p.received.After(deadline) {
+				break
+			}
+			if err := send(p.entry); err != nil {
+				return err
+			}
+			sent++
+		}
+		pending = pending[:copy(pending, pending[sent:])]
+		return nil
+	}
+
+	ticker := time.NewTicker(window / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case p, ok := <-merged:
+			if !ok {
+				return flush(true)
+			}
+			pending = append(pending, p)
+		case <-ticker.C:
+			if err := flush(false); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseLogLine разбирает строку лога Docker, запрошенного с временем:
+// "2024-05-01T10:00:00.123456789Z сообщение". Если времени нет, вся строка — сообщение.
+func parseLogLine(line string) *pb.LogEntry {
+	entry := &pb.LogEntry{Message: line}
+	ts, message, ok := strings.Cut(line, " ")
+	if !ok {
+		ts, message = line, ""
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return entry
+	}
+	entry.Message = message
+	entry.Timestamp = t.Unix()
+	entry.TimeUnixNano = t.UnixNano()
+	return entry
+}
+
+// logLineWriter собирает вывод одного потока контейнера в строки и передает
+// каждую целую строку в emit. Docker не гарантирует, что кадр содержит ровно одну строку.
+type logLineWriter struct {
+	stream string
+	buf    []byte
+	emit   func(stream, line string) error
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := strings.TrimSuffix(string(w.buf[:i]), "\r")
+		w.buf = w.buf[:copy(w.buf, w.buf[i+1:])]
+		if err := w.emit(w.stream, line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush передает последнюю строку, если она не закончилась переводом строки
+func (w *logLineWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := string(w.buf)
+	w.buf = w.buf[:0]
+	return w.emit(w.stream, line)
+}
@@ -0,0 +1,261 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/logstore"
+	"github.com/waste3d/forge/internal/state"
+)
+
+func TestParseLogLine(t *testing.T) {
+	entry := parseLogLine("2024-05-01T10:00:00.123456789Z listening on :8080")
+	want := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)
+	if entry.GetMessage() != "listening on :8080" || entry.GetTimeUnixNano() != want.UnixNano() || entry.GetTimestamp() != want.Unix() {
+		t.Errorf("разобрано %+v, ожидалось сообщение 'listening on :8080' со временем %s", entry, want)
+	}
+
+	if entry := parseLogLine("без времени"); entry.GetMessage() != "без времени" || entry.GetTimeUnixNano() != 0 {
+		t.Errorf("строка без времени разобрана как %+v", entry)
+	}
+	if entry := parseLogLine("2024-05-01T10:00:00Z"); entry.GetMessage() != "" || entry.GetTimeUnixNano() == 0 {
+		t.Errorf("пустая строка со временем разобрана как %+v", entry)
+	}
+}
+
+func TestLogLineWriter(t *testing.T) {
+	var lines []string
+	w := &logLineWriter{stream: StreamStderr, emit: func(stream, line string) error {
+		lines = append(lines, stream+": "+line)
+		return nil
+	}}
+
+	for _, chunk := range []string{"first\r\nsec", "ond\n", "\nlast"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	want := []string{"stderr: first", "stderr: second", "stderr: ", "stderr: last"}
+	if len(lines) != len(want) {
+		t.Fatalf("строки = %q, ожидалось %q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("строка %d = %q, ожидалось %q", i, lines[i], want[i])
+		}
+	}
+}
+
+// logSource возвращает закрытый канал с записями сервиса, время которых — times
+func logSource(service string, times ...int64) <-chan *pb.LogEntry {
+	ch := make(chan *pb.LogEntry, len(times))
+	for _, t := range times {
+		ch <- &pb.LogEntry{ServiceName: service, TimeUnixNano: t}
+	}
+	close(ch)
+	return ch
+}
+
+func TestMergeLogsSorted(t *testing.T) {
+	sources := []<-chan *pb.LogEntry{
+		logSource("api", 1, 4, 5),
+		logSource("db", 2, 3, 6),
+		logSource("empty"),
+	}
+
+	var got []int64
+	err := mergeLogsSorted(context.Background(), sources, func(e *pb.LogEntry) error {
+		got = append(got, e.GetTimeUnixNano())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("слияние завершилось ошибкой: %v", err)
+	}
+	want := []int64{1, 2, 3, 4, 5, 6}
+	if len(got) != len(want) {
+		t.Fatalf("получено %v, ожидалось %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("получено %v, ожидалось %v", got, want)
+		}
+	}
+}
+
+func TestMergeLogsWindowed(t *testing.T) {
+	api := make(chan *pb.LogEntry, 1)
+	db := make(chan *pb.LogEntry, 1)
+	sources := []<-chan *pb.LogEntry{api, db}
+
+	// Запись db старше, но приходит позже записи api — в пределах окна
+	api <- &pb.LogEntry{ServiceName: "api", TimeUnixNano: 2}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		db <- &pb.LogEntry{ServiceName: "db", TimeUnixNano: 1}
+		close(api)
+		close(db)
+	}()
+
+	var got []string
+	err := mergeLogsWindowed(context.Background(), sources, 200*time.Millisecond, func(e *pb.LogEntry) error {
+		got = append(got, e.GetServiceName())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("слияние завершилось ошибкой: %v", err)
+	}
+	if len(got) != 2 || got[0] != "db" || got[1] != "api" {
+		t.Errorf("получено %v, ожидалось [db api]", got)
+	}
+}
+
+func TestMergeLogsWithoutTime(t *testing.T) {
+	// Строка без времени — продолжение предыдущей записи своего источника
+	sources := []<-chan *pb.LogEntry{
+		logSource("api", 3, 0, 5),
+		logSource("db", 1, 4),
+	}
+	var got []int64
+	err := mergeLogsSorted(context.Background(), sources, func(e *pb.LogEntry) error {
+		got = append(got, e.GetTimeUnixNano())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("слияние завершилось ошибкой: %v", err)
+	}
+	if want := []int64{1, 3, 0, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("получено %v, ожидалось %v", got, want)
+	}
+
+	// В потоке --follow запись без времени не обгоняет полученные раньше
+	api := make(chan *pb.LogEntry, 1)
+	db := make(chan *pb.LogEntry, 1)
+	api <- &pb.LogEntry{ServiceName: "api", TimeUnixNano: time.Now().UnixNano()}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		db <- &pb.LogEntry{ServiceName: "db"}
+		close(api)
+		close(db)
+	}()
+	var services []string
+	err = mergeLogsWindowed(context.Background(), []<-chan *pb.LogEntry{api, db}, 200*time.Millisecond, func(e *pb.LogEntry) error {
+		services = append(services, e.GetServiceName())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("слияние завершилось ошибкой: %v", err)
+	}
+	if want := []string{"api", "db"}; !reflect.DeepEqual(services, want) {
+		t.Errorf("получено %v, ожидалось %v", services, want)
+	}
+}
+
+// storeInstance сохраняет логи экземпляра контейнера сервиса api приложения shop
+func storeInstance(t *testing.T, store *logstore.Store, containerID string, created time.Time, messages ...string) {
+	t.Helper()
+	w, err := store.Create("shop", "api", containerID, created, "")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for i, message := range messages {
+		if err := w.Write(logstore.Record{Time: created.Add(time.Duration(i+1) * time.Second), Stream: StreamStdout, Message: message}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+}
+
+func TestPreviousInstances(t *testing.T) {
+	store, err := logstore.Open(logstore.Config{Dir: t.TempDir(), MaxFileSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("logstore.Open: %v", err)
+	}
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	storeInstance(t, store, "aaaaaaaaaaaa0000", base, "first")
+	storeInstance(t, store, "bbbbbbbbbbbb0000", base.Add(time.Hour), "second")
+	storeInstance(t, store, "cccccccccccc0000", base.Add(2*time.Hour), "current")
+
+	o := &Orchestrator{appName: "shop", options: Options{LogStore: store}, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	// Последний экземпляр принадлежит приложению и в --previous не попадает
+	resources := []state.Resource{{ID: "cccccccccccc0000", AppName: "shop", ServiceName: "api", ResourceType: "container"}}
+
+	tests := []struct {
+		previous int
+		want     []string
+	}{
+		{previous: 1, want: []string{"bbbbbbbbbbbb"}},
+		{previous: 2, want: []string{"aaaaaaaaaaaa"}},
+		{previous: 3, want: nil},
+	}
+	for _, tt := range tests {
+		instances, err := o.previousInstances(LogQuery{Previous: tt.previous}, resources)
+		if err != nil {
+			t.Fatalf("previousInstances(%d): %v", tt.previous, err)
+		}
+		var got []string
+		for _, inst := range instances {
+			got = append(got, inst.ContainerID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("previousInstances(%d) = %v, ожидалось %v", tt.previous, got, tt.want)
+		}
+	}
+
+	o.options.LogStore = nil
+	if _, err := o.previousInstances(LogQuery{Previous: 1}, resources); err == nil {
+		t.Error("ожидалась ошибка при отключенном сохранении логов")
+	}
+}
+
+func TestReadStoredLogs(t *testing.T) {
+	store, err := logstore.Open(logstore.Config{Dir: t.TempDir(), MaxFileSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("logstore.Open: %v", err)
+	}
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	storeInstance(t, store, "aaaaaaaaaaaa0000", created, "boot", "error: disk", "ready", "error: timeout")
+	instances, _ := store.Instances("shop", "api")
+	o := &Orchestrator{appName: "shop", options: Options{LogStore: store}, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	tests := []struct {
+		name  string
+		query LogQuery
+		want  []string
+	}{
+		{name: "все", query: LogQuery{Tail: -1}, want: []string{"boot", "error: disk", "ready", "error: timeout"}},
+		{name: "tail", query: LogQuery{Tail: 2}, want: []string{"ready", "error: timeout"}},
+		{name: "grep и tail", query: LogQuery{Tail: 1, Grep: regexp.MustCompile("^error")}, want: []string{"error: timeout"}},
+		{name: "since и until", query: LogQuery{Tail: -1, Since: "2024-05-01T10:00:02Z", Until: "2024-05-01T10:00:03Z"}, want: []string{"error: disk", "ready"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := make(chan *pb.LogEntry, 10)
+			if err := o.readStoredLogs(context.Background(), instances[0], tt.query, out); err != nil {
+				t.Fatalf("readStoredLogs: %v", err)
+			}
+			close(out)
+			var got []string
+			for entry := range out {
+				if entry.GetServiceName() != "api" || entry.GetTimeUnixNano() == 0 {
+					t.Errorf("неполная запись: %+v", entry)
+				}
+				got = append(got, entry.GetMessage())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("получено %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,22 +3,26 @@ package orchestrator
 import (
 	// Добавлен для архивации
 
-	"bufio"
 	"context"
 	"fmt"
 	"io"
 	"log"
 	"log/slog" // Добавлен для работы с путями
 	"net"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
-	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-units"
+	"github.com/google/uuid"
+	"github.com/waste3d/forge/internal/events"
 	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/logstore"
 	"github.com/waste3d/forge/internal/state"
 	"github.com/waste3d/forge/pkg/parser"
 	"golang.org/x/sync/errgroup"
@@ -26,85 +30,255 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// Options — настройки демона, влияющие на работу оркестратора
+type Options struct {
+	// BindAddress — адрес хоста, на котором публикуются порты, если он не задан
+	// ни в описании порта, ни в поле 'bindAddress' приложения.
+	BindAddress string
+	// AllowPublicBind разрешает публикацию портов за пределами loopback-интерфейса
+	// для всех приложений.
+	AllowPublicBind bool
+	// DockerHost — адрес Docker API, например unix:///var/run/docker.sock.
+	// Пустое значение означает DOCKER_HOST или сокет по умолчанию.
+	DockerHost string
+	// Parallelism ограничивает число узлов, которые запускаются, образов, которые
+	// собираются, и контейнеров, которые удаляются одновременно. 0 означает без ограничений.
+	Parallelism int
+	// Events получает события жизненного цикла узлов. nil — события не публикуются.
+	Events events.Publisher
+	// LogStore хранит логи удаленных контейнеров для 'forge logs --previous'.
+	// nil — логи не сохраняются.
+	LogStore *logstore.Store
+}
+
+// NewDockerClient создает клиент Docker API. Пустой host означает настройки из окружения.
+func NewDockerClient(host string) (*client.Client, error) {
+	opts := []client.Opt{client.FromEnv}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания клиента Docker: %v", err)
+	}
+	return cli, nil
+}
+
 type Orchestrator struct {
-	dockerClient *client.Client
+	dockerClient client.APIClient
 	appName      string
-	stream       pb.Forge_UpServer
-	stateManager *state.Manager
+	stream       LogSender
+	stateManager state.Manager
 	logger       *slog.Logger
+	options      Options
+	portEnv      []string // порты хоста всех узлов, передаются в каждый контейнер
+	runID        string   // идентификатор текущего запуска 'forge up'
+	sendMu       sync.Mutex
+}
+
+// LogSender получает сообщения о ходе операции: поток gRPC клиента или буфер задания демона
+type LogSender interface {
+	Send(*pb.LogEntry) error
 }
 
-func New(appName string, stream pb.Forge_UpServer, logger *slog.Logger, sm *state.Manager) (*Orchestrator, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+func New(appName string, stream LogSender, logger *slog.Logger, sm state.Manager, opts Options) (*Orchestrator, error) {
+	cli, err := NewDockerClient(opts.DockerHost)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка создания клиента Docker: %v", err)
+		return nil, err
 	}
+	return newWithClient(cli, appName, stream, logger, sm, opts), nil
+}
 
+// newWithClient создает оркестратор, работающий через уже открытый клиент Docker
+func newWithClient(cli client.APIClient, appName string, stream LogSender, logger *slog.Logger, sm state.Manager, opts Options) *Orchestrator {
 	return &Orchestrator{
 		dockerClient: cli,
 		appName:      appName,
 		stream:       stream,
 		stateManager: sm,
 		logger:       logger.With("appName", appName),
-	}, nil
+		options:      opts,
+	}
+}
+
+// SetRunID задает идентификатор запуска, которым помечаются созданные ресурсы
+func (o *Orchestrator) SetRunID(runID string) {
+	o.runID = runID
 }
 
-func (o *Orchestrator) Up(ctx context.Context, config *parser.Config) error {
+func (o *Orchestrator) Up(ctx context.Context, config *parser.Config) (err error) {
+	if o.runID == "" {
+		o.runID = uuid.New().String()
+	}
+
+	allNodes, err := buildNodes(config)
+	if err != nil {
+		o.logger.Error("некорректная конфигурация узлов", "error", err)
+		return err
+	}
+
+	levels, err := Levels(allNodes)
+	if err != nil {
+		o.logger.Error("не удалось отсортировать узлы", "error", err)
+		return fmt.Errorf("не удалось отсортировать узлы: %w", err)
+	}
+	var sortedNodes []Node
+	for _, level := range levels {
+		sortedNodes = append(sortedNodes, level...)
+	}
+
+	if err := o.resolveBindAddresses(config, sortedNodes); err != nil {
+		o.logger.Error("недопустимый адрес публикации портов", "error", err)
+		return err
+	}
+
+	o.sendLog("forged-daemon", "Проверка доступности портов хоста...")
+	if err := o.preflightPorts(sortedNodes); err != nil {
+		o.logger.Error("проверка портов не пройдена", "error", err)
+		return err
+	}
+
+	previousPorts, err := o.appPortAllocations()
+	if err != nil {
+		return err
+	}
+	if err := o.recordPortAllocations(sortedNodes); err != nil {
+		return fmt.Errorf("критическая ошибка: не удалось сохранить порты в состоянии: %w", err)
+	}
+	// Порты резервируются до запуска, чтобы параллельный запуск другого
+	// приложения их не занял. Если запуск не удался, резерв возвращается к
+	// прежнему состоянию, иначе порты остались бы заняты до 'forge down'.
+	defer func() {
+		if err == nil {
+			return
+		}
+		if restoreErr := o.stateManager.ReplacePortAllocations(o.appName, previousPorts); restoreErr != nil {
+			o.logger.Error("не удалось освободить порты после неудачного запуска", "appName", o.appName, "error", restoreErr)
+		}
+	}()
+	o.portEnv = portEnvVars(sortedNodes)
+
 	networkName := fmt.Sprintf("forge-network-%s", o.appName)
 	o.sendLog("forged-daemon", fmt.Sprintf("Создание сети %s...", networkName))
 
-	networkResp, err := o.dockerClient.NetworkCreate(ctx, networkName, types.NetworkCreate{})
+	networkResp, err := o.dockerClient.NetworkCreate(ctx, networkName, types.NetworkCreate{
+		Labels: o.resourceLabels("forged-daemon"),
+	})
 	if err != nil {
 		o.logger.Error("не удалось создать сеть %s", "error", err)
 		return fmt.Errorf("не удалось создать сеть %s: %w", networkName, err)
 	}
 
 	networkID := networkResp.ID
-	if err := o.stateManager.AddResource(o.appName, "network", networkID, "forged-daemon"); err != nil {
+	err = o.stateManager.AddResource(state.Resource{
+		AppName:      o.appName,
+		ResourceType: "network",
+		ID:           networkID,
+		ServiceName:  "forged-daemon",
+		RunID:        o.runID,
+		Status:       state.StatusRunning,
+	})
+	if err != nil {
 		o.dockerClient.NetworkRemove(ctx, networkID)
 		return fmt.Errorf("критическая ошибка: не удалось сохранить состояние для сети %s: %w", networkID, err)
 	}
 
-	var allNodes []Node
-	for i := range config.Databases {
-		allNodes = append(allNodes, &DBNode{&config.Databases[i], config.Databases[i].Port})
+	// Узлы одного уровня не зависят друг от друга и запускаются одновременно,
+	// следующий уровень начинается, когда все узлы текущего готовы
+	for _, level := range levels {
+		g, gctx := errgroup.WithContext(ctx)
+		if o.options.Parallelism > 0 {
+			g.SetLimit(o.options.Parallelism)
+		}
+		for _, node := range level {
+			node := node
+			g.Go(func() error {
+				return o.startNode(gctx, node, networkID)
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
 	}
 
-	for i := range config.Services {
-		allNodes = append(allNodes, &ServiceNode{&config.Services[i], config.Services[i].Port})
-	}
+	o.sendLog("forged-daemon", fmt.Sprintf("Сеть %s создана.", networkName))
+	o.logger.Info("сеть успешно создана", "networkName", networkName, "networkID", networkID)
 
-	sortedNodes, err := Sort(allNodes)
-	if err != nil {
-		o.logger.Error("не удалось отсортировать узлы", "error", err)
-		return fmt.Errorf("не удалось отсортировать узлы: %w", err)
+	return nil
+}
+
+// startNode запускает узел и дожидается его готовности
+func (o *Orchestrator) startNode(ctx context.Context, node Node, networkID string) error {
+	nodeName := node.GetName()
+	o.sendLog("forged-daemon", fmt.Sprintf("Запуск %s...", nodeName))
+	o.publish(events.TypeStarting, nodeName, "", "")
+
+	if err := node.Start(ctx, networkID, o); err != nil {
+		o.setNodeStatus(nodeName, state.StatusFailed)
+		o.logger.Error("ошибка запуска узла", "nodeName", nodeName, "error", err)
+		o.sendLog(nodeName, fmt.Sprintf("Ошибка запуска: %v", err))
+		return fmt.Errorf("ошибка запуска узла %s: %w", nodeName, err)
 	}
 
-	for _, node := range sortedNodes {
-		nodeName := node.GetName()
-		o.sendLog("forged-daemon", fmt.Sprintf("Запуск %s...", nodeName))
+	if err := node.IsReady(ctx, o); err != nil {
+		o.setNodeStatus(nodeName, state.StatusFailed)
+		o.publish(events.TypeUnhealthy, nodeName, "", err.Error())
+		o.logger.Error("ошибка проверки готовности узла", "nodeName", nodeName, "error", err)
+		o.sendLog(nodeName, fmt.Sprintf("Ошибка проверки готовности: %v", err))
+		return fmt.Errorf("ошибка проверки готовности узла %s: %w", nodeName, err)
+	}
 
-		if err := node.Start(ctx, networkID, o); err != nil {
-			o.logger.Error("ошибка запуска узла", "nodeName", nodeName, "error", err)
-			o.sendLog(nodeName, fmt.Sprintf("Ошибка запуска: %v", err))
-			return fmt.Errorf("ошибка запуска узла %s: %w", nodeName, err)
-		}
+	o.setNodeStatus(nodeName, state.StatusRunning)
+	o.publish(events.TypeReady, nodeName, "", "")
+	o.logger.Info("узел успешно запущен и готов", "nodeName", nodeName)
 
-		if err := node.IsReady(ctx, o); err != nil {
-			o.logger.Error("ошибка проверки готовности узла", "nodeName", nodeName, "error", err)
-			o.sendLog(nodeName, fmt.Sprintf("Ошибка проверки готовности: %v", err))
-			return fmt.Errorf("ошибка проверки готовности узла %s: %w", nodeName, err)
-		}
+	o.sendLog(nodeName, "Узел успешно запущен и готов.")
+	return nil
+}
 
-		o.logger.Info("узел успешно запущен и готов", "nodeName", nodeName)
+// setNodeStatus сохраняет статус узла в состоянии. Ошибка только логируется:
+// статус — вспомогательная информация и не должен прерывать оркестрацию.
+func (o *Orchestrator) setNodeStatus(nodeName, status string) {
+	if err := o.stateManager.UpdateServiceStatus(o.appName, nodeName, status); err != nil {
+		o.logger.Error("не удалось обновить статус узла", "nodeName", nodeName, "error", err)
+	}
+}
 
-		o.sendLog(nodeName, "Узел успешно запущен и готов.")
+// publish отправляет событие жизненного цикла узла, если демон их принимает
+func (o *Orchestrator) publish(eventType, nodeName, resourceID, message string) {
+	if o.options.Events == nil {
+		return
 	}
+	o.options.Events.Publish(events.Event{
+		Type:        eventType,
+		AppName:     o.appName,
+		ServiceName: nodeName,
+		ResourceID:  resourceID,
+		Message:     message,
+		Source:      events.SourceOrchestrator,
+	})
+}
 
-	o.sendLog("forged-daemon", fmt.Sprintf("Сеть %s создана.", networkName))
-	o.logger.Info("сеть успешно создана", "networkName", networkName, "networkID", networkID)
+// buildNodes создает узлы графа для всех баз данных и сервисов конфигурации
+// и разбирает их пробросы портов.
+func buildNodes(config *parser.Config) ([]Node, error) {
+	var allNodes []Node
+	for i := range config.Databases {
+		ports, err := config.Databases[i].PortMappings()
+		if err != nil {
+			return nil, fmt.Errorf("база данных '%s': %w", config.Databases[i].Name, err)
+		}
+		allNodes = append(allNodes, &DBNode{&config.Databases[i], ports})
+	}
 
-	return nil
+	for i := range config.Services {
+		ports, err := config.Services[i].PortMappings()
+		if err != nil {
+			return nil, fmt.Errorf("сервис '%s': %w", config.Services[i].Name, err)
+		}
+		allNodes = append(allNodes, &ServiceNode{&config.Services[i], ports})
+	}
+	return allNodes, nil
 }
 
 // Down останавливает и удаляет все ресурсы, связанные с приложением.
@@ -117,13 +291,20 @@ func (o *Orchestrator) Down(ctx context.Context, appName string) error {
 		return fmt.Errorf("не удалось получить ресурсы: %w", err)
 	}
 
+	if err := o.stateManager.RemovePortAllocationsByApp(appName); err != nil {
+		o.logger.Error("не удалось освободить порты приложения", "error", err)
+	}
+
 	if len(resources) == 0 {
 		o.logger.Warn("не найдено ресурсов для приложения. процедура Down завершена.", "appName", appName)
 		return nil
 	}
 
 	g, _ := errgroup.WithContext(ctx)
-	var networkIDs []string
+	if o.options.Parallelism > 0 {
+		g.SetLimit(o.options.Parallelism)
+	}
+	var networkIDs, volumeIDs []string
 
 	for _, res := range resources {
 		res := res
@@ -153,12 +334,15 @@ func (o *Orchestrator) Down(ctx context.Context, appName string) error {
 					return err
 				}
 
+				o.publish(events.TypeRemoved, res.ServiceName, res.ID, "")
 				o.logger.Info("контейнер успешно удален", "containerID", res.ID)
 				return nil
 			})
 
 		case "network":
 			networkIDs = append(networkIDs, res.ID)
+		case "volume":
+			volumeIDs = append(volumeIDs, res.ID)
 		}
 	}
 
@@ -180,85 +364,22 @@ func (o *Orchestrator) Down(ctx context.Context, appName string) error {
 		o.logger.Info("сеть успешно удалена", "networkID", netID)
 	}
 
-	o.logger.Info("процедура Down успешно завершена", "appName", appName)
-	return nil
-}
-
-func (o *Orchestrator) Logs(ctx context.Context, serviceName string, follow bool, stream pb.Forge_LogsServer) error {
-	resources, err := o.stateManager.GetResourceByApp(o.appName)
-	if err != nil {
-		o.logger.Error("не удалось получить ресурсы из state manager", "error", err)
-		return fmt.Errorf("не удалось получить ресурсы: %w", err)
-	}
-
-	g, gCtx := errgroup.WithContext(ctx)
-
-	var matchFound bool
-
-	for _, res := range resources {
-		if res.ResourceType != "container" {
-			continue
+	for _, volumeID := range volumeIDs {
+		o.logger.Info("удаление тома", "volumeID", volumeID)
+		if err := o.dockerClient.VolumeRemove(context.Background(), volumeID, false); err != nil {
+			if !client.IsErrNotFound(err) {
+				o.logger.Error("не удалось удалить том", "volumeID", volumeID, "error", err)
+				continue
+			}
 		}
 
-		if serviceName != "" && res.ServiceName != serviceName {
-			continue
+		if err := o.stateManager.RemoveResource(volumeID); err != nil {
+			o.logger.Error("не удалось удалить ресурс тома из состояния", "resourceID", volumeID, "error", err)
 		}
-
-		matchFound = true
-
-		res := res
-
-		g.Go(func() error {
-			logOptions := container.LogsOptions{
-				ShowStdout: true,
-				ShowStderr: true,
-				Follow:     follow,
-				Timestamps: false,
-				Tail:       "100",
-			}
-
-			logReader, err := o.dockerClient.ContainerLogs(gCtx, res.ID, logOptions)
-			if err != nil {
-				o.logger.Error("не удалось получить логи контейнера", "containerID", res.ID, "error", err)
-				return err
-			}
-			defer logReader.Close()
-
-			r, w := io.Pipe()
-
-			go func() {
-				defer w.Close()
-				_, err := stdcopy.StdCopy(w, w, logReader)
-				if err != nil {
-					o.logger.Error("ошибка при демультиплексировании логов", "error", err)
-				}
-			}()
-
-			scanner := bufio.NewScanner(r)
-			for scanner.Scan() {
-				logEntry := &pb.LogEntry{
-					ServiceName: res.ServiceName,
-					Message:     scanner.Text(),
-				}
-
-				if err := stream.Send(logEntry); err != nil {
-					o.logger.Error("не удалось отправить лог клиенту", "error", err)
-					return err
-				}
-			}
-
-			return scanner.Err()
-		})
-	}
-	if serviceName != "" && !matchFound {
-		o.logger.Warn("сервис не найден", "serviceName", serviceName)
-		stream.Send(&pb.LogEntry{
-			ServiceName: "forged-daemon",
-			Message:     fmt.Sprintf("Ошибка: сервис с именем '%s' не найден в приложении '%s'.", serviceName, o.appName),
-		})
 	}
 
-	return g.Wait()
+	o.logger.Info("процедура Down успешно завершена", "appName", appName)
+	return nil
 }
 
 func (o *Orchestrator) Exec(stream pb.Forge_ExecServer) error {
@@ -364,6 +485,10 @@ func (o *Orchestrator) Build(ctx context.Context, config *parser.Config, service
 
 	buildAll := len(servicesToBuild) == 0
 
+	g, gctx := errgroup.WithContext(ctx)
+	if o.options.Parallelism > 0 {
+		g.SetLimit(o.options.Parallelism)
+	}
 	for i := range config.Services {
 		service := &config.Services[i]
 
@@ -381,28 +506,35 @@ func (o *Orchestrator) Build(ctx context.Context, config *parser.Config, service
 			continue
 		}
 
-		if _, err := o.buildService(ctx, service); err != nil {
-			o.sendLog(service.Name, fmt.Sprintf("Ошибка сборки: %v", err))
-			return err
-		}
+		g.Go(func() error {
+			imageTag, err := o.buildService(gctx, service)
+			if err != nil {
+				o.sendLog(service.Name, fmt.Sprintf("Ошибка сборки: %v", err))
+				return err
+			}
+			o.publish(events.TypeRebuilt, service.Name, "", fmt.Sprintf("образ %s", imageTag))
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
 	}
 
 	o.sendLog("forged-daemon", "Сборка образов завершена.")
 	return nil
 }
 
-func (o *Orchestrator) healthCheckPort(ctx context.Context, serviceName string, port int, timeout int) error {
-	if port == 0 {
+func (o *Orchestrator) healthCheckPort(ctx context.Context, serviceName string, address string, timeout int) error {
+	if address == "" {
 		o.sendLog(serviceName, "Проверка готовности пропущена: порт не указан.")
 		return nil
 	}
 
-	o.sendLog(serviceName, fmt.Sprintf("Проверка готовности на порту %d...", port))
+	o.sendLog(serviceName, fmt.Sprintf("Проверка готовности на %s...", address))
 
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
 	defer cancel()
 
-	address := fmt.Sprintf("localhost:%d", port)
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
@@ -416,7 +548,7 @@ func (o *Orchestrator) healthCheckPort(ctx context.Context, serviceName string,
 			if err == nil {
 				// Успех!
 				conn.Close()
-				o.sendLog(serviceName, fmt.Sprintf("Сервис готов и отвечает на порту %d.", port))
+				o.sendLog(serviceName, fmt.Sprintf("Сервис готов и отвечает на %s.", address))
 				return nil
 			}
 			// Логируем попытку для отладки, но не спамим в основной лог
@@ -468,15 +600,18 @@ func (o *Orchestrator) Status(ctx context.Context, appName string) ([]*pb.Servic
 		}
 
 		var portMappings []string
-		if inspect.HostConfig != nil {
+		if res.HostPorts != "" {
+			portMappings = strings.Split(res.HostPorts, ", ")
+		} else if inspect.HostConfig != nil {
 			for port, bindings := range inspect.HostConfig.PortBindings {
 				if len(bindings) > 0 {
 					for _, binding := range bindings {
-						mapping := fmt.Sprintf("%s:%s->%s", binding.HostIP, binding.HostPort, port.Port())
+						mapping := fmt.Sprintf("%s:%s->%s", binding.HostIP, binding.HostPort, port)
 						portMappings = append(portMappings, mapping)
 					}
 				}
 			}
+			sort.Strings(portMappings)
 		}
 
 		var statusString string
@@ -491,6 +626,14 @@ func (o *Orchestrator) Status(ctx context.Context, appName string) ([]*pb.Servic
 		} else {
 			statusString = fmt.Sprintf("Exited (%d)", inspect.State.ExitCode)
 		}
+		switch res.Status {
+		case state.StatusFailed:
+			statusString += " (не прошел проверку готовности)"
+		case state.StatusRestarting:
+			statusString += " (ожидает перезапуска)"
+		case state.StatusCrashLoop:
+			statusString += " (цикл падений)"
+		}
 
 		status := &pb.ServiceStatus{
 			AppName:      res.AppName,
@@ -500,6 +643,16 @@ func (o *Orchestrator) Status(ctx context.Context, appName string) ([]*pb.Servic
 			Created:      inspect.Created,
 			Status:       statusString,
 			Ports:        strings.Join(portMappings, ", "),
+			RestartCount: int32(inspect.RestartCount),
+			ImageId:      res.ImageID,
+			RunId:        res.RunID,
+			Running:      inspect.State.Running,
+			ExitCode:     int32(inspect.State.ExitCode),
+			OomKilled:    inspect.State.OOMKilled,
+		}
+		if inspect.HostConfig != nil {
+			status.Limits = formatLimits(inspect.HostConfig)
+			status.RestartPolicy = string(inspect.HostConfig.RestartPolicy.Name)
 		}
 		statuses = append(statuses, status)
 	}
@@ -507,6 +660,21 @@ func (o *Orchestrator) Status(ctx context.Context, appName string) ([]*pb.Servic
 	return statuses, nil
 }
 
+// formatLimits возвращает краткое описание ограничений ресурсов контейнера
+func formatLimits(hostConfig *container.HostConfig) string {
+	var limits []string
+	if hostConfig.NanoCPUs > 0 {
+		limits = append(limits, fmt.Sprintf("cpus=%s", strconv.FormatFloat(float64(hostConfig.NanoCPUs)/1e9, 'f', -1, 64)))
+	}
+	if hostConfig.Memory > 0 {
+		limits = append(limits, fmt.Sprintf("mem=%s", units.BytesSize(float64(hostConfig.Memory))))
+	}
+	if hostConfig.PidsLimit != nil && *hostConfig.PidsLimit > 0 {
+		limits = append(limits, fmt.Sprintf("pids=%d", *hostConfig.PidsLimit))
+	}
+	return strings.Join(limits, " ")
+}
+
 func (o *Orchestrator) sendLog(serviceName, message string) {
 	entry := &pb.LogEntry{
 		ServiceName: serviceName,
@@ -514,6 +682,10 @@ func (o *Orchestrator) sendLog(serviceName, message string) {
 		Message:     message,
 	}
 	if o.stream != nil {
+		// Узлы одного уровня и образы собираются параллельно, а поток gRPC
+		// не допускает одновременных Send
+		o.sendMu.Lock()
+		defer o.sendMu.Unlock()
 		if err := o.stream.Send(entry); err != nil {
 			log.Printf("Не удалось отправить лог клиенту: %v", err)
 		}
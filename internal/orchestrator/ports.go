@@ -0,0 +1,289 @@
+package orchestrator
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/waste3d/forge/internal/state"
+	"github.com/waste3d/forge/pkg/parser"
+)
+
+// DefaultBindAddress — адрес публикации портов по умолчанию. Порты доступны
+// только с локальной машины, пока пользователь явно не разрешит иное.
+const DefaultBindAddress = "127.0.0.1"
+
+// resolveBindAddresses проставляет адрес хоста каждому опубликованному порту и
+// проверяет, что публикация за пределами loopback явно разрешена.
+// Приоритет: адрес из описания порта, затем 'bindAddress' приложения, затем настройка демона.
+func (o *Orchestrator) resolveBindAddresses(config *parser.Config, nodes []Node) error {
+	defaultAddr := config.BindAddress
+	if defaultAddr == "" {
+		defaultAddr = o.options.BindAddress
+	}
+	if defaultAddr == "" {
+		defaultAddr = DefaultBindAddress
+	}
+	if net.ParseIP(defaultAddr) == nil {
+		return fmt.Errorf("некорректный адрес публикации портов '%s'", defaultAddr)
+	}
+
+	allowPublic := config.AllowPublicBind || o.options.AllowPublicBind
+
+	var exposed []string
+	for _, node := range nodes {
+		ports := node.GetPorts()
+		for i := range ports {
+			if !ports[i].Published() {
+				continue
+			}
+			if ports[i].HostIP == "" {
+				ports[i].HostIP = defaultAddr
+			}
+			if isLoopback(ports[i].HostIP) {
+				continue
+			}
+
+			exposed = append(exposed, fmt.Sprintf("%s (%s)", node.GetName(), ports[i]))
+
+			if _, isDB := node.(*DBNode); isDB {
+				o.sendLog(node.GetName(), fmt.Sprintf("⚠️ ВНИМАНИЕ: база данных доступна за пределами локальной машины по адресу %s. Убедитесь, что это действительно нужно.", ports[i]))
+			}
+		}
+	}
+
+	if len(exposed) > 0 && !allowPublic {
+		return fmt.Errorf("публикация портов за пределами loopback не разрешена: %s. Укажите 'allowPublicBind: true' в forge.yaml или запустите демон с флагом -allow-public-bind", strings.Join(exposed, ", "))
+	}
+	return nil
+}
+
+func isLoopback(hostIP string) bool {
+	ip := net.ParseIP(hostIP)
+	return ip != nil && ip.IsLoopback()
+}
+
+// reservedPort — порт хоста, уже занятый другим приложением Forge или узлом текущего
+type reservedPort struct {
+	hostIP   string
+	port     int
+	protocol string
+	owner    string
+}
+
+// preflightPorts проверяет, что все фиксированные порты хоста свободны, и выделяет
+// порты для значений "auto". Все найденные конфликты возвращаются одной ошибкой,
+// чтобы не падать глубоко внутри ContainerStart с невнятным сообщением Docker.
+func (o *Orchestrator) preflightPorts(nodes []Node) error {
+	allocations, err := o.stateManager.GetAllPortAllocations()
+	if err != nil {
+		return fmt.Errorf("не удалось получить занятые порты: %w", err)
+	}
+
+	var reserved []reservedPort
+	for _, a := range allocations {
+		if a.AppName == o.appName {
+			continue
+		}
+		reserved = append(reserved, reservedPort{
+			hostIP:   a.HostIP,
+			port:     a.HostPort,
+			protocol: a.Protocol,
+			owner:    fmt.Sprintf("приложением '%s' (сервис '%s')", a.AppName, a.ServiceName),
+		})
+	}
+
+	var conflicts []string
+	for _, node := range nodes {
+		ports := node.GetPorts()
+		for i := range ports {
+			m := &ports[i]
+			if !m.Published() || m.Auto {
+				continue
+			}
+			for _, pair := range m.Pairs() {
+				candidate := reservedPort{hostIP: m.HostIP, port: pair[0], protocol: m.Protocol}
+				if owner := findReservation(reserved, candidate); owner != "" {
+					conflicts = append(conflicts, fmt.Sprintf("%s: порт %s:%d/%s уже занят %s", node.GetName(), m.HostIP, pair[0], m.Protocol, owner))
+					continue
+				}
+				if err := probePort(m.HostIP, pair[0], m.Protocol); err != nil {
+					conflicts = append(conflicts, fmt.Sprintf("%s: порт %s:%d/%s недоступен на хосте (занят другим процессом?): %v", node.GetName(), m.HostIP, pair[0], m.Protocol, err))
+					continue
+				}
+				candidate.owner = fmt.Sprintf("сервисом '%s' этого же приложения", node.GetName())
+				reserved = append(reserved, candidate)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("обнаружены конфликты портов:\n  - %s", strings.Join(conflicts, "\n  - "))
+	}
+
+	for _, node := range nodes {
+		ports := node.GetPorts()
+		for i := range ports {
+			m := &ports[i]
+			if !m.Auto {
+				continue
+			}
+			port, err := allocatePort(m.HostIP, m.Protocol, reserved)
+			if err != nil {
+				return fmt.Errorf("%s: не удалось выделить порт хоста для %d/%s: %w", node.GetName(), m.ContainerPort, m.Protocol, err)
+			}
+			m.HostPort, m.HostPortEnd = port, port
+			reserved = append(reserved, reservedPort{hostIP: m.HostIP, port: port, protocol: m.Protocol})
+			o.sendLog(node.GetName(), fmt.Sprintf("Автоматически выделен порт хоста %s:%d для %d/%s.", m.HostIP, port, m.ContainerPort, m.Protocol))
+		}
+	}
+
+	return nil
+}
+
+// appPortAllocations возвращает порты, уже сохраненные для приложения
+func (o *Orchestrator) appPortAllocations() ([]state.PortAllocation, error) {
+	allocations, err := o.stateManager.GetAllPortAllocations()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить занятые порты: %w", err)
+	}
+	var own []state.PortAllocation
+	for _, a := range allocations {
+		if a.AppName == o.appName {
+			own = append(own, a)
+		}
+	}
+	return own, nil
+}
+
+// recordPortAllocations сохраняет опубликованные порты в состоянии, чтобы другие
+// приложения видели их при проверке конфликтов.
+func (o *Orchestrator) recordPortAllocations(nodes []Node) error {
+	var allocations []state.PortAllocation
+	for _, node := range nodes {
+		for _, m := range node.GetPorts() {
+			for _, pair := range m.Pairs() {
+				if pair[0] == 0 {
+					continue
+				}
+				allocations = append(allocations, state.PortAllocation{
+					AppName:       o.appName,
+					ServiceName:   node.GetName(),
+					HostIP:        m.HostIP,
+					HostPort:      pair[0],
+					ContainerPort: pair[1],
+					Protocol:      m.Protocol,
+				})
+			}
+		}
+	}
+	return o.stateManager.ReplacePortAllocations(o.appName, allocations)
+}
+
+// portEnvVars возвращает переменные окружения с портами хоста всех узлов приложения,
+// которые передаются в каждый контейнер: FORGE_<УЗЕЛ>_PORT для основного порта и
+// FORGE_<УЗЕЛ>_PORT_<ПОРТ_КОНТЕЙНЕРА>[_UDP] для каждого проброса.
+func portEnvVars(nodes []Node) []string {
+	var env []string
+	for _, node := range nodes {
+		prefix := "FORGE_" + envName(node.GetName()) + "_PORT"
+		primary := 0
+		for _, m := range node.GetPorts() {
+			for _, pair := range m.Pairs() {
+				if pair[0] == 0 {
+					continue
+				}
+				if primary == 0 {
+					primary = pair[0]
+				}
+				name := prefix + "_" + strconv.Itoa(pair[1])
+				if m.Protocol == "udp" {
+					name += "_UDP"
+				}
+				env = append(env, fmt.Sprintf("%s=%d", name, pair[0]))
+			}
+		}
+		if primary > 0 {
+			env = append(env, fmt.Sprintf("%s=%d", prefix, primary))
+		}
+	}
+	return env
+}
+
+func findReservation(reserved []reservedPort, candidate reservedPort) string {
+	for _, r := range reserved {
+		if r.port == candidate.port && r.protocol == candidate.protocol && addressesOverlap(r.hostIP, candidate.hostIP) {
+			return r.owner
+		}
+	}
+	return ""
+}
+
+// addressesOverlap сообщает, конфликтуют ли привязки к двум адресам:
+// совпадающие адреса и любой адрес вместе с 0.0.0.0/:: пересекаются.
+func addressesOverlap(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return a == b
+	}
+	return ipA.Equal(ipB) || ipA.IsUnspecified() || ipB.IsUnspecified()
+}
+
+// probePort пытается занять порт на хосте, чтобы убедиться, что он свободен
+func probePort(hostIP string, port int, protocol string) error {
+	address := net.JoinHostPort(hostIP, strconv.Itoa(port))
+	if protocol == "udp" {
+		conn, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return lis.Close()
+}
+
+// allocatePort просит ОС выделить свободный порт и проверяет, что он не зарезервирован
+// другим приложением Forge.
+func allocatePort(hostIP, protocol string, reserved []reservedPort) (int, error) {
+	const maxAttempts = 20
+	address := net.JoinHostPort(hostIP, "0")
+
+	for i := 0; i < maxAttempts; i++ {
+		var port int
+		if protocol == "udp" {
+			conn, err := net.ListenPacket("udp", address)
+			if err != nil {
+				return 0, err
+			}
+			port = conn.LocalAddr().(*net.UDPAddr).Port
+			conn.Close()
+		} else {
+			lis, err := net.Listen("tcp", address)
+			if err != nil {
+				return 0, err
+			}
+			port = lis.Addr().(*net.TCPAddr).Port
+			lis.Close()
+		}
+
+		if findReservation(reserved, reservedPort{hostIP: hostIP, port: port, protocol: protocol}) == "" {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("свободный порт не найден за %d попыток", maxAttempts)
+}
+
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
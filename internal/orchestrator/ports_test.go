@@ -0,0 +1,49 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/waste3d/forge/internal/state"
+	"github.com/waste3d/forge/pkg/parser"
+)
+
+func TestUpRestoresPortsOnFailure(t *testing.T) {
+	previous := []state.PortAllocation{
+		{AppName: "shop", ServiceName: "api", HostIP: "127.0.0.1", HostPort: 18080, ContainerPort: 80, Protocol: "tcp"},
+	}
+	other := state.PortAllocation{AppName: "blog", ServiceName: "web", HostIP: "127.0.0.1", HostPort: 18081, ContainerPort: 80, Protocol: "tcp"}
+
+	tests := []struct {
+		name     string
+		previous []state.PortAllocation
+	}{
+		{name: "первый запуск", previous: nil},
+		{name: "повторный запуск", previous: previous},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := state.NewMemoryManager()
+			sm.ReplacePortAllocations("blog", []state.PortAllocation{other})
+			sm.ReplacePortAllocations("shop", tt.previous)
+
+			o := newWithClient(&fakeDocker{networkErr: errors.New("network failed")}, "shop", nil, slog.New(slog.NewTextHandler(io.Discard, nil)), sm, Options{})
+			config := &parser.Config{AppName: "shop", Services: []parser.ServiceConfig{
+				{Name: "api", Image: "nginx", Port: parser.AutoPort, InternalPort: 80},
+			}}
+			if err := o.Up(context.Background(), config); err == nil {
+				t.Fatal("ожидалась ошибка создания сети")
+			}
+
+			all, _ := sm.GetAllPortAllocations()
+			want := append([]state.PortAllocation{other}, tt.previous...)
+			if !reflect.DeepEqual(all, want) {
+				t.Errorf("порты после неудачного запуска: %+v, ожидалось %+v", all, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/waste3d/forge/internal/state"
+)
+
+// ReconcileReport — результат сверки состояния с Docker
+type ReconcileReport struct {
+	// Adopted — ресурсы с метками Forge, которых не было в состоянии
+	Adopted []state.Resource
+	// Pruned — записи состояния, для которых ресурс в Docker больше не существует
+	Pruned []state.Resource
+}
+
+// Reconcile сверяет таблицу ресурсов с объектами Docker, помеченными метками Forge:
+// осиротевшие контейнеры, сети и тома добавляются в состояние, а записи об
+// удаленных в обход Forge ресурсах удаляются. При dryRun состояние не изменяется.
+func (o *Orchestrator) Reconcile(ctx context.Context, dryRun bool) (*ReconcileReport, error) {
+	o.logger.Info("начинаю сверку состояния с Docker", "dryRun", dryRun)
+
+	live, err := o.listManagedResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := o.stateManager.GetAllResources()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить ресурсы из state manager: %w", err)
+	}
+
+	known := make(map[string]bool, len(resources))
+	for _, res := range resources {
+		known[res.ID] = true
+	}
+
+	report := &ReconcileReport{}
+
+	for _, res := range live {
+		if known[res.ID] {
+			continue
+		}
+		o.logger.Info("ресурс принят под управление", "appName", res.AppName, "type", res.ResourceType, "resourceID", res.ID)
+		report.Adopted = append(report.Adopted, res)
+	}
+
+	liveIDs := make(map[string]bool, len(live))
+	for _, res := range live {
+		liveIDs[res.ID] = true
+	}
+
+	for _, res := range resources {
+		if liveIDs[res.ID] {
+			continue
+		}
+		// Ресурсы, созданные до появления меток, не попадают в выборку по фильтру,
+		// поэтому перед удалением записи убеждаемся, что объекта в Docker действительно нет.
+		exists, err := o.resourceExists(ctx, res)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			continue
+		}
+		o.logger.Info("устаревшая запись удалена из состояния", "appName", res.AppName, "type", res.ResourceType, "resourceID", res.ID)
+		report.Pruned = append(report.Pruned, res)
+	}
+
+	if !dryRun {
+		prunedIDs := make([]string, 0, len(report.Pruned))
+		for _, res := range report.Pruned {
+			prunedIDs = append(prunedIDs, res.ID)
+		}
+		if err := o.stateManager.ApplyResourceChanges(report.Adopted, prunedIDs); err != nil {
+			return nil, fmt.Errorf("не удалось обновить состояние: %w", err)
+		}
+	}
+
+	o.logger.Info("сверка состояния завершена", "adopted", len(report.Adopted), "pruned", len(report.Pruned))
+	return report, nil
+}
+
+// listManagedResources возвращает все контейнеры, сети и тома с меткой Forge
+func (o *Orchestrator) listManagedResources(ctx context.Context) ([]state.Resource, error) {
+	var resources []state.Resource
+
+	containers, err := o.dockerClient.ContainerList(ctx, container.ListOptions{All: true, Filters: managedFilter()})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список контейнеров: %w", err)
+	}
+	for _, c := range containers {
+		res := labeledResource("container", c.ID, c.Labels)
+		res.ImageID = c.ImageID
+		res.Status = c.State
+		resources = append(resources, res)
+	}
+
+	networks, err := o.dockerClient.NetworkList(ctx, network.ListOptions{Filters: managedFilter()})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список сетей: %w", err)
+	}
+	for _, n := range networks {
+		resources = append(resources, labeledResource("network", n.ID, n.Labels))
+	}
+
+	volumes, err := o.dockerClient.VolumeList(ctx, volume.ListOptions{Filters: managedFilter()})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список томов: %w", err)
+	}
+	for _, v := range volumes.Volumes {
+		resources = append(resources, labeledResource("volume", v.Name, v.Labels))
+	}
+
+	return resources, nil
+}
+
+// resourceExists проверяет, существует ли ресурс в Docker
+func (o *Orchestrator) resourceExists(ctx context.Context, res state.Resource) (bool, error) {
+	var err error
+	switch res.ResourceType {
+	case "container":
+		_, err = o.dockerClient.ContainerInspect(ctx, res.ID)
+	case "network":
+		_, err = o.dockerClient.NetworkInspect(ctx, res.ID, network.InspectOptions{})
+	case "volume":
+		_, err = o.dockerClient.VolumeInspect(ctx, res.ID)
+	default:
+		return true, nil
+	}
+
+	if err == nil {
+		return true, nil
+	}
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
+	return false, fmt.Errorf("не удалось проверить ресурс %s: %w", res.ID, err)
+}
+
+func labeledResource(resourceType, id string, labels map[string]string) state.Resource {
+	return state.Resource{
+		ID:           id,
+		AppName:      labels[LabelApp],
+		ResourceType: resourceType,
+		ServiceName:  labels[LabelService],
+	}
+}
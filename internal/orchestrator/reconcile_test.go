@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/waste3d/forge/internal/state"
+)
+
+func TestReconcile(t *testing.T) {
+	docker := &fakeDocker{
+		containers: []types.Container{
+			// Известный контейнер: запись остается как есть
+			{ID: "known", State: "running", Labels: managedLabels("shop", "api")},
+			// Осиротевший контейнер Forge, в том числе остановленный, принимается под управление
+			{ID: "orphan", State: "exited", Labels: managedLabels("shop", "worker")},
+			// Контейнер, созданный до появления меток: в выборку не попадает, но существует
+			{ID: "legacy", State: "running"},
+			// Чужой контейнер не принимается под управление
+			{ID: "foreign", State: "running", Labels: map[string]string{"com.example": "x"}},
+		},
+		networks: []network.Summary{{ID: "net-orphan", Labels: managedLabels("shop", "forged-daemon")}},
+		volumes:  []*volume.Volume{{Name: "vol-orphan", Labels: managedLabels("shop", "db")}},
+	}
+
+	sm := state.NewMemoryManager()
+	for _, res := range []state.Resource{
+		{ID: "known", AppName: "shop", ResourceType: "container", ServiceName: "api"},
+		{ID: "legacy", AppName: "blog", ResourceType: "container", ServiceName: "web"},
+		{ID: "removed", AppName: "shop", ResourceType: "container", ServiceName: "cache"},
+		{ID: "net-removed", AppName: "blog", ResourceType: "network", ServiceName: "forged-daemon"},
+	} {
+		if err := sm.AddResource(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := &Orchestrator{dockerClient: docker, stateManager: sm, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	report, err := o.Reconcile(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Reconcile(dryRun): %v", err)
+	}
+	assertIDs(t, "принятые", report.Adopted, []string{"net-orphan", "orphan", "vol-orphan"})
+	assertIDs(t, "удаленные", report.Pruned, []string{"net-removed", "removed"})
+	for _, res := range report.Adopted {
+		if res.ID == "orphan" && (res.AppName != "shop" || res.ServiceName != "worker" || res.ResourceType != "container" || res.Status != "exited") {
+			t.Errorf("принятый контейнер восстановлен по меткам неверно: %+v", res)
+		}
+	}
+
+	resources, _ := sm.GetAllResources()
+	assertIDs(t, "состояние после dryRun", resources, []string{"known", "legacy", "net-removed", "removed"})
+
+	if _, err := o.Reconcile(context.Background(), false); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	resources, _ = sm.GetAllResources()
+	assertIDs(t, "состояние после сверки", resources, []string{"known", "legacy", "net-orphan", "orphan", "vol-orphan"})
+
+	// Повторная сверка ничего не меняет
+	report, err = o.Reconcile(context.Background(), false)
+	if err != nil {
+		t.Fatalf("повторный Reconcile: %v", err)
+	}
+	if len(report.Adopted) != 0 || len(report.Pruned) != 0 {
+		t.Errorf("повторная сверка изменила состояние: %+v", report)
+	}
+}
+
+func assertIDs(t *testing.T, what string, resources []state.Resource, want []string) {
+	t.Helper()
+	got := make([]string, 0, len(resources))
+	for _, res := range resources {
+		got = append(got, res.ID)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: %v, ожидалось %v", what, got, want)
+	}
+}
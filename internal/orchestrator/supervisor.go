@@ -0,0 +1,260 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/waste3d/forge/internal/events"
+	"github.com/waste3d/forge/internal/state"
+)
+
+const (
+	// crashResetAfter — если контейнер проработал дольше, следующее падение
+	// снова считается первым
+	crashResetAfter = 10 * time.Minute
+	// crashLoopThreshold — после стольких падений подряд узел считается в цикле падений
+	crashLoopThreshold = 3
+	// restartPollInterval — как часто Supervisor проверяет, перезапустил ли Docker контейнер
+	restartPollInterval = time.Second
+)
+
+// Supervisor следит за контейнерами Forge, которые Docker перезапускает по
+// политике 'restart' их узла: отмечает падения и цикл падений в состоянии и
+// событиях, а после перезапуска повторяет проверку готовности. О завершении и
+// удалении контейнеров он узнает от демона через ContainerExited и ContainerRemoved.
+type Supervisor struct {
+	state  state.Manager
+	docker client.APIClient
+	logger *slog.Logger
+	// options возвращает текущие настройки демона: они меняются при перезагрузке
+	options func() Options
+	// notify сообщает Run, что в pending появились события
+	notify chan struct{}
+
+	mu sync.Mutex
+	// pending — события, еще не обработанные Run. Очередь не ограничена:
+	// потерянное падение скрыло бы цикл падений.
+	pending []events.Event
+	crashes map[string]*crashState // идентификатор контейнера -> падения подряд
+	wg      sync.WaitGroup
+}
+
+type crashState struct {
+	count int
+	// cancel прекращает ожидание перезапуска
+	cancel context.CancelFunc
+}
+
+func NewSupervisor(sm state.Manager, docker client.APIClient, logger *slog.Logger, options func() Options) *Supervisor {
+	return &Supervisor{
+		state:   sm,
+		docker:  docker,
+		logger:  logger.With("component", "supervisor"),
+		options: options,
+		notify:  make(chan struct{}, 1),
+		crashes: make(map[string]*crashState),
+	}
+}
+
+// ContainerExited сообщает о завершении контейнера Forge по событию Docker die
+func (s *Supervisor) ContainerExited(e events.Event) {
+	s.enqueue(e)
+}
+
+// ContainerRemoved сообщает об удалении контейнера
+func (s *Supervisor) ContainerRemoved(containerID string) {
+	s.enqueue(events.Event{Type: events.TypeRemoved, ResourceID: containerID})
+}
+
+func (s *Supervisor) enqueue(e events.Event) {
+	s.mu.Lock()
+	s.pending = append(s.pending, e)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run обрабатывает события, пока не будет отменен ctx, и дожидается завершения
+// начатых проверок
+func (s *Supervisor) Run(ctx context.Context) {
+	defer s.wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.notify:
+			s.mu.Lock()
+			pending := s.pending
+			s.pending = nil
+			s.mu.Unlock()
+			for _, e := range pending {
+				if ctx.Err() != nil {
+					return
+				}
+				if e.Type == events.TypeRemoved {
+					s.forget(e.ResourceID)
+				} else {
+					s.containerExited(ctx, e)
+				}
+			}
+		}
+	}
+}
+
+// forget сбрасывает историю падений контейнера и прекращает ожидание его перезапуска
+func (s *Supervisor) forget(containerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cs, ok := s.crashes[containerID]; ok {
+		if cs.cancel != nil {
+			cs.cancel()
+		}
+		delete(s.crashes, containerID)
+	}
+}
+
+func (s *Supervisor) containerExited(ctx context.Context, e events.Event) {
+	// Остановленный по запросу контейнер Docker не перезапускает
+	if e.Stopped {
+		s.forget(e.ResourceID)
+		return
+	}
+
+	o := newWithClient(s.docker, e.AppName, nil, s.logger, s.state, s.options())
+
+	inspect, err := o.dockerClient.ContainerInspect(ctx, e.ResourceID)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			s.forget(e.ResourceID)
+		} else {
+			s.logger.Error("не удалось инспектировать упавший контейнер", "containerID", e.ResourceID, "error", err)
+		}
+		return
+	}
+
+	if inspect.HostConfig == nil {
+		return
+	}
+	policy := inspect.HostConfig.RestartPolicy
+	if !shouldRestart(policy, e.ExitCode) {
+		s.forget(e.ResourceID)
+		return
+	}
+
+	started, _ := time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+	finished, _ := time.Parse(time.RFC3339Nano, inspect.State.FinishedAt)
+
+	s.mu.Lock()
+	cs, ok := s.crashes[e.ResourceID]
+	if !ok {
+		cs = &crashState{}
+		s.crashes[e.ResourceID] = cs
+	}
+	if finished.Sub(started) >= crashResetAfter {
+		cs.count = 0
+	}
+	cs.count++
+	attempt := cs.count
+	if cs.cancel != nil {
+		cs.cancel()
+	}
+	waitCtx, cancel := context.WithCancel(ctx)
+	cs.cancel = cancel
+	s.mu.Unlock()
+
+	// Docker считает перезапуски по политике on-failure:N сам и после N-го
+	// больше не запускает контейнер
+	if policy.Name == container.RestartPolicyOnFailure && policy.MaximumRetryCount > 0 && inspect.RestartCount >= policy.MaximumRetryCount {
+		cancel()
+		o.setNodeStatus(e.ServiceName, state.StatusCrashLoop)
+		o.publish(events.TypeCrashLoop, e.ServiceName, e.ResourceID, fmt.Sprintf("лимит перезапусков (%d) исчерпан, контейнер не будет перезапущен", policy.MaximumRetryCount))
+		s.logger.Warn("лимит перезапусков исчерпан", "appName", e.AppName, "service", e.ServiceName, "retries", policy.MaximumRetryCount)
+		return
+	}
+
+	if attempt >= crashLoopThreshold {
+		o.setNodeStatus(e.ServiceName, state.StatusCrashLoop)
+		o.publish(events.TypeCrashLoop, e.ServiceName, e.ResourceID, fmt.Sprintf("упал %d раз подряд, Docker перезапустит его", attempt))
+		s.logger.Warn("контейнер в цикле падений", "appName", e.AppName, "service", e.ServiceName, "crashes", attempt)
+	} else {
+		o.setNodeStatus(e.ServiceName, state.StatusRestarting)
+		o.publish(events.TypeRestarting, e.ServiceName, e.ResourceID, "Docker перезапустит контейнер")
+		s.logger.Info("контейнер упал и будет перезапущен", "appName", e.AppName, "service", e.ServiceName, "exitCode", e.ExitCode)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		s.awaitRestart(waitCtx, o, e, finished, attempt)
+	}()
+}
+
+// awaitRestart дожидается, пока Docker снова запустит контейнер, и проверяет
+// готовность узла. Ожидание прекращается при следующем падении, удалении
+// контейнера или остановке демона.
+func (s *Supervisor) awaitRestart(ctx context.Context, o *Orchestrator, e events.Event, finished time.Time, attempt int) {
+	ticker := time.NewTicker(restartPollInterval)
+	defer ticker.Stop()
+
+	var labels map[string]string
+	for labels == nil {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		inspect, err := o.dockerClient.ContainerInspect(ctx, e.ResourceID)
+		if err != nil {
+			if client.IsErrNotFound(err) {
+				s.forget(e.ResourceID)
+				return
+			}
+			s.logger.Debug("не удалось инспектировать контейнер в ожидании перезапуска", "containerID", e.ResourceID, "error", err)
+			continue
+		}
+		started, _ := time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+		if inspect.State.Running && started.After(finished) {
+			labels = inspect.Config.Labels
+		}
+	}
+
+	s.logger.Info("контейнер перезапущен", "appName", e.AppName, "service", e.ServiceName, "attempt", attempt)
+	o.publish(events.TypeStarting, e.ServiceName, e.ResourceID, fmt.Sprintf("перезапуск после падения, попытка %d", attempt))
+
+	timeout, _ := strconv.Atoi(labels[LabelReadinessTimeout])
+	if err := o.healthCheckPort(ctx, e.ServiceName, labels[LabelReadiness], timeout); err != nil {
+		// Проверку прервала остановка демона или новое падение, а не сам узел
+		if ctx.Err() != nil {
+			return
+		}
+		o.setNodeStatus(e.ServiceName, state.StatusFailed)
+		o.publish(events.TypeUnhealthy, e.ServiceName, e.ResourceID, err.Error())
+		return
+	}
+	o.setNodeStatus(e.ServiceName, state.StatusRunning)
+	o.publish(events.TypeReady, e.ServiceName, e.ResourceID, "")
+}
+
+// shouldRestart сообщает, перезапустит ли Docker по политике контейнер, завершившийся с кодом exitCode
+func shouldRestart(policy container.RestartPolicy, exitCode int) bool {
+	switch policy.Name {
+	case container.RestartPolicyAlways, container.RestartPolicyUnlessStopped:
+		return true
+	case container.RestartPolicyOnFailure:
+		return exitCode != 0
+	default:
+		return false
+	}
+}
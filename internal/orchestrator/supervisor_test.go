@@ -0,0 +1,162 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/waste3d/forge/internal/events"
+	"github.com/waste3d/forge/internal/state"
+)
+
+func TestShouldRestart(t *testing.T) {
+	tests := []struct {
+		restart  string
+		exitCode int
+		want     bool
+	}{
+		{"", 1, false},
+		{"no", 1, false},
+		{"on-failure", 0, false},
+		{"on-failure:3", 137, true},
+		{"always", 0, true},
+		{"unless-stopped", 2, true},
+	}
+	for _, tt := range tests {
+		policy, err := parseRestartPolicy(tt.restart)
+		if err != nil {
+			t.Fatalf("parseRestartPolicy(%q): %v", tt.restart, err)
+		}
+		if got := shouldRestart(policy, tt.exitCode); got != tt.want {
+			t.Errorf("shouldRestart(%q, %d) = %v, ожидалось %v", tt.restart, tt.exitCode, got, tt.want)
+		}
+	}
+}
+
+// containerInspect описывает контейнер, как его видит Supervisor
+func containerInspect(id, restart string, running bool, restartCount int, started, finished time.Time) types.ContainerJSON {
+	policy, _ := parseRestartPolicy(restart)
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:           id,
+			RestartCount: restartCount,
+			State: &types.ContainerState{
+				Running:    running,
+				StartedAt:  started.Format(time.RFC3339Nano),
+				FinishedAt: finished.Format(time.RFC3339Nano),
+			},
+			HostConfig: &container.HostConfig{RestartPolicy: policy},
+		},
+		Config: &container.Config{Labels: managedLabels("shop", "api")},
+	}
+}
+
+func TestSupervisor(t *testing.T) {
+	started := time.Now().Add(-time.Minute)
+	finished := time.Now()
+
+	tests := []struct {
+		name    string
+		restart string
+		// restartCount — сколько раз Docker уже перезапускал контейнер
+		restartCount int
+		stopped      bool
+		exitCode     int
+		// restarted — Docker снова запускает контейнер после падения
+		restarted  bool
+		wantEvents []string
+		wantStatus string
+	}{
+		{
+			name:       "перезапуск Docker и проверка готовности",
+			restart:    "always",
+			exitCode:   1,
+			restarted:  true,
+			wantEvents: []string{events.TypeRestarting, events.TypeStarting, events.TypeReady},
+			wantStatus: state.StatusRunning,
+		},
+		{
+			name:         "лимит on-failure исчерпан",
+			restart:      "on-failure:2",
+			restartCount: 2,
+			exitCode:     1,
+			wantEvents:   []string{events.TypeCrashLoop},
+			wantStatus:   state.StatusCrashLoop,
+		},
+		{
+			name:       "остановлен по запросу",
+			restart:    "unless-stopped",
+			stopped:    true,
+			exitCode:   137,
+			wantStatus: state.StatusRunning,
+		},
+		{
+			name:       "без политики перезапуска",
+			restart:    "no",
+			exitCode:   1,
+			wantStatus: state.StatusRunning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docker := &fakeDocker{}
+			docker.setInspect("c1", containerInspect("c1", tt.restart, false, tt.restartCount, started, finished))
+			sm := state.NewMemoryManager()
+			if err := sm.AddResource(state.Resource{ID: "c1", AppName: "shop", ServiceName: "api", ResourceType: "container", Status: state.StatusRunning}); err != nil {
+				t.Fatalf("AddResource: %v", err)
+			}
+			recorder := &eventRecorder{}
+			s := NewSupervisor(sm, docker, slog.New(slog.NewTextHandler(io.Discard, nil)), func() Options { return Options{Events: recorder} })
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				s.Run(ctx)
+				close(done)
+			}()
+
+			s.ContainerExited(events.Event{Type: events.TypeExited, AppName: "shop", ServiceName: "api", ResourceID: "c1", ExitCode: tt.exitCode, Stopped: tt.stopped, Source: events.SourceDocker})
+			if tt.restarted {
+				docker.setInspect("c1", containerInspect("c1", tt.restart, true, tt.restartCount+1, finished.Add(time.Second), finished))
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for len(recorder.types()) < len(tt.wantEvents) && time.Now().Before(deadline) {
+				time.Sleep(50 * time.Millisecond)
+			}
+			cancel()
+			<-done
+
+			if got := recorder.types(); !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Errorf("события %v, ожидалось %v", got, tt.wantEvents)
+			}
+			resources, _ := sm.GetResourceByApp("shop")
+			if resources[0].Status != tt.wantStatus {
+				t.Errorf("статус %q, ожидалось %q", resources[0].Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSupervisorNeverDrops(t *testing.T) {
+	s := NewSupervisor(state.NewMemoryManager(), &fakeDocker{}, slog.New(slog.NewTextHandler(io.Discard, nil)), func() Options { return Options{} })
+
+	// Run не запущен: события копятся в очереди, а не отбрасываются, как в шине событий
+	const exits = 1000
+	for i := 0; i < exits; i++ {
+		s.ContainerExited(events.Event{Type: events.TypeExited, ResourceID: fmt.Sprintf("c%d", i)})
+	}
+	s.ContainerRemoved("c0")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) != exits+1 {
+		t.Errorf("в очереди %d событий, ожидалось %d", len(s.pending), exits+1)
+	}
+}
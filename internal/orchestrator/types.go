@@ -2,6 +2,8 @@ package orchestrator
 
 import (
 	"context"
+	"net"
+	"strconv"
 
 	"github.com/waste3d/forge/pkg/parser"
 )
@@ -9,13 +11,14 @@ import (
 type Node interface {
 	GetName() string
 	GetDependencies() []string
+	GetPorts() []parser.PortMapping
 	Start(ctx context.Context, networkID string, orchestrator *Orchestrator) error
 	IsReady(ctx context.Context, orchestrator *Orchestrator) error
 }
 
 type ServiceNode struct {
 	*parser.ServiceConfig
-	port int
+	ports []parser.PortMapping
 }
 
 func (s *ServiceNode) GetName() string {
@@ -26,17 +29,21 @@ func (s *ServiceNode) GetDependencies() []string {
 	return s.DependsOn
 }
 
+func (s *ServiceNode) GetPorts() []parser.PortMapping {
+	return s.ports
+}
+
 func (s *ServiceNode) Start(ctx context.Context, networkID string, orchestrator *Orchestrator) error {
-	return orchestrator.startService(ctx, s.ServiceConfig, networkID)
+	return orchestrator.startService(ctx, s.ServiceConfig, s.ports, networkID)
 }
 
 func (s *ServiceNode) IsReady(ctx context.Context, orchestrator *Orchestrator) error {
-	return orchestrator.healthCheckPort(ctx, s.Name, s.port, s.HealthCheckTimeout)
+	return orchestrator.healthCheckPort(ctx, s.Name, healthCheckAddress(s.ports), s.HealthCheckTimeout)
 }
 
 type DBNode struct {
 	*parser.DBConfig
-	port int
+	ports []parser.PortMapping
 }
 
 func (d *DBNode) GetName() string {
@@ -50,10 +57,30 @@ func (d *DBNode) GetDependencies() []string {
 	return d.DependsOn
 }
 
+func (d *DBNode) GetPorts() []parser.PortMapping {
+	return d.ports
+}
+
 func (d *DBNode) Start(ctx context.Context, networkID string, orchestrator *Orchestrator) error {
-	return orchestrator.startDatabase(ctx, d.DBConfig, networkID)
+	return orchestrator.startDatabase(ctx, d.DBConfig, d.ports, networkID)
 }
 
 func (d *DBNode) IsReady(ctx context.Context, orchestrator *Orchestrator) error {
-	return orchestrator.healthCheckPort(ctx, d.Name, d.port, d.HealthCheckTimeout)
+	return orchestrator.healthCheckPort(ctx, d.Name, healthCheckAddress(d.ports), d.HealthCheckTimeout)
+}
+
+// healthCheckAddress возвращает адрес на хосте, по которому проверяется готовность узла:
+// первый опубликованный TCP-порт. Если таких нет, возвращается пустая строка.
+func healthCheckAddress(mappings []parser.PortMapping) string {
+	for _, m := range mappings {
+		if m.Protocol != "tcp" || !m.Published() {
+			continue
+		}
+		host := m.HostIP
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			host = "localhost"
+		}
+		return net.JoinHostPort(host, strconv.Itoa(m.HostPort))
+	}
+	return ""
 }
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package pidfile
+
+import "os"
+
+// На платформах без flock PID-файл только записывается: защиту от второго
+// экземпляра демона в этом случае обеспечивает занятый адрес gRPC.
+func lock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,90 @@
+// Package pidfile управляет PID-файлом демона. Файл удерживается под
+// эксклюзивной блокировкой все время работы процесса, поэтому второй
+// экземпляр демона не запустится, а оставшийся после сбоя файл не мешает
+// следующему запуску: блокировка снимается вместе с процессом.
+package pidfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked возвращается, если PID-файл удерживает другой работающий процесс
+var ErrLocked = errors.New("PID-файл занят другим процессом")
+
+// PIDFile — захваченный PID-файл
+type PIDFile struct {
+	path string
+	file *os.File
+}
+
+// DefaultPath возвращает путь к PID-файлу по умолчанию: ~/.forge/forged.pid
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("не удалось определить домашнюю директорию: %w", err)
+	}
+	return filepath.Join(home, ".forge", "forged.pid"), nil
+}
+
+// Acquire блокирует PID-файл и записывает в него PID текущего процесса
+func Acquire(path string) (*PIDFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию для PID-файла: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть PID-файл %s: %w", path, err)
+	}
+
+	if err := lock(f); err != nil {
+		f.Close()
+		if errors.Is(err, ErrLocked) {
+			if pid, readErr := Read(path); readErr == nil {
+				return nil, fmt.Errorf("демон уже запущен (PID %d, %s): %w", pid, path, err)
+			}
+		}
+		return nil, fmt.Errorf("не удалось заблокировать PID-файл %s: %w", path, err)
+	}
+
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("не удалось очистить PID-файл: %w", err)
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("не удалось записать PID-файл: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("не удалось записать PID-файл: %w", err)
+	}
+
+	return &PIDFile{path: path, file: f}, nil
+}
+
+// Release удаляет PID-файл и снимает блокировку
+func (p *PIDFile) Release() {
+	// Файл удаляется до снятия блокировки, чтобы новый процесс не успел
+	// захватить его и потерять свою запись
+	os.Remove(p.path)
+	p.file.Close()
+}
+
+// Read возвращает PID, записанный в файле
+func Read(path string) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, fmt.Errorf("некорректное содержимое PID-файла %s: %w", path, err)
+	}
+	return pid, nil
+}
@@ -0,0 +1,39 @@
+package pidfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestAcquire(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("блокировка PID-файла поддерживается только на unix")
+	}
+	path := filepath.Join(t.TempDir(), "forged.pid")
+
+	p, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("не удалось захватить PID-файл: %v", err)
+	}
+	if pid, err := Read(path); err != nil || pid != os.Getpid() {
+		t.Errorf("в PID-файле %d (%v), ожидался %d", pid, err, os.Getpid())
+	}
+
+	if _, err := Acquire(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("повторный захват: ожидалась ErrLocked, получено %v", err)
+	}
+
+	p.Release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("после Release PID-файл не удален: %v", err)
+	}
+
+	p, err = Acquire(path)
+	if err != nil {
+		t.Fatalf("после Release PID-файл не захватывается: %v", err)
+	}
+	p.Release()
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/waste3d/forge/internal/config"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/version"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DaemonInfo возвращает сведения о работающем демоне
+func (s *forgeServer) DaemonInfo(ctx context.Context, req *pb.DaemonInfoRequest) (*pb.DaemonInfoResponse, error) {
+	resources, err := s.state.GetAllResources()
+	if err != nil {
+		s.logger.Error("ошибка чтения состояния", "error", err)
+		return nil, status.Errorf(codes.Internal, "ошибка чтения состояния: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	apps := []string{}
+	for _, r := range resources {
+		if !seen[r.AppName] {
+			seen[r.AppName] = true
+			apps = append(apps, r.AppName)
+		}
+	}
+	sort.Strings(apps)
+
+	cfg := s.config.Load()
+	return &pb.DaemonInfoResponse{
+		Version:       version.Version,
+		Pid:           int32(os.Getpid()),
+		StartedAt:     s.startedAt.Unix(),
+		UptimeSeconds: int64(time.Since(s.startedAt).Seconds()),
+		ListenAddress: cfg.Listen.Address,
+		Runtime:       s.runtimeInfo(ctx),
+		StateBackend:  cfg.State.Backend,
+		StatePath:     cfg.State.Path,
+		ActiveApps:    apps,
+		Operations:    s.operations(),
+	}, nil
+}
+
+// Version возвращает версию демона и возможности его API
+func (s *forgeServer) Version(ctx context.Context, req *pb.VersionRequest) (*pb.VersionResponse, error) {
+	if req.GetClientVersion() != "" && req.GetClientVersion() != version.Version {
+		s.logger.Debug("версия клиента отличается от версии демона", "clientVersion", req.GetClientVersion(), "clientAPIVersion", req.GetClientApiVersion())
+	}
+
+	return &pb.VersionResponse{
+		Version:    version.Version,
+		ApiVersion: version.APIVersion,
+		Features:   version.Features,
+		GoVersion:  runtime.Version(),
+		Platform:   runtime.GOOS + "/" + runtime.GOARCH,
+	}, nil
+}
+
+// Shutdown запускает корректную остановку демона: новые запросы больше не
+// принимаются, а ответ приходит, когда завершатся текущие операции над приложениями
+func (s *forgeServer) Shutdown(ctx context.Context, req *pb.ShutdownRequest) (*pb.ShutdownResponse, error) {
+	operations := s.operations()
+	s.logger.Info("получен Shutdown-запрос", "operations", len(operations))
+	s.shutdown()
+
+	ctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+	if err := s.locks.wait(ctx); err != nil {
+		return nil, status.Errorf(codes.DeadlineExceeded, "операции не завершились до остановки демона: %v", err)
+	}
+	return &pb.ShutdownResponse{Operations: operations}, nil
+}
+
+// runtimeInfo описывает контейнерный runtime, с которым работает демон
+func (s *forgeServer) runtimeInfo(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	v, err := s.docker.ServerVersion(ctx)
+	if err != nil {
+		return fmt.Sprintf("docker (недоступен: %v)", err)
+	}
+	return fmt.Sprintf("docker %s (%s/%s)", v.Version, v.Os, v.Arch)
+}
+
+func (s *forgeServer) operations() []*pb.DaemonOperation {
+	snapshot := s.locks.snapshot()
+	operations := make([]*pb.DaemonOperation, 0, len(snapshot))
+	for app, op := range snapshot {
+		operations = append(operations, &pb.DaemonOperation{AppName: app, Operation: op})
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].GetAppName() < operations[j].GetAppName() })
+	return operations
+}
+
+// GetDaemonConfig возвращает действующие настройки демона
+func (s *forgeServer) GetDaemonConfig(ctx context.Context, req *pb.DaemonConfigRequest) (*pb.DaemonConfigResponse, error) {
+	cfg := s.config.Load()
+	content, err := cfg.Marshal()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return &pb.DaemonConfigResponse{Path: cfg.Path(), Loaded: cfg.Loaded(), Content: content}, nil
+}
+
+// reloadConfig повторно читает настройки и применяет те, что не требуют
+// перезапуска. При ошибке продолжают действовать прежние настройки.
+func (s *forgeServer) reloadConfig(load func() (*config.Config, error)) {
+	next, err := load()
+	if err != nil {
+		s.logger.Error("настройки демона не перезагружены", "error", err)
+		return
+	}
+
+	current := s.config.Load()
+	if changed := current.StructuralChanges(next); len(changed) > 0 {
+		s.logger.Warn("изменения этих настроек вступят в силу только после перезапуска демона", "settings", changed)
+	}
+
+	// Уровень уже проверен в Validate
+	level, _ := next.Log.SlogLevel()
+	s.logLevel.Set(level)
+	s.config.Store(current.WithReloadable(next))
+	s.logger.Info("настройки демона перезагружены", "path", next.Path(), "logLevel", next.Log.Level)
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/waste3d/forge/internal/config"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/state"
+	"github.com/waste3d/forge/version"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeDocker отвечает только на ServerVersion: остальные методы демону в этих тестах не нужны
+type fakeDocker struct {
+	client.APIClient
+	err error
+}
+
+func (f fakeDocker) ServerVersion(ctx context.Context) (types.Version, error) {
+	return types.Version{Version: "27.0.0", Os: "linux", Arch: "amd64"}, f.err
+}
+
+func newTestServer(t *testing.T, docker client.APIClient) *forgeServer {
+	t.Helper()
+	stopping, shutdown := context.WithCancel(context.Background())
+	t.Cleanup(shutdown)
+
+	s := &forgeServer{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		state:     state.NewMemoryManager(),
+		locks:     newAppLocks(),
+		docker:    docker,
+		startedAt: time.Now().Add(-time.Minute),
+		stopping:  stopping,
+		shutdown:  shutdown,
+	}
+	s.config.Store(config.Default())
+	return s
+}
+
+func TestDaemonInfo(t *testing.T) {
+	s := newTestServer(t, fakeDocker{})
+	for _, r := range []state.Resource{
+		{ID: "1", AppName: "shop", ServiceName: "api"},
+		{ID: "2", AppName: "blog", ServiceName: "web"},
+		{ID: "3", AppName: "shop", ServiceName: "db"},
+	} {
+		if err := s.state.AddResource(r); err != nil {
+			t.Fatalf("AddResource: %v", err)
+		}
+	}
+	release, err := s.locks.acquire("shop", "up")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer release()
+
+	info, err := s.DaemonInfo(context.Background(), &pb.DaemonInfoRequest{})
+	if err != nil {
+		t.Fatalf("DaemonInfo: %v", err)
+	}
+	if info.GetVersion() != version.Version || info.GetPid() != int32(os.Getpid()) {
+		t.Errorf("версия %q, PID %d", info.GetVersion(), info.GetPid())
+	}
+	if info.GetUptimeSeconds() < 60 {
+		t.Errorf("UptimeSeconds = %d, ожидалось не меньше 60", info.GetUptimeSeconds())
+	}
+	if got, want := info.GetActiveApps(), []string{"blog", "shop"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ActiveApps = %v, ожидалось %v", got, want)
+	}
+	if ops := info.GetOperations(); len(ops) != 1 || ops[0].GetAppName() != "shop" || ops[0].GetOperation() != "up" {
+		t.Errorf("Operations = %v", ops)
+	}
+	if got := info.GetRuntime(); got != "docker 27.0.0 (linux/amd64)" {
+		t.Errorf("Runtime = %q", got)
+	}
+}
+
+func TestDaemonInfoDockerUnavailable(t *testing.T) {
+	s := newTestServer(t, fakeDocker{err: errors.New("connection refused")})
+
+	info, err := s.DaemonInfo(context.Background(), &pb.DaemonInfoRequest{})
+	if err != nil {
+		t.Fatalf("DaemonInfo должен отвечать и без Docker: %v", err)
+	}
+	if !strings.Contains(info.GetRuntime(), "недоступен") {
+		t.Errorf("Runtime = %q, ожидалось сообщение о недоступности Docker", info.GetRuntime())
+	}
+}
+
+func TestShutdownWaitsForOperations(t *testing.T) {
+	s := newTestServer(t, fakeDocker{})
+	release, err := s.locks.acquire("shop", "up")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	done := make(chan *pb.ShutdownResponse, 1)
+	go func() {
+		resp, err := s.Shutdown(context.Background(), &pb.ShutdownRequest{})
+		if err != nil {
+			t.Errorf("Shutdown: %v", err)
+		}
+		done <- resp
+	}()
+
+	select {
+	case <-s.stopping.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown не начал остановку демона")
+	}
+	select {
+	case <-done:
+		t.Fatal("Shutdown ответил, не дождавшись выполняемой операции")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case resp := <-done:
+		if ops := resp.GetOperations(); len(ops) != 1 || ops[0].GetAppName() != "shop" {
+			t.Errorf("Operations = %v, ожидалась операция над shop", ops)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown не ответил после завершения операции")
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	s := newTestServer(t, fakeDocker{})
+	release, err := s.locks.acquire("shop", "up")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := s.Shutdown(ctx, &pb.ShutdownRequest{}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("ожидалась ошибка DeadlineExceeded, получено %v", err)
+	}
+}
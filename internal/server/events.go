@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	dockerevents "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/waste3d/forge/internal/events"
+	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/orchestrator"
+)
+
+// dockerEventsRetry — пауза перед повторной подпиской на события Docker после ошибки
+const dockerEventsRetry = 5 * time.Second
+
+func (s *forgeServer) Events(req *pb.EventsRequest, stream pb.Forge_EventsServer) error {
+	ch, unsubscribe := s.events.Subscribe(events.Filter{
+		AppName:     req.GetAppName(),
+		ServiceName: req.GetServiceName(),
+	})
+	defer unsubscribe()
+	s.logger.Info("клиент подписался на события", "appName", req.GetAppName(), "serviceName", req.GetServiceName())
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		// Подписка бессрочная, поэтому при остановке демона ее нужно прервать
+		case <-s.stopping.Done():
+			return nil
+		case e, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toEvent(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toEvent(e events.Event) *pb.Event {
+	return &pb.Event{
+		Type:        e.Type,
+		AppName:     e.AppName,
+		ServiceName: e.ServiceName,
+		ResourceId:  e.ResourceID,
+		ExitCode:    int32(e.ExitCode),
+		Message:     e.Message,
+		Source:      e.Source,
+		Time:        e.Time.UnixNano(),
+	}
+}
+
+// watchDocker публикует события контейнеров Forge, о которых оркестратор не знает:
+// завершение процесса и результаты healthcheck Docker, сообщает о запусках
+// контейнеров сборщику логов, а о завершениях и удалениях — супервизору. После
+// ошибки подписка возобновляется с момента последнего полученного события.
+func (s *forgeServer) watchDocker(ctx context.Context) {
+	args := filters.NewArgs(
+		filters.Arg("type", string(dockerevents.ContainerEventType)),
+		filters.Arg("label", orchestrator.LabelManaged+"=true"),
+	)
+	since := time.Now()
+	flags := make(containerFlags)
+	// Пока Docker недоступен, повторные ошибки не засоряют журнал
+	failing := false
+
+	for {
+		msgs, errs := s.docker.Events(ctx, dockerevents.ListOptions{
+			Filters: args,
+			Since:   fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond()),
+		})
+
+	receive:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-errs:
+				if !failing {
+					s.logger.Warn("поток событий Docker прерван, повторная подписка", "error", err, "retry", dockerEventsRetry)
+				}
+				failing = true
+				break receive
+			case msg := <-msgs:
+				failing = false
+				since = time.Unix(0, msg.TimeNano+1)
+				if msg.Action == dockerevents.ActionStart && s.collector != nil {
+					s.collector.ContainerStarted(msg.Actor.ID)
+				}
+				if msg.Action == dockerevents.ActionDestroy && s.supervisor != nil {
+					s.supervisor.ContainerRemoved(msg.Actor.ID)
+				}
+				if e, ok := dockerEvent(msg, flags); ok {
+					if e.Type == events.TypeExited && s.supervisor != nil {
+						s.supervisor.ContainerExited(e)
+					}
+					s.events.Publish(e)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(dockerEventsRetry):
+		}
+	}
+}
+
+// containerFlags запоминает, что предшествовало завершению контейнера: Docker
+// сообщает о нехватке памяти (oom) и остановке по запросу (kill) до события die
+type containerFlags map[string]struct{ oomKilled, stopped bool }
+
+// stopSignals — сигналы, которыми останавливают контейнер. Остальные, например
+// SIGHUP для перечитывания настроек nginx, Docker тоже сообщает событием kill,
+// но контейнер после них продолжает работать.
+var stopSignals = map[string]bool{
+	"2": true, "3": true, "9": true, "15": true,
+	"SIGINT": true, "SIGQUIT": true, "SIGKILL": true, "SIGTERM": true,
+}
+
+// isStopSignal сообщает, остановлен ли контейнер сигналом события kill. Старые
+// версии Docker не передают сигнал: такое событие считается остановкой.
+func isStopSignal(signal string) bool {
+	return signal == "" || stopSignals[strings.ToUpper(signal)]
+}
+
+// dockerEvent переводит событие Docker в событие Forge
+func dockerEvent(msg dockerevents.Message, flags containerFlags) (events.Event, bool) {
+	e := events.Event{
+		AppName:     msg.Actor.Attributes[orchestrator.LabelApp],
+		ServiceName: msg.Actor.Attributes[orchestrator.LabelService],
+		ResourceID:  msg.Actor.ID,
+		Source:      events.SourceDocker,
+		Time:        time.Unix(0, msg.TimeNano),
+	}
+
+	switch msg.Action {
+	case dockerevents.ActionOOM:
+		f := flags[msg.Actor.ID]
+		f.oomKilled = true
+		flags[msg.Actor.ID] = f
+		return e, false
+	case dockerevents.ActionKill:
+		if !isStopSignal(msg.Actor.Attributes["signal"]) {
+			return e, false
+		}
+		f := flags[msg.Actor.ID]
+		f.stopped = true
+		flags[msg.Actor.ID] = f
+		return e, false
+	case dockerevents.ActionDie:
+		f := flags[msg.Actor.ID]
+		delete(flags, msg.Actor.ID)
+		e.Type = events.TypeExited
+		e.ExitCode, _ = strconv.Atoi(msg.Actor.Attributes["exitCode"])
+		switch {
+		case f.oomKilled:
+			e.Message = "контейнер остановлен из-за нехватки памяти (OOM)"
+		case f.stopped:
+			e.Stopped = true
+			e.Message = "контейнер остановлен по запросу"
+		}
+	case dockerevents.ActionHealthStatusUnhealthy:
+		e.Type = events.TypeUnhealthy
+		e.Message = "healthcheck Docker не пройден"
+	case dockerevents.ActionHealthStatusHealthy:
+		e.Type = events.TypeReady
+		e.Message = "healthcheck Docker пройден"
+	case dockerevents.ActionDestroy:
+		delete(flags, msg.Actor.ID)
+		return e, false
+	default:
+		return e, false
+	}
+	return e, true
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"testing"
+
+	dockerevents "github.com/docker/docker/api/types/events"
+	"github.com/waste3d/forge/internal/events"
+	"github.com/waste3d/forge/internal/orchestrator"
+)
+
+func dockerMessage(action dockerevents.Action, id string, attributes map[string]string) dockerevents.Message {
+	attrs := map[string]string{orchestrator.LabelApp: "shop", orchestrator.LabelService: "api"}
+	for k, v := range attributes {
+		attrs[k] = v
+	}
+	return dockerevents.Message{Action: action, Actor: dockerevents.Actor{ID: id, Attributes: attrs}}
+}
+
+func TestDockerEventExit(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      []dockerevents.Action
+		signal      string
+		exitCode    string
+		wantCode    int
+		wantStopped bool
+		wantMessage string
+	}{
+		{
+			name:     "падение",
+			exitCode: "1",
+			wantCode: 1,
+		},
+		{
+			name:        "остановка по запросу",
+			before:      []dockerevents.Action{dockerevents.ActionKill},
+			exitCode:    "137",
+			wantCode:    137,
+			wantStopped: true,
+			wantMessage: "контейнер остановлен по запросу",
+		},
+		{
+			name:        "остановка по SIGTERM",
+			before:      []dockerevents.Action{dockerevents.ActionKill},
+			signal:      "15",
+			exitCode:    "143",
+			wantCode:    143,
+			wantStopped: true,
+			wantMessage: "контейнер остановлен по запросу",
+		},
+		{
+			// docker kill -s HUP перечитывает настройки, а не останавливает контейнер:
+			// следующее завершение — падение
+			name:     "падение после SIGHUP",
+			before:   []dockerevents.Action{dockerevents.ActionKill},
+			signal:   "1",
+			exitCode: "1",
+			wantCode: 1,
+		},
+		{
+			name:        "нехватка памяти",
+			before:      []dockerevents.Action{dockerevents.ActionOOM, dockerevents.ActionKill},
+			exitCode:    "137",
+			wantCode:    137,
+			wantMessage: "контейнер остановлен из-за нехватки памяти (OOM)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := make(containerFlags)
+			for _, action := range tt.before {
+				attrs := map[string]string{}
+				if action == dockerevents.ActionKill && tt.signal != "" {
+					attrs["signal"] = tt.signal
+				}
+				if _, ok := dockerEvent(dockerMessage(action, "c1", attrs), flags); ok {
+					t.Fatalf("событие %s не должно публиковаться", action)
+				}
+			}
+
+			e, ok := dockerEvent(dockerMessage(dockerevents.ActionDie, "c1", map[string]string{"exitCode": tt.exitCode}), flags)
+			if !ok {
+				t.Fatal("событие die не опубликовано")
+			}
+			if e.Type != events.TypeExited || e.ExitCode != tt.wantCode || e.Stopped != tt.wantStopped || e.Message != tt.wantMessage {
+				t.Errorf("получено %+v", e)
+			}
+			if e.AppName != "shop" || e.ServiceName != "api" || e.ResourceID != "c1" || e.Source != events.SourceDocker {
+				t.Errorf("неверные поля события: %+v", e)
+			}
+			if _, ok := flags["c1"]; ok {
+				t.Error("флаги контейнера не сброшены после die")
+			}
+		})
+	}
+}
+
+func TestDockerEventFlagsPerContainer(t *testing.T) {
+	flags := make(containerFlags)
+	dockerEvent(dockerMessage(dockerevents.ActionKill, "c1", nil), flags)
+
+	// Остановка одного контейнера не влияет на падение другого
+	e, _ := dockerEvent(dockerMessage(dockerevents.ActionDie, "c2", map[string]string{"exitCode": "1"}), flags)
+	if e.Stopped {
+		t.Errorf("c2 отмечен остановленным по запросу: %+v", e)
+	}
+
+	// Удаленный контейнер не оставляет флагов
+	dockerEvent(dockerMessage(dockerevents.ActionDestroy, "c1", nil), flags)
+	if len(flags) != 0 {
+		t.Errorf("флаги после destroy: %v", flags)
+	}
+}
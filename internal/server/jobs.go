@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/jobs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// jobStream — поток gRPC, в который передается вывод задания
+type jobStream interface {
+	Send(*pb.LogEntry) error
+	Context() context.Context
+}
+
+// streamJob сообщает клиенту идентификатор задания и передает его вывод до
+// завершения. Если клиент отключится, задание продолжит выполняться.
+func (s *forgeServer) streamJob(job *jobs.Job, detach bool, stream jobStream) error {
+	info := job.Info()
+	if err := stream.Send(&pb.LogEntry{
+		ServiceName: "forged-daemon",
+		Timestamp:   time.Now().Unix(),
+		Message:     fmt.Sprintf("Задание %s (%s %s).", info.ID, info.Kind, info.AppName),
+		JobId:       info.ID,
+	}); err != nil {
+		return err
+	}
+	if detach {
+		return nil
+	}
+
+	if err := job.Follow(stream.Context(), stream.Send); err != nil {
+		s.logger.Info("клиент отключился от задания, задание продолжает выполняться", "jobID", info.ID, "error", err)
+		return err
+	}
+	return job.Err()
+}
+
+func (s *forgeServer) ListJobs(ctx context.Context, req *pb.ListJobsRequest) (*pb.ListJobsResponse, error) {
+	resp := &pb.ListJobsResponse{}
+	for _, info := range s.jobs.List() {
+		if req.GetAppName() != "" && info.AppName != req.GetAppName() {
+			continue
+		}
+		resp.Jobs = append(resp.Jobs, toJobInfo(info))
+	}
+	return resp, nil
+}
+
+func (s *forgeServer) AttachJob(req *pb.AttachJobRequest, stream pb.Forge_AttachJobServer) error {
+	job, err := s.findJob(req.GetJobId())
+	if err != nil {
+		return err
+	}
+	s.logger.Info("клиент подключился к заданию", "jobID", job.ID())
+	return s.streamJob(job, false, stream)
+}
+
+func (s *forgeServer) CancelJob(ctx context.Context, req *pb.CancelJobRequest) (*pb.CancelJobResponse, error) {
+	job, err := s.findJob(req.GetJobId())
+	if err != nil {
+		return nil, err
+	}
+
+	if !job.Cancel() {
+		info := job.Info()
+		return nil, status.Errorf(codes.FailedPrecondition, "задание %s уже завершено со статусом '%s'", info.ID, info.Status)
+	}
+	s.logger.Info("задание отменено клиентом", "jobID", job.ID())
+	return &pb.CancelJobResponse{Job: toJobInfo(job.Info())}, nil
+}
+
+func (s *forgeServer) findJob(id string) (*jobs.Job, error) {
+	if id == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "в запросе не указан идентификатор задания")
+	}
+	job, err := s.jobs.Get(id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return job, nil
+}
+
+func toJobInfo(info jobs.Info) *pb.JobInfo {
+	job := &pb.JobInfo{
+		Id:        info.ID,
+		Kind:      info.Kind,
+		AppName:   info.AppName,
+		Status:    info.Status,
+		CreatedAt: info.CreatedAt.Unix(),
+		RunId:     info.RunID,
+	}
+	if info.Err != nil {
+		job.Error = status.Convert(info.Err).Message()
+	}
+	if !info.FinishedAt.IsZero() {
+		job.FinishedAt = info.FinishedAt.Unix()
+	}
+	return job
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// appLocks не дает двум изменяющим операциям (Up, Down, Build) одновременно
+// работать с одним приложением. Вторая операция сразу получает ошибку, а не
+// ждет в очереди: клиент видит, что именно сейчас выполняется.
+type appLocks struct {
+	mu         sync.Mutex
+	operations map[string]string // имя приложения -> выполняемая операция
+	// exclusive — операция над всеми приложениями сразу, например сверка
+	// состояния. Пока она выполняется, остальные операции не начинаются.
+	exclusive string
+}
+
+func newAppLocks() *appLocks {
+	return &appLocks{operations: make(map[string]string)}
+}
+
+// acquire занимает приложение под операцию op. Возвращает функцию освобождения
+// или ошибку codes.Aborted, если над приложением уже выполняется другая операция.
+func (l *appLocks) acquire(appName, op string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.exclusive != "" {
+		return nil, status.Errorf(codes.Aborted, "выполняется операция '%s' над всеми приложениями. Дождитесь ее завершения и повторите попытку", l.exclusive)
+	}
+	if current, busy := l.operations[appName]; busy {
+		return nil, status.Errorf(codes.Aborted, "над приложением '%s' уже выполняется операция '%s'. Дождитесь ее завершения и повторите попытку", appName, current)
+	}
+	l.operations[appName] = op
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.operations, appName)
+	}, nil
+}
+
+// acquireAll занимает все приложения под операцию op. Возвращает функцию
+// освобождения или ошибку codes.Aborted, если выполняется любая другая операция.
+func (l *appLocks) acquireAll(op string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.exclusive != "" {
+		return nil, status.Errorf(codes.Aborted, "уже выполняется операция '%s' над всеми приложениями. Дождитесь ее завершения и повторите попытку", l.exclusive)
+	}
+	if len(l.operations) > 0 {
+		return nil, status.Errorf(codes.Aborted, "операция '%s' невозможна, пока выполняются операции над приложениями: %s", op, strings.Join(l.appsLocked(), ", "))
+	}
+	l.exclusive = op
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.exclusive = ""
+	}, nil
+}
+
+// busy возвращает имена приложений, над которыми сейчас выполняются операции
+func (l *appLocks) busy() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.appsLocked()
+}
+
+func (l *appLocks) appsLocked() []string {
+	apps := make([]string, 0, len(l.operations))
+	for app := range l.operations {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+// idle сообщает, что не выполняется ни одной операции
+func (l *appLocks) idle() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.operations) == 0 && l.exclusive == ""
+}
+
+// snapshot возвращает копию выполняемых операций: имя приложения -> операция
+func (l *appLocks) snapshot() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	operations := make(map[string]string, len(l.operations))
+	for app, op := range l.operations {
+		operations[app] = op
+	}
+	return operations
+}
+
+// wait ждет, пока не останется выполняемых операций
+func (l *appLocks) wait(ctx context.Context) error {
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !l.idle() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAppLocksAcquire(t *testing.T) {
+	l := newAppLocks()
+
+	releaseShop, err := l.acquire("shop", "up")
+	if err != nil {
+		t.Fatalf("acquire(shop): %v", err)
+	}
+	if _, err := l.acquire("shop", "down"); status.Code(err) != codes.Aborted {
+		t.Fatalf("повторный acquire(shop): ожидалась ошибка Aborted, получено %v", err)
+	}
+	releaseBlog, err := l.acquire("blog", "build")
+	if err != nil {
+		t.Fatalf("acquire(blog): %v", err)
+	}
+
+	if got, want := l.busy(), []string{"blog", "shop"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("busy() = %v, ожидалось %v", got, want)
+	}
+	if got := l.snapshot(); got["shop"] != "up" || got["blog"] != "build" {
+		t.Errorf("snapshot() = %v", got)
+	}
+
+	releaseShop()
+	releaseBlog()
+	if got := l.busy(); len(got) != 0 {
+		t.Errorf("после освобождения busy() = %v", got)
+	}
+	if release, err := l.acquire("shop", "down"); err != nil {
+		t.Errorf("acquire после освобождения: %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestAppLocksAcquireAll(t *testing.T) {
+	l := newAppLocks()
+
+	release, err := l.acquire("shop", "up")
+	if err != nil {
+		t.Fatalf("acquire(shop): %v", err)
+	}
+	if _, err := l.acquireAll("reconcile"); status.Code(err) != codes.Aborted {
+		t.Fatalf("acquireAll во время Up: ожидалась ошибка Aborted, получено %v", err)
+	}
+	release()
+
+	releaseAll, err := l.acquireAll("reconcile")
+	if err != nil {
+		t.Fatalf("acquireAll: %v", err)
+	}
+	if _, err := l.acquire("blog", "up"); status.Code(err) != codes.Aborted {
+		t.Errorf("acquire во время сверки: ожидалась ошибка Aborted, получено %v", err)
+	}
+	if _, err := l.acquireAll("reconcile"); status.Code(err) != codes.Aborted {
+		t.Errorf("повторный acquireAll: ожидалась ошибка Aborted, получено %v", err)
+	}
+	releaseAll()
+
+	if release, err := l.acquire("blog", "up"); err != nil {
+		t.Errorf("acquire после сверки: %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestAppLocksWait(t *testing.T) {
+	l := newAppLocks()
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatalf("wait без операций: %v", err)
+	}
+
+	release, err := l.acquire("shop", "up")
+	if err != nil {
+		t.Fatalf("acquire(shop): %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("wait с занятым приложением: ожидалось %v, получено %v", context.DeadlineExceeded, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- l.wait(context.Background()) }()
+	release()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("wait после освобождения: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait не завершился после освобождения приложения")
+	}
+
+	releaseAll, err := l.acquireAll("reconcile")
+	if err != nil {
+		t.Fatalf("acquireAll: %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("wait во время сверки: ожидалось %v, получено %v", context.DeadlineExceeded, err)
+	}
+	releaseAll()
+}
@@ -2,17 +2,29 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
-	"net"
 	"os"
+	"os/signal"
+	"regexp"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	timetypes "github.com/docker/docker/api/types/time"
 	"github.com/docker/docker/client"
+	"github.com/google/uuid"
+	"github.com/waste3d/forge/internal/config"
+	"github.com/waste3d/forge/internal/events"
 	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/jobs"
+	"github.com/waste3d/forge/internal/logstore"
 	"github.com/waste3d/forge/internal/orchestrator"
 	"github.com/waste3d/forge/internal/state"
+	"github.com/waste3d/forge/internal/transport"
 	"github.com/waste3d/forge/pkg/parser"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
@@ -23,8 +35,37 @@ import (
 type forgeServer struct {
 	pb.UnimplementedForgeServer
 	logger *slog.Logger
+	// logLevel меняется при перезагрузке настроек по SIGHUP
+	logLevel *slog.LevelVar
+	// config — текущие настройки демона, заменяются целиком при перезагрузке
+	config atomic.Pointer[config.Config]
+	// state — общее для всех запросов хранилище состояния, открытое при старте демона
+	state state.Manager
+	locks *appLocks
+	// jobs — выполняемые и недавно завершенные задания up и build
+	jobs *jobs.Manager
+	// events рассылает события жизненного цикла узлов подписчикам 'forge events'
+	events *events.Bus
+	// logStore и collector сохраняют логи контейнеров на диск. nil, если сохранение отключено.
+	logStore  *logstore.Store
+	collector *orchestrator.LogCollector
+	// supervisor отслеживает перезапуски упавших контейнеров
+	supervisor *orchestrator.Supervisor
+
+	docker    client.APIClient
+	startedAt time.Time
+	// stopping отменяется, когда демон начинает остановку: по сигналу или через Shutdown
+	stopping context.Context
+	shutdown context.CancelFunc
 }
 
+// shutdownTimeout — сколько демон ждет завершения текущих операций при остановке,
+// прежде чем отменить их
+const shutdownTimeout = 2 * time.Minute
+
+// jobCleanupTimeout — сколько демон ждет удаления ресурсов заданий, отмененных при остановке
+const jobCleanupTimeout = time.Minute
+
 func (s *forgeServer) Up(req *pb.UpRequest, stream pb.Forge_UpServer) error {
 	s.logger.Info("получен Up-запрос")
 
@@ -48,20 +89,25 @@ func (s *forgeServer) Up(req *pb.UpRequest, stream pb.Forge_UpServer) error {
 
 	appName := config.AppName
 
-	sm, err := state.NewManager()
+	if s.stopping.Err() != nil {
+		return status.Errorf(codes.Unavailable, "демон останавливается и не принимает новые операции")
+	}
+
+	release, err := s.locks.acquire(appName, "up")
 	if err != nil {
-		s.logger.Error("критическая ошибка инициализации state manager", "error", err)
-		return status.Errorf(codes.Internal, "ошибка инициализации state manager: %v", err)
+		s.logger.Warn("операция отклонена", "appName", appName, "error", err)
+		return err
 	}
-	defer sm.Close()
 
-	existingResources, err := sm.GetResourceByApp(appName)
+	existingResources, err := s.state.GetResourceByApp(appName)
 	if err != nil {
+		release()
 		s.logger.Error("ошибка проверки существующих ресурсов", "appName", appName, "error", err)
 		return status.Errorf(codes.Internal, "ошибка проверки состояния: %v", err)
 	}
 
 	if len(existingResources) > 0 {
+		release()
 		errMsg := fmt.Sprintf("окружение для '%s' уже запущено. Пожалуйста, сначала выполните 'forge down %s'", appName, appName)
 		s.logger.Warn(errMsg)
 		return status.Errorf(codes.AlreadyExists, "%s", errMsg)
@@ -69,30 +115,92 @@ func (s *forgeServer) Up(req *pb.UpRequest, stream pb.Forge_UpServer) error {
 
 	s.logger.Info("конфигурация проверена", "appName", appName)
 
-	stream.Send(&pb.LogEntry{
+	// Оркестрация выполняется заданием: отключение клиента ее не прерывает,
+	// а вывод можно получить снова через AttachJob
+	job := s.jobs.Start("up", appName, func(ctx context.Context, job *jobs.Job) error {
+		defer release()
+		return s.runUp(ctx, job, config, req)
+	})
+	s.logger.Info("запущено задание", "jobID", job.ID(), "kind", "up", "appName", appName)
+
+	return s.streamJob(job, req.GetDetach(), stream)
+}
+
+// runUp разворачивает окружение в рамках задания. Если задание отменено,
+// созданные к этому моменту ресурсы удаляются.
+func (s *forgeServer) runUp(ctx context.Context, job *jobs.Job, config *parser.Config, req *pb.UpRequest) error {
+	appName := config.AppName
+
+	job.Send(&pb.LogEntry{
 		ServiceName: "forged-daemon",
 		Timestamp:   time.Now().Unix(),
 		Message:     fmt.Sprintf("Конфигурация для '%s' принята и проверена.", appName),
 	})
 	time.Sleep(200 * time.Millisecond)
-	stream.Send(&pb.LogEntry{
+	job.Send(&pb.LogEntry{
 		ServiceName: "forged-daemon",
 		Timestamp:   time.Now().Unix(),
 		Message:     "Начинаю оркестрацию...",
 	})
 
-	orch, err := orchestrator.New(appName, stream, s.logger, sm)
+	orch, err := orchestrator.New(appName, job, s.logger, s.state, s.options())
 	if err != nil {
 		s.logger.Error("критическая ошибка инициализации оркестратора", "error", err)
 		return status.Errorf(codes.Internal, "ошибка инициализации: %v", err)
 	}
 
-	err = orch.Up(context.Background(), config)
+	// Значения переменных окружения не сохраняются: в них обычно пароли и токены
+	applied, err := parser.RedactEnv([]byte(req.GetConfigContent()))
 	if err != nil {
+		s.logger.Error("не удалось подготовить конфигурацию к сохранению", "appName", appName, "error", err)
+		return status.Errorf(codes.Internal, "ошибка сохранения запуска: %v", err)
+	}
+
+	run := state.Run{
+		ID:         uuid.New().String(),
+		AppName:    appName,
+		Config:     string(applied),
+		ConfigHash: contentHash(req.GetConfigContent()),
+		ClientUser: req.GetClientUser(),
+		WorkingDir: req.GetWorkingDir(),
+		Status:     state.RunInProgress,
+		StartedAt:  time.Now(),
+	}
+	if err := s.state.CreateRun(run); err != nil {
+		s.logger.Error("не удалось сохранить запуск", "appName", appName, "error", err)
+		return status.Errorf(codes.Internal, "ошибка сохранения запуска: %v", err)
+	}
+	orch.SetRunID(run.ID)
+	job.SetRunID(run.ID)
+
+	err = orch.Up(ctx, config)
+	if err != nil && ctx.Err() != nil {
+		s.logger.Warn("задание отменено, удаляем созданные ресурсы", "jobID", job.ID(), "appName", appName)
+		job.Send(&pb.LogEntry{
+			ServiceName: "forged-daemon",
+			Timestamp:   time.Now().Unix(),
+			Message:     "Задание отменено. Удаляю созданные ресурсы...",
+		})
+		if downErr := orch.Down(context.Background(), appName); downErr != nil {
+			s.logger.Error("не удалось удалить ресурсы отмененного задания", "appName", appName, "error", downErr)
+		}
+		if finishErr := s.state.FinishRun(run.ID, state.RunCanceled, "запуск отменен"); finishErr != nil {
+			s.logger.Error("не удалось обновить запуск", "runID", run.ID, "error", finishErr)
+		}
+		return status.Errorf(codes.Canceled, "задание %s отменено, созданные ресурсы удалены", job.ID())
+	}
+	if err != nil {
+		if finishErr := s.state.FinishRun(run.ID, state.RunFailed, err.Error()); finishErr != nil {
+			s.logger.Error("не удалось обновить запуск", "runID", run.ID, "error", finishErr)
+		}
 		s.logger.Error("ошибка выполнения оркестрации", "appName", appName, "error", err)
 		return status.Errorf(codes.Internal, "ошибка выполнения оркестрации: %v", err)
 	}
 
+	if err := s.state.FinishRun(run.ID, state.RunSucceeded, ""); err != nil {
+		s.logger.Error("не удалось обновить запуск", "runID", run.ID, "error", err)
+	}
+
 	s.logger.Info("оркестрация успешно завершена", "appName", appName)
 	return nil
 }
@@ -105,14 +213,14 @@ func (s *forgeServer) Down(ctx context.Context, req *pb.DownRequest) (*pb.DownRe
 		return nil, status.Errorf(codes.InvalidArgument, "в запросе не указано обязательное поле 'appName'")
 	}
 
-	sm, err := state.NewManager()
+	release, err := s.locks.acquire(appName, "down")
 	if err != nil {
-		s.logger.Error("критическая ошибка инициализации state manager", "error", err)
-		return nil, status.Errorf(codes.Internal, "ошибка инициализации state manager: %v", err)
+		s.logger.Warn("операция отклонена", "appName", appName, "error", err)
+		return nil, err
 	}
-	defer sm.Close()
+	defer release()
 
-	orch, err := orchestrator.New(appName, nil, s.logger, sm)
+	orch, err := orchestrator.New(appName, nil, s.logger, s.state, s.options())
 	if err != nil {
 		s.logger.Error("критическая ошибка инициализации оркестратора", "error", err)
 		return nil, status.Errorf(codes.Internal, "ошибка инициализации оркестратора: %v", err)
@@ -133,64 +241,266 @@ func (s *forgeServer) Down(ctx context.Context, req *pb.DownRequest) (*pb.DownRe
 // Logs реализует получение логов для одного или всех сервисов приложения
 func (s *forgeServer) Logs(req *pb.LogRequest, stream pb.Forge_LogsServer) error {
 	appName := req.GetAppName()
-	serviceName := req.GetServiceName()
-	follow := req.GetFollow()
 
-	sm, err := state.NewManager()
+	s.logger.Info("получен Logs-запрос", "appName", appName, "serviceName", req.GetServiceName(), "follow", req.GetFollow())
+
+	query, err := logQuery(req)
 	if err != nil {
-		s.logger.Error("критическая ошибка инициализации state manager", "error", err)
-		return status.Errorf(codes.Internal, "ошибка инициализации state manager: %v", err)
+		return err
 	}
-	defer sm.Close()
 
-	s.logger.Info("получен Logs-запрос", "appName", appName, "serviceName", serviceName, "follow", follow)
-
-	orch, err := orchestrator.New(appName, stream, s.logger, sm)
+	orch, err := orchestrator.New(appName, stream, s.logger, s.state, s.options())
 	if err != nil {
 		s.logger.Error("критическая ошибка инициализации оркестратора", "error", err)
 		return status.Errorf(codes.Internal, "ошибка инициализации оркестратора: %v", err)
 	}
 
-	return orch.Logs(stream.Context(), serviceName, follow, stream)
+	// Потоковое чтение логов (--follow) само не завершается, поэтому при
+	// остановке демона его нужно прервать, чтобы не задерживать выход
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	stop := context.AfterFunc(s.stopping, cancel)
+	defer stop()
+
+	return orch.Logs(ctx, query, stream)
+}
+
+// logQuery проверяет параметры выборки логов из запроса
+func logQuery(req *pb.LogRequest) (orchestrator.LogQuery, error) {
+	q := orchestrator.LogQuery{
+		ServiceName: req.GetServiceName(),
+		Follow:      req.GetFollow(),
+		Tail:        orchestrator.DefaultLogTail,
+		Since:       req.GetSince(),
+		Until:       req.GetUntil(),
+		Timestamps:  req.GetTimestamps(),
+		Previous:    int(req.GetPreviousIndex()),
+		Fields:      req.GetFields(),
+		Raw:         req.GetRaw(),
+	}
+	if q.Previous < 0 {
+		return q, status.Errorf(codes.InvalidArgument, "номер предыдущего контейнера должен быть положительным, получено %d", q.Previous)
+	}
+	// Клиенты без previous_index запрашивают последний предыдущий экземпляр
+	if q.Previous == 0 && req.GetPrevious() {
+		q.Previous = 1
+	}
+	if q.Previous > 0 && q.Follow {
+		return q, status.Errorf(codes.InvalidArgument, "логи предыдущих контейнеров не обновляются: --previous нельзя совмещать с --follow")
+	}
+	if req.Tail != nil {
+		q.Tail = int(req.GetTail())
+	}
+
+	for name, value := range map[string]string{"since": q.Since, "until": q.Until} {
+		if value == "" {
+			continue
+		}
+		if _, err := timetypes.GetTimestamp(value, time.Now()); err != nil {
+			return q, status.Errorf(codes.InvalidArgument, "некорректное значение %s '%s': ожидалось время RFC3339, Unix-время или длительность, например 10m", name, value)
+		}
+	}
+
+	if req.GetLevel() != "" {
+		q.Level = orchestrator.NormalizeLevel(req.GetLevel())
+		if q.Level == "" {
+			return q, status.Errorf(codes.InvalidArgument, "неизвестный уровень '%s': ожидалось debug, info, warn, error или fatal", req.GetLevel())
+		}
+	}
+
+	if req.GetGrep() != "" {
+		re, err := regexp.Compile(req.GetGrep())
+		if err != nil {
+			return q, status.Errorf(codes.InvalidArgument, "некорректное регулярное выражение grep: %v", err)
+		}
+		q.Grep = re
+	}
+	return q, nil
+}
+
+// options возвращает настройки оркестратора из текущих настроек демона
+func (s *forgeServer) options() orchestrator.Options {
+	opts := s.config.Load().OrchestratorOptions()
+	opts.Events = s.events
+	opts.LogStore = s.logStore
+	return opts
 }
 
-func InitializeServer(listenAddr string) error {
-	handler := slog.NewJSONHandler(os.Stderr, nil)
+// InitializeServer запускает демон с настройками cfg. reload повторно читает
+// настройки при получении SIGHUP.
+func InitializeServer(cfg *config.Config, reload func() (*config.Config, error)) error {
+	logLevel := new(slog.LevelVar)
+	level, err := cfg.Log.SlogLevel()
+	if err != nil {
+		return err
+	}
+	logLevel.Set(level)
+
+	var handler slog.Handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})
+	if cfg.Log.Format == config.LogFormatText {
+		handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})
+	}
 	logger := slog.New(handler)
+	startedAt := time.Now()
+
+	if cfg.Loaded() {
+		logger.Info("настройки демона загружены", "path", cfg.Path())
+	}
 
-	sm, err := state.NewManager()
+	stateCfg := cfg.StateConfig()
+	sm, err := state.Open(stateCfg)
 	if err != nil {
 		return fmt.Errorf("критическая ошибка инициализации state manager: %w", err)
 	}
+	logger.Info("хранилище состояния открыто", "backend", stateCfg.Backend, "path", stateCfg.Path)
 	defer sm.Close()
 
-	dockerCli, err := client.NewClientWithOpts(client.FromEnv)
+	opts := cfg.OrchestratorOptions()
+	dockerCli, err := orchestrator.NewDockerClient(opts.DockerHost)
 	if err != nil {
-		return fmt.Errorf("ошибка создания клиента Docker: %w", err)
+		return err
 	}
 	defer dockerCli.Close()
 
-	g, ctx := errgroup.WithContext(context.Background())
+	listenAddr := cfg.Listen.Address
+	security := cfg.Security()
+	serverOpts, err := transport.ServerOptions(security)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки безопасности gRPC: %w", err)
+	}
 
-	g.Go(func() error {
-		lis, err := net.Listen("tcp", listenAddr)
+	lis, err := transport.Listen(listenAddr)
+	if err != nil {
+		logger.Error("не удалось запустить gRPC listener", "addr", listenAddr, "error", err)
+		return fmt.Errorf("не удалось слушать адрес gRPC %s: %w", listenAddr, err)
+	}
+	if !transport.IsUnix(listenAddr) {
+		if !security.TLSEnabled() {
+			logger.Warn("демон слушает TCP без TLS: трафик передается в открытом виде", "addr", listenAddr)
+		}
+		if security.TokenFile == "" && security.ClientCAFile == "" {
+			logger.Warn("демон слушает TCP без аутентификации: подключиться к нему и выполнять команды в контейнерах может любой, кто имеет доступ к порту", "addr", listenAddr)
+		}
+	}
+
+	// Остановку запускает SIGINT/SIGTERM или RPC Shutdown
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	stopping, shutdown := context.WithCancel(sigCtx)
+	defer shutdown()
+
+	srv := &forgeServer{
+		logger:    logger,
+		logLevel:  logLevel,
+		state:     sm,
+		locks:     newAppLocks(),
+		jobs:      jobs.NewManager(),
+		events:    events.NewBus(),
+		docker:    dockerCli,
+		startedAt: startedAt,
+		stopping:  stopping,
+		shutdown:  shutdown,
+	}
+	srv.config.Store(cfg)
+
+	// Сверяем состояние с Docker при старте: база могла быть удалена,
+	// а контейнеры — изменены в обход Forge, пока демон не работал.
+	// Сверка занимает все приложения, как и запрошенная через RPC.
+	if _, err := srv.reconcile(context.Background(), false); err != nil {
+		logger.Error("не удалось сверить состояние с Docker при старте", "error", err)
+	}
+
+	if cfg.ContainerLogs.Enabled {
+		store, err := logstore.Open(cfg.LogStoreConfig())
 		if err != nil {
-			logger.Error("не удалось запустить gRPC listener", "addr", listenAddr, "error", err)
-			return fmt.Errorf("не удалось слушать порт gRPC %s: %w", listenAddr, err)
+			return err
+		}
+		srv.logStore = store
+		srv.collector = orchestrator.NewLogCollector(store, dockerCli, logger)
+		logger.Info("логи контейнеров сохраняются на диск", "dir", cfg.ContainerLogs.Dir)
+	}
+
+	// Супервизор узнает о падениях контейнеров из событий Docker напрямую от
+	// watchDocker, а не через шину событий: шина отбрасывает события медленных подписчиков
+	srv.supervisor = orchestrator.NewSupervisor(sm, dockerCli, logger, srv.options)
+
+	s := grpc.NewServer(serverOpts...)
+	pb.RegisterForgeServer(s, srv)
+
+	g, ctx := errgroup.WithContext(stopping)
+
+	g.Go(func() error {
+		logger.Info("gRPC сервер запущен", "addr", listenAddr, "pid", os.Getpid())
+		if err := s.Serve(lis); err != nil {
+			return fmt.Errorf("ошибка gRPC сервера: %w", err)
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		srv.watchDocker(ctx)
+		return nil
+	})
+
+	if srv.collector != nil {
+		g.Go(func() error {
+			srv.collector.Run(ctx)
+			return nil
+		})
+	}
+
+	g.Go(func() error {
+		srv.supervisor.Run(ctx)
+		return nil
+	})
+
+	g.Go(func() error {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-hup:
+				srv.reloadConfig(reload)
+			}
 		}
+	})
 
-		s := grpc.NewServer()
-		pb.RegisterForgeServer(s, &forgeServer{logger: logger})
-		logger.Info("gRPC сервер запущен", "addr", listenAddr)
+	// Serve возвращается сразу после начала остановки, поэтому дожидаемся
+	// завершения текущих операций здесь: иначе хранилище состояния закроется под ними
+	g.Go(func() error {
+		<-ctx.Done()
+		logger.Info("Остановка демона: ожидаем завершения текущих операций...", "operations", srv.locks.busy())
+
+		// Задания не зависят от соединений клиентов, поэтому их ждем отдельно.
+		// Не успевшие завершиться отменяются, чтобы не оставлять полуразвернутые окружения.
+		waitCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.jobs.Wait(waitCtx); err != nil {
+			logger.Warn("задания не завершились вовремя и будут отменены", "timeout", shutdownTimeout, "operations", srv.locks.busy())
+			srv.jobs.CancelAll()
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), jobCleanupTimeout)
+			defer cancel()
+			if err := srv.jobs.Wait(cleanupCtx); err != nil {
+				logger.Error("отмененные задания не завершились, ресурсы могут остаться в Docker", "timeout", jobCleanupTimeout)
+			}
+		}
 
+		stopped := make(chan struct{})
 		go func() {
-			<-ctx.Done()
-			logger.Info("Остановка gRPC сервера...")
 			s.GracefulStop()
+			close(stopped)
 		}()
 
-		if err := s.Serve(lis); err != nil {
-			return fmt.Errorf("ошибка gRPC сервера: %w", err)
+		select {
+		case <-stopped:
+			logger.Info("gRPC сервер остановлен")
+		case <-time.After(shutdownTimeout):
+			logger.Warn("запросы не завершились вовремя, оставшиеся соединения будут разорваны", "timeout", shutdownTimeout, "operations", srv.locks.busy())
+			s.Stop()
 		}
 		return nil
 	})
@@ -211,42 +521,47 @@ func (s *forgeServer) Build(req *pb.BuildRequest, stream pb.Forge_BuildServer) e
 	}
 
 	appName := config.AppName
-	sm, err := state.NewManager()
 
-	if err != nil {
-		s.logger.Error("критическая ошибка инициализации state manager", "error", err)
-		return status.Errorf(codes.Internal, "ошибка инициализации state manager: %v", err)
+	if s.stopping.Err() != nil {
+		return status.Errorf(codes.Unavailable, "демон останавливается и не принимает новые операции")
 	}
-	defer sm.Close()
 
-	orch, err := orchestrator.New(appName, stream, s.logger, sm)
+	release, err := s.locks.acquire(appName, "build")
 	if err != nil {
-		s.logger.Error("критическая ошибка инициализации оркестратора", "error", err)
-		return status.Errorf(codes.Internal, "ошибка инициализации оркестратора: %v", err)
+		s.logger.Warn("операция отклонена", "appName", appName, "error", err)
+		return err
 	}
 
-	err = orch.Build(stream.Context(), config, req.GetServicesName())
-	if err != nil {
-		s.logger.Error("ошибка выполнения сборки", "appName", appName, "error", err)
-		return status.Errorf(codes.Internal, "ошибка выполнения сборки: %v", err)
-	}
+	job := s.jobs.Start("build", appName, func(ctx context.Context, job *jobs.Job) error {
+		defer release()
 
-	s.logger.Info("сборка образов завершена", "appName", appName)
-	return nil
+		orch, err := orchestrator.New(appName, job, s.logger, s.state, s.options())
+		if err != nil {
+			s.logger.Error("критическая ошибка инициализации оркестратора", "error", err)
+			return status.Errorf(codes.Internal, "ошибка инициализации оркестратора: %v", err)
+		}
+
+		if err := orch.Build(ctx, config, req.GetServicesName()); err != nil {
+			if ctx.Err() != nil {
+				return status.Errorf(codes.Canceled, "задание %s отменено", job.ID())
+			}
+			s.logger.Error("ошибка выполнения сборки", "appName", appName, "error", err)
+			return status.Errorf(codes.Internal, "ошибка выполнения сборки: %v", err)
+		}
+
+		s.logger.Info("сборка образов завершена", "appName", appName)
+		return nil
+	})
+	s.logger.Info("запущено задание", "jobID", job.ID(), "kind", "build", "appName", appName)
+
+	return s.streamJob(job, req.GetDetach(), stream)
 }
 
 func (s *forgeServer) Status(ctx context.Context, req *pb.StatusRequest) (*pb.StatusResponse, error) {
 	appName := req.GetAppName()
 	s.logger.Info("получен Status-запрос", "appName", appName)
 
-	sm, err := state.NewManager()
-	if err != nil {
-		s.logger.Error("критическая ошибка инициализации state manager", "error", err)
-		return nil, status.Errorf(codes.Internal, "ошибка инициализации state manager: %v", err)
-	}
-	defer sm.Close()
-
-	orch, err := orchestrator.New(appName, nil, s.logger, sm)
+	orch, err := orchestrator.New(appName, nil, s.logger, s.state, s.options())
 	if err != nil {
 		s.logger.Error("критическая ошибка инициализации оркестратора", "error", err)
 		return nil, status.Errorf(codes.Internal, "ошибка инициализации оркестратора: %v", err)
@@ -264,14 +579,8 @@ func (s *forgeServer) Status(ctx context.Context, req *pb.StatusRequest) (*pb.St
 }
 
 func (s *forgeServer) Exec(stream pb.Forge_ExecServer) error {
-	sm, err := state.NewManager()
-	if err != nil {
-		s.logger.Error("критическая ошибка инициализации state manager", "error", err)
-		return status.Errorf(codes.Internal, "ошибка инициализации state manager: %v", err)
-	}
-	defer sm.Close()
 
-	orch, err := orchestrator.New("", nil, s.logger, sm)
+	orch, err := orchestrator.New("", nil, s.logger, s.state, s.options())
 	if err != nil {
 		s.logger.Error("критическая ошибка инициализации оркестратора", "error", err)
 		return status.Errorf(codes.Internal, "ошибка инициализации оркестратора: %v", err)
@@ -279,3 +588,172 @@ func (s *forgeServer) Exec(stream pb.Forge_ExecServer) error {
 
 	return orch.Exec(stream)
 }
+
+func (s *forgeServer) Reconcile(ctx context.Context, req *pb.ReconcileRequest) (*pb.ReconcileResponse, error) {
+	s.logger.Info("получен Reconcile-запрос", "dryRun", req.GetDryRun())
+
+	report, err := s.reconcile(ctx, req.GetDryRun())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ReconcileResponse{
+		Adopted: toResourceChanges(report.Adopted),
+		Pruned:  toResourceChanges(report.Pruned),
+	}, nil
+}
+
+// reconcile сверяет состояние с Docker, заняв все приложения: сверка во время
+// Up приняла бы под управление контейнер, который оркестратор уже создал, но
+// еще не успел записать в состояние.
+func (s *forgeServer) reconcile(ctx context.Context, dryRun bool) (*orchestrator.ReconcileReport, error) {
+	release, err := s.locks.acquireAll("reconcile")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	orch, err := orchestrator.New("", nil, s.logger, s.state, s.options())
+	if err != nil {
+		s.logger.Error("критическая ошибка инициализации оркестратора", "error", err)
+		return nil, status.Errorf(codes.Internal, "ошибка инициализации оркестратора: %v", err)
+	}
+
+	report, err := orch.Reconcile(ctx, dryRun)
+	if err != nil {
+		s.logger.Error("ошибка сверки состояния", "error", err)
+		return nil, status.Errorf(codes.Internal, "ошибка сверки состояния: %v", err)
+	}
+	return report, nil
+}
+
+func toResourceChanges(resources []state.Resource) []*pb.ResourceChange {
+	changes := make([]*pb.ResourceChange, 0, len(resources))
+	for _, res := range resources {
+		changes = append(changes, &pb.ResourceChange{
+			AppName:      res.AppName,
+			ServiceName:  res.ServiceName,
+			ResourceType: res.ResourceType,
+			ResourceId:   res.ID,
+		})
+	}
+	return changes
+}
+
+func (s *forgeServer) History(ctx context.Context, req *pb.HistoryRequest) (*pb.HistoryResponse, error) {
+	appName := req.GetAppName()
+	s.logger.Info("получен History-запрос", "appName", appName)
+
+	if appName == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "в запросе не указано обязательное поле 'appName'")
+	}
+
+	runs, err := s.state.GetRunsByApp(appName, int(req.GetLimit()))
+	if err != nil {
+		s.logger.Error("ошибка получения истории запусков", "appName", appName, "error", err)
+		return nil, status.Errorf(codes.Internal, "ошибка получения истории запусков: %v", err)
+	}
+
+	resp := &pb.HistoryResponse{}
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, toRunRecord(run))
+	}
+	return resp, nil
+}
+
+func (s *forgeServer) GetAppliedConfig(ctx context.Context, req *pb.AppliedConfigRequest) (*pb.AppliedConfigResponse, error) {
+	appName := req.GetAppName()
+	s.logger.Info("получен GetAppliedConfig-запрос", "appName", appName)
+
+	if appName == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "в запросе не указано обязательное поле 'appName'")
+	}
+
+	run, err := s.state.GetLatestAppliedRun(appName)
+	if errors.Is(err, state.ErrRunNotFound) {
+		return nil, status.Errorf(codes.NotFound, "для приложения '%s' нет примененной конфигурации", appName)
+	}
+	if err != nil {
+		s.logger.Error("ошибка получения примененной конфигурации", "appName", appName, "error", err)
+		return nil, status.Errorf(codes.Internal, "ошибка получения примененной конфигурации: %v", err)
+	}
+
+	resources, err := s.state.GetResourceByApp(appName)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "ошибка проверки состояния: %v", err)
+	}
+
+	return &pb.AppliedConfigResponse{
+		Run:           toRunRecord(run),
+		ConfigContent: run.Config,
+		Running:       len(resources) > 0,
+	}, nil
+}
+
+func (s *forgeServer) ExportState(ctx context.Context, req *pb.ExportStateRequest) (*pb.ExportStateResponse, error) {
+	s.logger.Info("получен ExportState-запрос")
+
+	snapshot, err := s.state.Export()
+	if err != nil {
+		s.logger.Error("ошибка экспорта состояния", "error", err)
+		return nil, status.Errorf(codes.Internal, "ошибка экспорта состояния: %v", err)
+	}
+
+	content, err := state.EncodeSnapshot(snapshot)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return &pb.ExportStateResponse{Snapshot: content}, nil
+}
+
+func (s *forgeServer) ImportState(ctx context.Context, req *pb.ImportStateRequest) (*pb.ImportStateResponse, error) {
+	s.logger.Info("получен ImportState-запрос")
+
+	snapshot, err := state.DecodeSnapshot(req.GetSnapshot())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	// Импорт меняет записи всех приложений: выполняемая операция записала бы
+	// свои ресурсы поверх импортированных
+	release, err := s.locks.acquireAll("import")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	stats, err := s.state.Import(snapshot)
+	if err != nil {
+		s.logger.Error("ошибка импорта состояния", "error", err)
+		return nil, status.Errorf(codes.Internal, "ошибка импорта состояния: %v", err)
+	}
+
+	s.logger.Info("состояние импортировано", "resources", stats.Resources, "portAllocations", stats.PortAllocations, "runs", stats.Runs)
+	return &pb.ImportStateResponse{
+		Resources:       int32(stats.Resources),
+		PortAllocations: int32(stats.PortAllocations),
+		Runs:            int32(stats.Runs),
+	}, nil
+}
+
+func toRunRecord(run state.Run) *pb.RunRecord {
+	record := &pb.RunRecord{
+		Id:         run.ID,
+		AppName:    run.AppName,
+		ConfigHash: run.ConfigHash,
+		Status:     run.Status,
+		Error:      run.Error,
+		ClientUser: run.ClientUser,
+		WorkingDir: run.WorkingDir,
+		StartedAt:  run.StartedAt.Unix(),
+	}
+	if !run.FinishedAt.IsZero() {
+		record.FinishedAt = run.FinishedAt.Unix()
+	}
+	return record
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
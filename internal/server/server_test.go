@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/waste3d/forge/internal/gen/proto"
+	"github.com/waste3d/forge/internal/state"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestImportStateWaitsForOperations(t *testing.T) {
+	s := newTestServer(t, fakeDocker{})
+
+	source := state.NewMemoryManager()
+	if err := source.AddResource(state.Resource{ID: "1", AppName: "blog", ServiceName: "web"}); err != nil {
+		t.Fatalf("AddResource: %v", err)
+	}
+	snapshot, err := source.Export()
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	content, err := state.EncodeSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("EncodeSnapshot: %v", err)
+	}
+
+	release, err := s.locks.acquire("shop", "up")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	// Пока выполняется операция, импорт не начинается и состояние не меняется
+	_, err = s.ImportState(context.Background(), &pb.ImportStateRequest{Snapshot: content})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("ImportState во время операции: %v, ожидался codes.Aborted", err)
+	}
+	if resources, _ := s.state.GetResourceByApp("blog"); len(resources) != 0 {
+		t.Fatalf("импорт изменил состояние во время операции: %+v", resources)
+	}
+
+	release()
+	resp, err := s.ImportState(context.Background(), &pb.ImportStateRequest{Snapshot: content})
+	if err != nil {
+		t.Fatalf("ImportState: %v", err)
+	}
+	if resp.GetResources() != 1 {
+		t.Errorf("импортировано ресурсов: %d, ожидался 1", resp.GetResources())
+	}
+	if !s.locks.idle() {
+		t.Error("блокировка импорта не освобождена")
+	}
+}
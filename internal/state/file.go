@@ -0,0 +1,72 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// openFile открывает хранилище состояния в JSON-файле. Данные целиком держатся в
+// памяти, а после каждого изменения файл атомарно перезаписывается.
+func openFile(path string) (Manager, error) {
+	data := &stateData{}
+
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("не удалось прочитать файл состояния: %w", err)
+	default:
+		// Файл состояния имеет тот же формат, что и снимок 'forge system state export'
+		snapshot, err := DecodeSnapshot(content)
+		if err != nil {
+			return nil, fmt.Errorf("файл состояния %s: %w", path, err)
+		}
+		data = &stateData{
+			Resources:       snapshot.Resources,
+			PortAllocations: snapshot.PortAllocations,
+			Runs:            snapshot.Runs,
+		}
+	}
+
+	return &memoryManager{
+		data: data,
+		persist: func(d *stateData) error {
+			content, err := EncodeSnapshot(newSnapshot(d))
+			if err != nil {
+				return err
+			}
+			return writeFileAtomic(path, content)
+		},
+	}, nil
+}
+
+// writeFileAtomic записывает содержимое во временный файл рядом с path и переименовывает
+// его поверх старого: при сбое на диске остается либо старая, либо новая версия.
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("не удалось создать временный файл состояния: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("не удалось записать состояние: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("не удалось записать состояние: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("не удалось записать состояние: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("не удалось записать состояние: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("не удалось заменить файл состояния: %w", err)
+	}
+	return nil
+}
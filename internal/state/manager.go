@@ -1,118 +1,135 @@
 package state
 
 import (
-	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+)
 
-	_ "github.com/mattn/go-sqlite3"
+// Статусы ресурса в состоянии
+const (
+	StatusStarting = "starting" // контейнер создан, готовность еще не подтверждена
+	StatusRunning  = "running"  // узел прошел проверку готовности
+	StatusFailed   = "failed"   // узел не удалось запустить, или он не прошел проверку готовности
+	// StatusRestarting — контейнер упал, Docker перезапустит его по политике узла
+	StatusRestarting = "restarting"
+	// StatusCrashLoop — контейнер падает снова и снова сразу после перезапуска
+	StatusCrashLoop = "crash-loop"
 )
 
 type Resource struct {
-	ID           string
-	AppName      string
-	ResourceType string
-	ServiceName  string
+	ID           string    `json:"id"`
+	AppName      string    `json:"appName"`
+	ResourceType string    `json:"resourceType"`
+	ServiceName  string    `json:"serviceName"`
+	ImageID      string    `json:"imageId"`
+	ConfigHash   string    `json:"configHash"` // хэш конфигурации узла, из которой создан ресурс
+	HostPorts    string    `json:"hostPorts"`  // опубликованные порты, например "127.0.0.1:8080->80/tcp"
+	RunID        string    `json:"runId"`      // идентификатор запуска 'forge up', создавшего ресурс
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"createdAt"`
 }
 
-type Manager struct {
-	db *sql.DB
+// PortAllocation — порт хоста, опубликованный для сервиса приложения
+type PortAllocation struct {
+	AppName       string `json:"appName"`
+	ServiceName   string `json:"serviceName"`
+	HostIP        string `json:"hostIp"`
+	HostPort      int    `json:"hostPort"`
+	ContainerPort int    `json:"containerPort"`
+	Protocol      string `json:"protocol"`
 }
 
-func (m *Manager) GetAllResources() ([]Resource, error) {
-	query := "SELECT resource_id, app_name, resource_type, service_name FROM resources"
-	rows, err := m.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось получить ресурсы: %w", err)
-	}
-	defer rows.Close()
+// Manager — хранилище состояния демона: ресурсы Docker, которыми владеет Forge,
+// опубликованные порты и история запусков. Реализации безопасны для
+// одновременного использования из нескольких горутин.
+type Manager interface {
+	GetAllResources() ([]Resource, error)
+	GetResourceByApp(appName string) ([]Resource, error)
+	AddResource(r Resource) error
+	RemoveResource(resourceId string) error
+	// ApplyResourceChanges атомарно добавляет и удаляет записи о ресурсах
+	ApplyResourceChanges(added []Resource, removedIDs []string) error
+	UpdateServiceStatus(appName, serviceName, status string) error
+
+	GetAllPortAllocations() ([]PortAllocation, error)
+	// ReplacePortAllocations атомарно заменяет опубликованные порты приложения
+	ReplacePortAllocations(appName string, ports []PortAllocation) error
+	RemovePortAllocationsByApp(appName string) error
+
+	CreateRun(r Run) error
+	FinishRun(id, status, errMsg string) error
+	// GetRunsByApp возвращает запуски приложения, начиная с последнего.
+	// limit <= 0 означает все запуски.
+	GetRunsByApp(appName string, limit int) ([]Run, error)
+	// GetLatestAppliedRun возвращает последний успешный запуск приложения или ErrRunNotFound
+	GetLatestAppliedRun(appName string) (Run, error)
+
+	// Export возвращает согласованный снимок всего состояния
+	Export() (*Snapshot, error)
+	// Import добавляет в состояние записи снимка, которых в нем еще нет
+	Import(s *Snapshot) (ImportStats, error)
+
+	Close()
+}
 
-	var resources []Resource
-	for rows.Next() {
-		var r Resource
-		if err := rows.Scan(&r.ID, &r.AppName, &r.ResourceType, &r.ServiceName); err != nil {
-			return nil, fmt.Errorf("ошибка сканирования строки ресурса: %w", err)
-		}
-		resources = append(resources, r)
-	}
-	return resources, nil
+// Бэкенды хранилища состояния
+const (
+	BackendSQLite = "sqlite" // база SQLite, требует сборки с cgo
+	BackendFile   = "file"   // JSON-файл, без внешних зависимостей
+	BackendMemory = "memory" // в памяти процесса, для тестов
+)
+
+// Config выбирает бэкенд хранилища и путь к его данным
+type Config struct {
+	Backend string
+	// Path — путь к базе или файлу состояния. Пустое значение означает путь по умолчанию в ~/.forge.
+	Path string
 }
 
-func (m *Manager) GetResourceByApp(appName string) ([]Resource, error) {
-	query := "SELECT resource_id, app_name, resource_type, service_name FROM resources WHERE app_name = ?"
-	rows, err := m.db.Query(query, appName)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось получить ресурсы: %w", err)
+// Open открывает хранилище состояния выбранного бэкенда
+func Open(cfg Config) (Manager, error) {
+	backend := cfg.Backend
+	if backend == "" {
+		backend = DefaultBackend
 	}
-	defer rows.Close()
 
-	var resources []Resource
-	for rows.Next() {
-		var r Resource
-		if err := rows.Scan(&r.ID, &r.AppName, &r.ResourceType, &r.ServiceName); err != nil {
-			return nil, fmt.Errorf("ошибка сканирования строки ресурса: %w", err)
-		}
-		resources = append(resources, r)
+	if backend == BackendMemory {
+		return NewMemoryManager(), nil
 	}
 
-	return resources, nil
-}
+	path := cfg.Path
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(backend); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию для базы данных: %w", err)
+	}
 
-func (m *Manager) RemoveResource(resourceId string) error {
-	query := "DELETE FROM resources WHERE resource_id = ?"
-	_, err := m.db.Exec(query, resourceId)
-	if err != nil {
-		return fmt.Errorf("не удалось удалить ресурс: %w", err)
+	switch backend {
+	case BackendSQLite:
+		return openSQLite(path)
+	case BackendFile:
+		return openFile(path)
+	default:
+		return nil, fmt.Errorf("неизвестный бэкенд состояния '%s' (доступны: %s, %s, %s)", backend, BackendSQLite, BackendFile, BackendMemory)
 	}
-	return nil
 }
 
-func NewManager() (*Manager, error) {
+// DefaultPath возвращает путь к данным бэкенда по умолчанию
+func DefaultPath(backend string) (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("не удалось определить домашнюю директорию: %w", err)
-	}
-	dbPath := filepath.Join(home, ".forge")
-	if err := os.MkdirAll(dbPath, 0755); err != nil {
-		return nil, fmt.Errorf("не удалось создать директорию для базы данных: %w", err)
-	}
-
-	db, err := sql.Open("sqlite3", filepath.Join(dbPath, "forge.db"))
-	if err != nil {
-		return nil, fmt.Errorf("не удалось открыть базу данных: %w", err)
+		return "", fmt.Errorf("не удалось определить домашнюю директорию: %w", err)
 	}
 
-	m := &Manager{db: db}
-
-	if err := m.createTables(); err != nil {
-		return nil, fmt.Errorf("не удалось создать таблицы: %w", err)
+	name := "forge.db"
+	if backend == BackendFile {
+		name = "state.json"
 	}
-	return m, nil
-}
-
-func (m *Manager) createTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS resources (
-		id INTEGER primary key autoincrement,
-		app_name text not null,
-		resource_type text not null, -- "container", "network", etc.
-		resource_id text not null,
-		service_name text not null,
-		created_at datetime default current_timestamp
-	);
-	`
-
-	_, err := m.db.Exec(query)
-	return err
-}
-
-func (m *Manager) AddResource(appName, resourceType, resourceId, serviceName string) error {
-	query := `insert into resources (app_name, resource_type, resource_id, service_name) values (?, ?, ?, ?)`
-	_, err := m.db.Exec(query, appName, resourceType, resourceId, serviceName)
-	return err
-}
-
-func (m *Manager) Close() {
-	m.db.Close()
+	return filepath.Join(home, ".forge", name), nil
 }
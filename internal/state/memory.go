@@ -0,0 +1,237 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stateData — все содержимое хранилища состояния
+type stateData struct {
+	Resources       []Resource       `json:"resources"`
+	PortAllocations []PortAllocation `json:"portAllocations"`
+	Runs            []Run            `json:"runs"`
+}
+
+func (d *stateData) clone() *stateData {
+	return &stateData{
+		Resources:       append([]Resource(nil), d.Resources...),
+		PortAllocations: append([]PortAllocation(nil), d.PortAllocations...),
+		Runs:            append([]Run(nil), d.Runs...),
+	}
+}
+
+// memoryManager хранит состояние в памяти процесса. Используется в тестах и
+// как основа файлового бэкенда, который сохраняет данные после каждого изменения.
+type memoryManager struct {
+	mu   sync.RWMutex
+	data *stateData
+	// persist сохраняет новую версию данных; если он вернул ошибку, изменение отменяется
+	persist func(*stateData) error
+}
+
+// NewMemoryManager возвращает пустое хранилище состояния в памяти
+func NewMemoryManager() Manager {
+	return &memoryManager{data: &stateData{}}
+}
+
+// update применяет fn к копии данных и заменяет ею текущие данные, только если
+// fn и сохранение завершились успешно. Так каждое изменение атомарно.
+func (m *memoryManager) update(fn func(d *stateData) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := m.data.clone()
+	if err := fn(next); err != nil {
+		return err
+	}
+	if m.persist != nil {
+		if err := m.persist(next); err != nil {
+			return err
+		}
+	}
+	m.data = next
+	return nil
+}
+
+func (m *memoryManager) GetAllResources() ([]Resource, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Resource(nil), m.data.Resources...), nil
+}
+
+func (m *memoryManager) GetResourceByApp(appName string) ([]Resource, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var resources []Resource
+	for _, r := range m.data.Resources {
+		if r.AppName == appName {
+			resources = append(resources, r)
+		}
+	}
+	return resources, nil
+}
+
+func (m *memoryManager) AddResource(r Resource) error {
+	return m.update(func(d *stateData) error {
+		d.Resources = append(d.Resources, newResource(r))
+		return nil
+	})
+}
+
+func (m *memoryManager) RemoveResource(resourceId string) error {
+	return m.update(func(d *stateData) error {
+		d.Resources = removeResources(d.Resources, map[string]bool{resourceId: true})
+		return nil
+	})
+}
+
+func (m *memoryManager) ApplyResourceChanges(added []Resource, removedIDs []string) error {
+	return m.update(func(d *stateData) error {
+		for _, r := range added {
+			d.Resources = append(d.Resources, newResource(r))
+		}
+		removed := make(map[string]bool, len(removedIDs))
+		for _, id := range removedIDs {
+			removed[id] = true
+		}
+		d.Resources = removeResources(d.Resources, removed)
+		return nil
+	})
+}
+
+func (m *memoryManager) UpdateServiceStatus(appName, serviceName, status string) error {
+	return m.update(func(d *stateData) error {
+		for i := range d.Resources {
+			if d.Resources[i].AppName == appName && d.Resources[i].ServiceName == serviceName {
+				d.Resources[i].Status = status
+			}
+		}
+		return nil
+	})
+}
+
+func (m *memoryManager) GetAllPortAllocations() ([]PortAllocation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]PortAllocation(nil), m.data.PortAllocations...), nil
+}
+
+func (m *memoryManager) ReplacePortAllocations(appName string, ports []PortAllocation) error {
+	return m.update(func(d *stateData) error {
+		d.PortAllocations = append(removePortAllocations(d.PortAllocations, appName), ports...)
+		return nil
+	})
+}
+
+func (m *memoryManager) RemovePortAllocationsByApp(appName string) error {
+	return m.update(func(d *stateData) error {
+		d.PortAllocations = removePortAllocations(d.PortAllocations, appName)
+		return nil
+	})
+}
+
+func (m *memoryManager) CreateRun(r Run) error {
+	return m.update(func(d *stateData) error {
+		for _, existing := range d.Runs {
+			if existing.ID == r.ID {
+				return fmt.Errorf("не удалось сохранить запуск: запуск %s уже существует", r.ID)
+			}
+		}
+		r.StartedAt = r.StartedAt.UTC()
+		r.FinishedAt = time.Time{}
+		d.Runs = append(d.Runs, r)
+		return nil
+	})
+}
+
+func (m *memoryManager) FinishRun(id, status, errMsg string) error {
+	return m.update(func(d *stateData) error {
+		for i := range d.Runs {
+			if d.Runs[i].ID == id {
+				d.Runs[i].Status = status
+				d.Runs[i].Error = errMsg
+				d.Runs[i].FinishedAt = time.Now().UTC()
+			}
+		}
+		return nil
+	})
+}
+
+func (m *memoryManager) GetRunsByApp(appName string, limit int) ([]Run, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var runs []Run
+	for _, r := range m.data.Runs {
+		if r.AppName == appName {
+			runs = append(runs, r)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func (m *memoryManager) GetLatestAppliedRun(appName string) (Run, error) {
+	runs, err := m.GetRunsByApp(appName, 0)
+	if err != nil {
+		return Run{}, err
+	}
+	for _, r := range runs {
+		if r.Status == RunSucceeded {
+			return r, nil
+		}
+	}
+	return Run{}, ErrRunNotFound
+}
+
+func (m *memoryManager) Export() (*Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return newSnapshot(m.data.clone()), nil
+}
+
+func (m *memoryManager) Import(s *Snapshot) (ImportStats, error) {
+	var stats ImportStats
+	err := m.update(func(d *stateData) error {
+		stats = mergeSnapshot(d, s)
+		return nil
+	})
+	return stats, err
+}
+
+func (m *memoryManager) Close() {}
+
+// newResource заполняет поля, которые SQLite проставляет значениями по умолчанию
+func newResource(r Resource) Resource {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now().UTC()
+	}
+	return r
+}
+
+func removeResources(resources []Resource, ids map[string]bool) []Resource {
+	kept := resources[:0]
+	for _, r := range resources {
+		if !ids[r.ID] {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+func removePortAllocations(ports []PortAllocation, appName string) []PortAllocation {
+	kept := ports[:0]
+	for _, p := range ports {
+		if p.AppName != appName {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,232 @@
+//go:build cgo
+
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxBackups — сколько резервных копий базы хранится перед миграциями
+const maxBackups = 5
+
+// migration — одно изменение схемы базы состояния. Миграции применяются по
+// порядку версий, каждая в своей транзакции, и больше никогда не изменяются:
+// новые изменения схемы добавляются только новой миграцией в конец списка.
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+var migrations = []migration{
+	{
+		version:     1,
+		description: "таблица ресурсов",
+		statements: []string{`
+		CREATE TABLE IF NOT EXISTS resources (
+			id INTEGER primary key autoincrement,
+			app_name text not null,
+			resource_type text not null, -- "container", "network", etc.
+			resource_id text not null,
+			service_name text not null,
+			created_at datetime default current_timestamp
+		)`},
+	},
+	{
+		version:     2,
+		description: "таблица опубликованных портов",
+		statements: []string{`
+		CREATE TABLE IF NOT EXISTS port_allocations (
+			id INTEGER primary key autoincrement,
+			app_name text not null,
+			service_name text not null,
+			host_ip text not null,
+			host_port integer not null,
+			container_port integer not null,
+			protocol text not null, -- "tcp" или "udp"
+			created_at datetime default current_timestamp
+		)`},
+	},
+	{
+		version:     3,
+		description: "метаданные ресурсов",
+		statements: []string{
+			`ALTER TABLE resources ADD COLUMN image_id text not null default ''`,
+			`ALTER TABLE resources ADD COLUMN config_hash text not null default ''`,
+			`ALTER TABLE resources ADD COLUMN host_ports text not null default ''`,
+			`ALTER TABLE resources ADD COLUMN run_id text not null default ''`,
+			`ALTER TABLE resources ADD COLUMN status text not null default ''`,
+			`ALTER TABLE resources ADD COLUMN updated_at datetime`,
+			`CREATE INDEX IF NOT EXISTS idx_resources_app_name ON resources (app_name)`,
+		},
+	},
+	{
+		version:     4,
+		description: "история запусков",
+		statements: []string{`
+		CREATE TABLE IF NOT EXISTS runs (
+			id text primary key,
+			app_name text not null,
+			config text not null, -- примененный forge.yaml
+			config_hash text not null,
+			client_user text not null default '',
+			working_dir text not null default '',
+			status text not null, -- "in-progress", "succeeded", "failed"
+			error text not null default '',
+			started_at datetime not null,
+			finished_at datetime
+		)`,
+			`CREATE INDEX IF NOT EXISTS idx_runs_app_name ON runs (app_name, started_at)`,
+		},
+	},
+	{
+		version:     5,
+		description: "счетчик перезапусков",
+		statements: []string{
+			`ALTER TABLE resources ADD COLUMN restart_count integer not null default 0`,
+		},
+	},
+	{
+		// Перезапуски выполняет Docker и сам считает их в RestartCount контейнера
+		version:     6,
+		description: "удаление счетчика перезапусков",
+		statements: []string{
+			`ALTER TABLE resources DROP COLUMN restart_count`,
+		},
+	},
+}
+
+// migrate приводит схему базы к последней версии. Базы, созданные до появления
+// миграций, имеют версию 0: первые миграции для них идемпотентны.
+func (m *sqliteManager) migrate() error {
+	_, err := m.db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER primary key,
+		description text not null,
+		applied_at datetime default current_timestamp
+	)`)
+	if err != nil {
+		return fmt.Errorf("не удалось создать таблицу schema_version: %w", err)
+	}
+
+	current, err := m.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current > latestSchemaVersion() {
+		return fmt.Errorf("схема базы состояния (версия %d) новее, чем поддерживает эта версия forged (%d)", current, latestSchemaVersion())
+	}
+
+	if current < latestSchemaVersion() {
+		if err := m.backupBeforeMigration(current); err != nil {
+			return fmt.Errorf("не удалось создать резервную копию базы перед миграцией: %w", err)
+		}
+	}
+
+	for _, mig := range migrations {
+		if mig.version <= current {
+			continue
+		}
+		if err := m.applyMigration(mig); err != nil {
+			return fmt.Errorf("миграция %d (%s): %w", mig.version, mig.description, err)
+		}
+	}
+	return nil
+}
+
+func (m *sqliteManager) applyMigration(mig migration) error {
+	return m.withTx(func(tx *sql.Tx) error {
+		for _, stmt := range mig.statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec("INSERT INTO schema_version (version, description) VALUES (?, ?)", mig.version, mig.description)
+		return err
+	})
+}
+
+// SchemaVersion возвращает номер последней примененной миграции
+func (m *sqliteManager) SchemaVersion() (int, error) {
+	var version sql.NullInt64
+	if err := m.db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("не удалось определить версию схемы: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// latestSchemaVersion — версия схемы, которую ожидает текущая сборка
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// backupBeforeMigration сохраняет копию базы в ~/.forge/backups перед изменением
+// схемы, чтобы неудачную миграцию можно было откатить вручную. Хранятся только
+// последние maxBackups копий. Новая пустая база не копируется.
+func (m *sqliteManager) backupBeforeMigration(version int) error {
+	var tables int
+	err := m.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name != 'schema_version'").Scan(&tables)
+	if err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	dir := filepath.Join(filepath.Dir(m.path), "backups")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	prefix := strings.TrimSuffix(filepath.Base(m.path), filepath.Ext(m.path)) + "-"
+	name := fmt.Sprintf("%sv%d-%s.db", prefix, version, time.Now().UTC().Format("20060102T150405Z"))
+
+	// VACUUM INTO дает согласованную копию даже в режиме WAL, в отличие от копирования файла
+	if _, err := m.db.Exec("VACUUM INTO ?", filepath.Join(dir, name)); err != nil {
+		return err
+	}
+
+	return rotateBackups(dir, prefix, maxBackups)
+}
+
+// rotateBackups удаляет самые старые резервные копии с префиксом prefix, оставляя keep штук
+func rotateBackups(dir, prefix string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) && strings.HasSuffix(e.Name(), ".db") {
+			backups = append(backups, e.Name())
+		}
+	}
+	if len(backups) <= keep {
+		return nil
+	}
+
+	// Имена копий заканчиваются временем создания, поэтому сортировка по
+	// времени совпадает с сортировкой по имени после версии схемы.
+	sort.Slice(backups, func(i, j int) bool {
+		return backupTime(backups[i]) < backupTime(backups[j])
+	})
+	for _, name := range backups[:len(backups)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func backupTime(name string) string {
+	name = strings.TrimSuffix(name, ".db")
+	return name[strings.LastIndex(name, "-")+1:]
+}
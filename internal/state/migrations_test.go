@@ -0,0 +1,71 @@
+//go:build cgo
+
+package state
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateFromV1(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "forge.db")
+
+	// База первой версии: только таблица ресурсов и одна запись в ней
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		migrations[0].statements[0],
+		`CREATE TABLE schema_version (
+			version INTEGER primary key,
+			description text not null,
+			applied_at datetime default current_timestamp
+		)`,
+		`INSERT INTO schema_version (version, description) VALUES (1, 'таблица ресурсов')`,
+		`INSERT INTO resources (app_name, resource_type, resource_id, service_name) VALUES ('shop', 'container', 'abc123', 'api')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("не удалось подготовить базу v1: %v", err)
+		}
+	}
+	db.Close()
+
+	m, err := Open(Config{Backend: BackendSQLite, Path: path})
+	if err != nil {
+		t.Fatalf("не удалось открыть базу v1: %v", err)
+	}
+	defer m.Close()
+
+	version, err := m.(*sqliteManager).SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != latestSchemaVersion() {
+		t.Errorf("версия схемы %d, ожидалась %d", version, latestSchemaVersion())
+	}
+
+	resources, err := m.GetResourceByApp("shop")
+	if err != nil {
+		t.Fatalf("не удалось прочитать ресурсы после миграции: %v", err)
+	}
+	if len(resources) != 1 || resources[0].ID != "abc123" || resources[0].ServiceName != "api" {
+		t.Fatalf("ресурсы после миграции: %+v", resources)
+	}
+
+	// Новые столбцы доступны для записи
+	if err := m.UpdateServiceStatus("shop", "api", StatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CreateRun(Run{ID: "run-1", AppName: "shop", Status: RunInProgress}); err != nil {
+		t.Fatalf("таблица запусков не создана: %v", err)
+	}
+
+	backups, err := os.ReadDir(filepath.Join(dir, "backups"))
+	if err != nil || len(backups) != 1 {
+		t.Errorf("перед миграцией должна быть создана одна резервная копия: %v, %v", backups, err)
+	}
+}
@@ -0,0 +1,31 @@
+package state
+
+import (
+	"errors"
+	"time"
+)
+
+// Статусы запуска 'forge up'
+const (
+	RunInProgress = "in-progress"
+	RunSucceeded  = "succeeded"
+	RunFailed     = "failed"
+	RunCanceled   = "canceled" // задание отменено через 'forge cancel', ресурсы удалены
+)
+
+// Run — запись о применении конфигурации приложения
+type Run struct {
+	ID         string    `json:"id"`
+	AppName    string    `json:"appName"`
+	Config     string    `json:"config"`
+	ConfigHash string    `json:"configHash"`
+	ClientUser string    `json:"clientUser"`
+	WorkingDir string    `json:"workingDir"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// ErrRunNotFound возвращается, если для приложения нет подходящего запуска
+var ErrRunNotFound = errors.New("запуск не найден")
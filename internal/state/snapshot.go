@@ -0,0 +1,127 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SnapshotVersion — версия формата снимка состояния. Увеличивается при
+// несовместимых изменениях; старые снимки должны по-прежнему импортироваться.
+const SnapshotVersion = 1
+
+// Snapshot — переносимый снимок всего состояния демона. Используется командами
+// 'forge system state export/import' и как формат файлового бэкенда.
+type Snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Apps — приложения, упомянутые в снимке. Только для чтения человеком, при импорте не используется.
+	Apps            []string         `json:"apps"`
+	Resources       []Resource       `json:"resources"`
+	PortAllocations []PortAllocation `json:"portAllocations"`
+	Runs            []Run            `json:"runs"`
+}
+
+// ImportStats — число записей, добавленных при импорте снимка
+type ImportStats struct {
+	Resources       int
+	PortAllocations int
+	Runs            int
+}
+
+func newSnapshot(d *stateData) *Snapshot {
+	apps := make(map[string]bool)
+	for _, r := range d.Resources {
+		apps[r.AppName] = true
+	}
+	for _, r := range d.Runs {
+		apps[r.AppName] = true
+	}
+
+	// Пустые списки сериализуются как [], а не null
+	s := &Snapshot{
+		Version:         SnapshotVersion,
+		CreatedAt:       time.Now().UTC(),
+		Apps:            make([]string, 0, len(apps)),
+		Resources:       append([]Resource{}, d.Resources...),
+		PortAllocations: append([]PortAllocation{}, d.PortAllocations...),
+		Runs:            append([]Run{}, d.Runs...),
+	}
+	for app := range apps {
+		s.Apps = append(s.Apps, app)
+	}
+	sort.Strings(s.Apps)
+	return s
+}
+
+// EncodeSnapshot сериализует снимок в JSON
+func EncodeSnapshot(s *Snapshot) ([]byte, error) {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сериализовать снимок состояния: %w", err)
+	}
+	return content, nil
+}
+
+// DecodeSnapshot разбирает JSON-снимок и проверяет, что его версия поддерживается
+func DecodeSnapshot(content []byte) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.Unmarshal(content, &s); err != nil {
+		return nil, fmt.Errorf("некорректный снимок состояния: %w", err)
+	}
+	if s.Version < 1 {
+		return nil, fmt.Errorf("в снимке состояния не указана версия формата")
+	}
+	if s.Version > SnapshotVersion {
+		return nil, fmt.Errorf("снимок состояния (версия %d) новее, чем поддерживает эта версия forged (%d)", s.Version, SnapshotVersion)
+	}
+	return &s, nil
+}
+
+// mergeSnapshot добавляет к данным записи снимка, которых в них еще нет: ресурсы и
+// запуски сравниваются по идентификатору, а порты берутся только для приложений,
+// у которых в состоянии нет ни одного порта. Существующие записи не изменяются.
+func mergeSnapshot(d *stateData, s *Snapshot) ImportStats {
+	var stats ImportStats
+
+	resources := make(map[string]bool, len(d.Resources))
+	for _, r := range d.Resources {
+		resources[r.ID] = true
+	}
+	for _, r := range s.Resources {
+		if resources[r.ID] {
+			continue
+		}
+		resources[r.ID] = true
+		d.Resources = append(d.Resources, newResource(r))
+		stats.Resources++
+	}
+
+	appsWithPorts := make(map[string]bool)
+	for _, p := range d.PortAllocations {
+		appsWithPorts[p.AppName] = true
+	}
+	for _, p := range s.PortAllocations {
+		if appsWithPorts[p.AppName] {
+			continue
+		}
+		d.PortAllocations = append(d.PortAllocations, p)
+		stats.PortAllocations++
+	}
+
+	runs := make(map[string]bool, len(d.Runs))
+	for _, r := range d.Runs {
+		runs[r.ID] = true
+	}
+	for _, r := range s.Runs {
+		if runs[r.ID] {
+			continue
+		}
+		runs[r.ID] = true
+		d.Runs = append(d.Runs, r)
+		stats.Runs++
+	}
+
+	return stats
+}
@@ -0,0 +1,356 @@
+//go:build cgo
+
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DefaultBackend — бэкенд состояния по умолчанию
+const DefaultBackend = BackendSQLite
+
+// sqliteManager хранит состояние в базе SQLite. Требует cgo.
+type sqliteManager struct {
+	db   *sql.DB
+	path string
+}
+
+const resourceColumns = "resource_id, app_name, resource_type, service_name, image_id, config_hash, host_ports, run_id, status, created_at"
+
+func (m *sqliteManager) GetAllResources() ([]Resource, error) {
+	return queryResources(m.db, "SELECT "+resourceColumns+" FROM resources")
+}
+
+func (m *sqliteManager) GetResourceByApp(appName string) ([]Resource, error) {
+	return queryResources(m.db, "SELECT "+resourceColumns+" FROM resources WHERE app_name = ?", appName)
+}
+
+func queryResources(q querier, query string, args ...interface{}) ([]Resource, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить ресурсы: %w", err)
+	}
+	defer rows.Close()
+
+	var resources []Resource
+	for rows.Next() {
+		var r Resource
+		var createdAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.AppName, &r.ResourceType, &r.ServiceName, &r.ImageID, &r.ConfigHash, &r.HostPorts, &r.RunID, &r.Status, &createdAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки ресурса: %w", err)
+		}
+		r.CreatedAt = createdAt.Time
+		resources = append(resources, r)
+	}
+
+	return resources, rows.Err()
+}
+
+func (m *sqliteManager) RemoveResource(resourceId string) error {
+	query := "DELETE FROM resources WHERE resource_id = ?"
+	_, err := m.db.Exec(query, resourceId)
+	if err != nil {
+		return fmt.Errorf("не удалось удалить ресурс: %w", err)
+	}
+	return nil
+}
+
+func openSQLite(path string) (Manager, error) {
+	// WAL позволяет читать состояние, пока идет запись, а busy_timeout заставляет
+	// конкурирующие записи ждать освобождения блокировки вместо ошибки SQLITE_BUSY.
+	// Транзакции открываются сразу на запись (immediate), чтобы не получать
+	// взаимоблокировку при повышении уровня блокировки внутри транзакции.
+	dsn := "file:" + path + "?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть базу данных: %w", err)
+	}
+
+	m := &sqliteManager{db: db, path: path}
+
+	if err := m.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("не удалось обновить схему базы состояния: %w", err)
+	}
+	return m, nil
+}
+
+func (m *sqliteManager) AddResource(r Resource) error {
+	return insertResource(m.db, r)
+}
+
+func insertResource(e execer, r Resource) error {
+	r = newResource(r)
+	query := `insert into resources (app_name, resource_type, resource_id, service_name, image_id, config_hash, host_ports, run_id, status, created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, current_timestamp)`
+	_, err := e.Exec(query, r.AppName, r.ResourceType, r.ID, r.ServiceName, r.ImageID, r.ConfigHash, r.HostPorts, r.RunID, r.Status, r.CreatedAt)
+	return err
+}
+
+// UpdateServiceStatus обновляет статус ресурсов сервиса приложения
+func (m *sqliteManager) UpdateServiceStatus(appName, serviceName, status string) error {
+	query := "UPDATE resources SET status = ?, updated_at = current_timestamp WHERE app_name = ? AND service_name = ?"
+	_, err := m.db.Exec(query, status, appName, serviceName)
+	if err != nil {
+		return fmt.Errorf("не удалось обновить статус сервиса: %w", err)
+	}
+	return nil
+}
+
+// ApplyResourceChanges одной транзакцией добавляет и удаляет записи о ресурсах
+func (m *sqliteManager) ApplyResourceChanges(added []Resource, removedIDs []string) error {
+	return m.withTx(func(tx *sql.Tx) error {
+		for _, r := range added {
+			if err := insertResource(tx, r); err != nil {
+				return fmt.Errorf("не удалось добавить ресурс %s: %w", r.ID, err)
+			}
+		}
+		for _, id := range removedIDs {
+			if _, err := tx.Exec("DELETE FROM resources WHERE resource_id = ?", id); err != nil {
+				return fmt.Errorf("не удалось удалить ресурс %s: %w", id, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ReplacePortAllocations атомарно заменяет опубликованные порты приложения:
+// другие приложения никогда не видят промежуточного состояния без портов.
+func (m *sqliteManager) ReplacePortAllocations(appName string, ports []PortAllocation) error {
+	return m.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM port_allocations WHERE app_name = ?", appName); err != nil {
+			return fmt.Errorf("не удалось удалить порты приложения: %w", err)
+		}
+		for _, p := range ports {
+			if err := insertPortAllocation(tx, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func insertPortAllocation(e execer, p PortAllocation) error {
+	query := `insert into port_allocations (app_name, service_name, host_ip, host_port, container_port, protocol) values (?, ?, ?, ?, ?, ?)`
+	_, err := e.Exec(query, p.AppName, p.ServiceName, p.HostIP, p.HostPort, p.ContainerPort, p.Protocol)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить порт %d: %w", p.HostPort, err)
+	}
+	return nil
+}
+
+func (m *sqliteManager) GetAllPortAllocations() ([]PortAllocation, error) {
+	return queryPortAllocations(m.db)
+}
+
+func queryPortAllocations(q querier) ([]PortAllocation, error) {
+	query := "SELECT app_name, service_name, host_ip, host_port, container_port, protocol FROM port_allocations"
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить порты: %w", err)
+	}
+	defer rows.Close()
+
+	var ports []PortAllocation
+	for rows.Next() {
+		var p PortAllocation
+		if err := rows.Scan(&p.AppName, &p.ServiceName, &p.HostIP, &p.HostPort, &p.ContainerPort, &p.Protocol); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки порта: %w", err)
+		}
+		ports = append(ports, p)
+	}
+	return ports, rows.Err()
+}
+
+func (m *sqliteManager) RemovePortAllocationsByApp(appName string) error {
+	query := "DELETE FROM port_allocations WHERE app_name = ?"
+	_, err := m.db.Exec(query, appName)
+	if err != nil {
+		return fmt.Errorf("не удалось удалить порты приложения: %w", err)
+	}
+	return nil
+}
+
+// execer — общий интерфейс *sql.DB и *sql.Tx для запросов на запись
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// querier — общий интерфейс *sql.DB и *sql.Tx для запросов на чтение
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// withTx выполняет fn в транзакции: она фиксируется, если fn вернула nil, и откатывается иначе
+func (m *sqliteManager) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *sqliteManager) Export() (*Snapshot, error) {
+	var data *stateData
+	err := m.withTx(func(tx *sql.Tx) error {
+		var err error
+		data, err = loadData(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newSnapshot(data), nil
+}
+
+func (m *sqliteManager) Import(s *Snapshot) (ImportStats, error) {
+	var stats ImportStats
+	err := m.withTx(func(tx *sql.Tx) error {
+		data, err := loadData(tx)
+		if err != nil {
+			return err
+		}
+
+		// mergeSnapshot дописывает новые записи в конец списков: вставляем только их
+		before := data.clone()
+		stats = mergeSnapshot(data, s)
+
+		for _, r := range data.Resources[len(before.Resources):] {
+			if err := insertResource(tx, r); err != nil {
+				return fmt.Errorf("не удалось импортировать ресурс %s: %w", r.ID, err)
+			}
+		}
+		for _, p := range data.PortAllocations[len(before.PortAllocations):] {
+			if err := insertPortAllocation(tx, p); err != nil {
+				return err
+			}
+		}
+		for _, r := range data.Runs[len(before.Runs):] {
+			if err := insertRun(tx, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return stats, err
+}
+
+func loadData(q querier) (*stateData, error) {
+	resources, err := queryResources(q, "SELECT "+resourceColumns+" FROM resources")
+	if err != nil {
+		return nil, err
+	}
+	ports, err := queryPortAllocations(q)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := queryRuns(q, "SELECT "+runColumns+" FROM runs ORDER BY started_at")
+	if err != nil {
+		return nil, err
+	}
+	return &stateData{Resources: resources, PortAllocations: ports, Runs: runs}, nil
+}
+
+func (m *sqliteManager) Close() {
+	m.db.Close()
+}
+
+const runColumns = "id, app_name, config, config_hash, client_user, working_dir, status, error, started_at, finished_at"
+
+func (m *sqliteManager) CreateRun(r Run) error {
+	r.FinishedAt = time.Time{}
+	return insertRun(m.db, r)
+}
+
+func insertRun(e execer, r Run) error {
+	var finishedAt sql.NullTime
+	if !r.FinishedAt.IsZero() {
+		finishedAt = sql.NullTime{Time: r.FinishedAt.UTC(), Valid: true}
+	}
+
+	query := `insert into runs (` + runColumns + `) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := e.Exec(query, r.ID, r.AppName, r.Config, r.ConfigHash, r.ClientUser, r.WorkingDir, r.Status, r.Error, r.StartedAt.UTC(), finishedAt)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить запуск: %w", err)
+	}
+	return nil
+}
+
+// FinishRun фиксирует результат запуска
+func (m *sqliteManager) FinishRun(id, status, errMsg string) error {
+	query := "UPDATE runs SET status = ?, error = ?, finished_at = ? WHERE id = ?"
+	_, err := m.db.Exec(query, status, errMsg, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("не удалось обновить запуск: %w", err)
+	}
+	return nil
+}
+
+// GetRunsByApp возвращает запуски приложения, начиная с последнего.
+// limit <= 0 означает все запуски.
+func (m *sqliteManager) GetRunsByApp(appName string, limit int) ([]Run, error) {
+	query := "SELECT " + runColumns + " FROM runs WHERE app_name = ? ORDER BY started_at DESC"
+	args := []interface{}{appName}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	return queryRuns(m.db, query, args...)
+}
+
+func queryRuns(q querier, query string, args ...interface{}) ([]Run, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить историю запусков: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		r, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// GetLatestAppliedRun возвращает последний успешный запуск приложения
+func (m *sqliteManager) GetLatestAppliedRun(appName string) (Run, error) {
+	query := "SELECT " + runColumns + " FROM runs WHERE app_name = ? AND status = ? ORDER BY started_at DESC LIMIT 1"
+	r, err := scanRun(m.db.QueryRow(query, appName, RunSucceeded))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Run{}, ErrRunNotFound
+	}
+	return r, err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRun(row rowScanner) (Run, error) {
+	var r Run
+	var finishedAt sql.NullTime
+	err := row.Scan(&r.ID, &r.AppName, &r.Config, &r.ConfigHash, &r.ClientUser, &r.WorkingDir, &r.Status, &r.Error, &r.StartedAt, &finishedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return r, err
+		}
+		return r, fmt.Errorf("ошибка сканирования строки запуска: %w", err)
+	}
+	r.FinishedAt = finishedAt.Time
+	return r, nil
+}
@@ -0,0 +1,13 @@
+//go:build !cgo
+
+package state
+
+import "fmt"
+
+// DefaultBackend — бэкенд состояния по умолчанию. Сборка без cgo не содержит
+// драйвера SQLite, поэтому по умолчанию используется JSON-файл.
+const DefaultBackend = BackendFile
+
+func openSQLite(path string) (Manager, error) {
+	return nil, fmt.Errorf("бэкенд '%s' недоступен: forged собран без cgo. Используйте -state-backend=%s", BackendSQLite, BackendFile)
+}
@@ -0,0 +1,200 @@
+package state
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openBackends возвращает по хранилищу каждого доступного бэкенда
+func openBackends(t *testing.T) map[string]Manager {
+	t.Helper()
+	dir := t.TempDir()
+
+	backends := map[string]Manager{BackendMemory: NewMemoryManager()}
+
+	file, err := Open(Config{Backend: BackendFile, Path: filepath.Join(dir, "state.json")})
+	if err != nil {
+		t.Fatalf("не удалось открыть файловый бэкенд: %v", err)
+	}
+	backends[BackendFile] = file
+
+	if DefaultBackend == BackendSQLite {
+		db, err := Open(Config{Backend: BackendSQLite, Path: filepath.Join(dir, "forge.db")})
+		if err != nil {
+			t.Fatalf("не удалось открыть бэкенд SQLite: %v", err)
+		}
+		backends[BackendSQLite] = db
+	}
+
+	t.Cleanup(func() {
+		for _, m := range backends {
+			m.Close()
+		}
+	})
+	return backends
+}
+
+func TestManagerResources(t *testing.T) {
+	for name, m := range openBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			mustDo(t, m.AddResource(Resource{ID: "net-1", AppName: "shop", ResourceType: "network", ServiceName: "shop-network"}))
+			mustDo(t, m.AddResource(Resource{ID: "c-1", AppName: "shop", ResourceType: "container", ServiceName: "api", Status: StatusStarting}))
+			mustDo(t, m.AddResource(Resource{ID: "c-2", AppName: "blog", ResourceType: "container", ServiceName: "web"}))
+
+			mustDo(t, m.UpdateServiceStatus("shop", "api", StatusRunning))
+			mustDo(t, m.ApplyResourceChanges([]Resource{{ID: "c-3", AppName: "blog", ResourceType: "container", ServiceName: "db"}}, []string{"c-2"}))
+			mustDo(t, m.RemoveResource("net-1"))
+
+			shop, err := m.GetResourceByApp("shop")
+			mustDo(t, err)
+			if len(shop) != 1 || shop[0].ID != "c-1" || shop[0].Status != StatusRunning {
+				t.Errorf("ресурсы 'shop' = %+v, ожидался c-1 в статусе running", shop)
+			}
+			if shop[0].CreatedAt.IsZero() {
+				t.Errorf("у ресурса не заполнено время создания")
+			}
+
+			all, err := m.GetAllResources()
+			mustDo(t, err)
+			if len(all) != 2 {
+				t.Errorf("всего ресурсов = %d, ожидалось 2", len(all))
+			}
+		})
+	}
+}
+
+func TestManagerPortAllocations(t *testing.T) {
+	for name, m := range openBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			mustDo(t, m.ReplacePortAllocations("shop", []PortAllocation{
+				{AppName: "shop", ServiceName: "api", HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+				{AppName: "shop", ServiceName: "db", HostIP: "127.0.0.1", HostPort: 5432, ContainerPort: 5432, Protocol: "tcp"},
+			}))
+			mustDo(t, m.ReplacePortAllocations("blog", []PortAllocation{
+				{AppName: "blog", ServiceName: "web", HostIP: "127.0.0.1", HostPort: 3000, ContainerPort: 3000, Protocol: "tcp"},
+			}))
+			mustDo(t, m.ReplacePortAllocations("shop", []PortAllocation{
+				{AppName: "shop", ServiceName: "api", HostIP: "127.0.0.1", HostPort: 8081, ContainerPort: 80, Protocol: "tcp"},
+			}))
+
+			ports, err := m.GetAllPortAllocations()
+			mustDo(t, err)
+			if len(ports) != 2 {
+				t.Fatalf("портов = %d, ожидалось 2: %+v", len(ports), ports)
+			}
+
+			mustDo(t, m.RemovePortAllocationsByApp("blog"))
+			ports, err = m.GetAllPortAllocations()
+			mustDo(t, err)
+			if len(ports) != 1 || ports[0].HostPort != 8081 {
+				t.Errorf("после удаления порты = %+v, ожидался только 8081", ports)
+			}
+		})
+	}
+}
+
+func TestManagerRuns(t *testing.T) {
+	for name, m := range openBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := m.GetLatestAppliedRun("shop"); !errors.Is(err, ErrRunNotFound) {
+				t.Fatalf("для пустой истории ожидалась ErrRunNotFound, получено %v", err)
+			}
+
+			start := time.Now().Add(-time.Hour)
+			for i, id := range []string{"run-1", "run-2", "run-3"} {
+				mustDo(t, m.CreateRun(Run{ID: id, AppName: "shop", Config: id, Status: RunInProgress, StartedAt: start.Add(time.Duration(i) * time.Minute)}))
+			}
+			mustDo(t, m.FinishRun("run-1", RunSucceeded, ""))
+			mustDo(t, m.FinishRun("run-2", RunSucceeded, ""))
+			mustDo(t, m.FinishRun("run-3", RunFailed, "порт занят"))
+
+			runs, err := m.GetRunsByApp("shop", 2)
+			mustDo(t, err)
+			if len(runs) != 2 || runs[0].ID != "run-3" || runs[1].ID != "run-2" {
+				t.Fatalf("последние запуски = %+v, ожидались run-3 и run-2", runs)
+			}
+			if runs[0].Error != "порт занят" || runs[0].FinishedAt.IsZero() {
+				t.Errorf("результат run-3 не сохранен: %+v", runs[0])
+			}
+
+			applied, err := m.GetLatestAppliedRun("shop")
+			mustDo(t, err)
+			if applied.ID != "run-2" {
+				t.Errorf("примененный запуск = %s, ожидался run-2", applied.ID)
+			}
+		})
+	}
+}
+
+func TestFileBackendPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	m, err := Open(Config{Backend: BackendFile, Path: path})
+	mustDo(t, err)
+	mustDo(t, m.AddResource(Resource{ID: "c-1", AppName: "shop", ResourceType: "container", ServiceName: "api"}))
+	m.Close()
+
+	reopened, err := Open(Config{Backend: BackendFile, Path: path})
+	mustDo(t, err)
+	defer reopened.Close()
+
+	resources, err := reopened.GetResourceByApp("shop")
+	mustDo(t, err)
+	if len(resources) != 1 || resources[0].ID != "c-1" {
+		t.Errorf("после повторного открытия ресурсы = %+v, ожидался c-1", resources)
+	}
+}
+
+func TestSnapshotExportImport(t *testing.T) {
+	source := NewMemoryManager()
+	mustDo(t, source.AddResource(Resource{ID: "c-1", AppName: "shop", ResourceType: "container", ServiceName: "api"}))
+	mustDo(t, source.ReplacePortAllocations("shop", []PortAllocation{{AppName: "shop", ServiceName: "api", HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}}))
+	mustDo(t, source.CreateRun(Run{ID: "run-1", AppName: "shop", Config: "appName: shop", Status: RunInProgress, StartedAt: time.Now()}))
+	mustDo(t, source.FinishRun("run-1", RunSucceeded, ""))
+
+	snapshot, err := source.Export()
+	mustDo(t, err)
+	content, err := EncodeSnapshot(snapshot)
+	mustDo(t, err)
+	decoded, err := DecodeSnapshot(content)
+	mustDo(t, err)
+	if len(decoded.Apps) != 1 || decoded.Apps[0] != "shop" {
+		t.Errorf("приложения в снимке = %v, ожидалось [shop]", decoded.Apps)
+	}
+
+	for name, m := range openBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			stats, err := m.Import(decoded)
+			mustDo(t, err)
+			if stats != (ImportStats{Resources: 1, PortAllocations: 1, Runs: 1}) {
+				t.Errorf("импортировано %+v, ожидалось по одной записи каждого вида", stats)
+			}
+
+			// Повторный импорт того же снимка ничего не добавляет
+			stats, err = m.Import(decoded)
+			mustDo(t, err)
+			if stats != (ImportStats{}) {
+				t.Errorf("повторный импорт добавил записи: %+v", stats)
+			}
+
+			applied, err := m.GetLatestAppliedRun("shop")
+			mustDo(t, err)
+			if applied.Config != "appName: shop" || applied.FinishedAt.IsZero() {
+				t.Errorf("импортированный запуск = %+v", applied)
+			}
+		})
+	}
+
+	if _, err := DecodeSnapshot([]byte(`{"version": 99}`)); err == nil {
+		t.Errorf("ожидалась ошибка для снимка неподдерживаемой версии")
+	}
+}
+
+func mustDo(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Имена файлов, которые создает GenerateCertificates
+const (
+	CAFile        = "ca.pem"
+	CAKeyFile     = "ca-key.pem"
+	ServerFile    = "server.pem"
+	ServerKeyFile = "server-key.pem"
+	ClientFile    = "client.pem"
+	ClientKeyFile = "client-key.pem"
+	TokenFile     = "token"
+)
+
+const (
+	caValidity   = 10 * 365 * 24 * time.Hour
+	certValidity = 2 * 365 * 24 * time.Hour
+)
+
+// ErrCertificatesExist — в директории уже есть сертификаты, а перезапись не разрешена
+var ErrCertificatesExist = errors.New("сертификаты уже существуют")
+
+// GenerateCertificates создает в dir локальный CA, сертификат сервера для hosts
+// и клиентский сертификат для mTLS, а также файл с токеном доступа. Уже
+// существующие CA и токен переиспользуются, чтобы выпуск сертификата для нового
+// хоста не обесценивал выданные ранее клиентские сертификаты. Существующие
+// сертификаты сервера и клиента перезаписываются только при force, иначе
+// возвращается ErrCertificatesExist.
+func GenerateCertificates(dir string, hosts []string, force bool) error {
+	if len(hosts) == 0 {
+		return errors.New("не указано ни одного хоста для сертификата сервера")
+	}
+	if !force {
+		var existing []string
+		for _, name := range []string{ServerFile, ServerKeyFile, ClientFile, ClientKeyFile} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				existing = append(existing, name)
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("%w в %s: %s", ErrCertificatesExist, dir, strings.Join(existing, ", "))
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("не удалось создать директорию для сертификатов: %w", err)
+	}
+
+	caCert, caKey, err := loadOrCreateCA(dir, force)
+	if err != nil {
+		return err
+	}
+
+	server := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: hosts[0], Organization: []string{"Forge"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			server.IPAddresses = append(server.IPAddresses, ip)
+		} else {
+			server.DNSNames = append(server.DNSNames, h)
+		}
+	}
+	if err := issueCertificate(dir, ServerFile, ServerKeyFile, server, caCert, caKey); err != nil {
+		return err
+	}
+
+	client := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "forge-client", Organization: []string{"Forge"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if err := issueCertificate(dir, ClientFile, ClientKeyFile, client, caCert, caKey); err != nil {
+		return err
+	}
+
+	tokenPath := filepath.Join(dir, TokenFile)
+	if _, err := os.Stat(tokenPath); errors.Is(err, os.ErrNotExist) {
+		token := make([]byte, 32)
+		if _, err := rand.Read(token); err != nil {
+			return err
+		}
+		if err := os.WriteFile(tokenPath, []byte(hex.EncodeToString(token)+"\n"), 0600); err != nil {
+			return fmt.Errorf("не удалось сохранить токен: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadOrCreateCA загружает CA из dir или создает новый. Если найден только
+// сертификат или только ключ CA, новый CA создается лишь при force: иначе
+// перестали бы проходить проверку все выданные им сертификаты.
+func loadOrCreateCA(dir string, force bool) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPath, keyPath := filepath.Join(dir, CAFile), filepath.Join(dir, CAKeyFile)
+
+	certPEM, certErr := os.ReadFile(certPath)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	if certErr == nil && keyErr == nil {
+		return parseCA(certPEM, keyPEM)
+	}
+	if (certErr == nil || keyErr == nil) && !force {
+		return nil, nil, fmt.Errorf("%w: в %s найден только один из файлов %s и %s", ErrCertificatesExist, dir, CAFile, CAKeyFile)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Forge Local CA", Organization: []string{"Forge"}},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := createCertificate(template, template, &key.PublicKey, key, caValidity)
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось создать CA: %w", err)
+	}
+	if err := writePEM(certPath, "CERTIFICATE", der, 0644); err != nil {
+		return nil, nil, err
+	}
+	if err := writeKey(keyPath, key); err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+func parseCA(certPEM, keyPEM []byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, errors.New("файлы CA повреждены: ожидался PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось разобрать сертификат CA: %w", err)
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось разобрать ключ CA: %w", err)
+	}
+	return cert, key, nil
+}
+
+func issueCertificate(dir, certName, keyName string, template, caCert *x509.Certificate, caKey *ecdsa.PrivateKey) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := createCertificate(template, caCert, &key.PublicKey, caKey, certValidity)
+	if err != nil {
+		return fmt.Errorf("не удалось выпустить сертификат %s: %w", certName, err)
+	}
+	if err := writePEM(filepath.Join(dir, certName), "CERTIFICATE", der, 0644); err != nil {
+		return err
+	}
+	return writeKey(filepath.Join(dir, keyName), key)
+}
+
+func createCertificate(template, parent *x509.Certificate, pub *ecdsa.PublicKey, signer *ecdsa.PrivateKey, validity time.Duration) ([]byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(validity)
+	return x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+}
+
+func writeKey(path string, key *ecdsa.PrivateKey) error {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return writePEM(path, "EC PRIVATE KEY", der, 0600)
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	content := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, content, perm); err != nil {
+		return fmt.Errorf("не удалось сохранить %s: %w", path, err)
+	}
+	return nil
+}
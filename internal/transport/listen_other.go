@@ -0,0 +1,10 @@
+//go:build !unix
+
+package transport
+
+import "net"
+
+// На платформах без umask права на сокет выставляются только после создания
+func listenUnix(address string) (net.Listener, error) {
+	return net.Listen("unix", address)
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package transport
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenUnix создает сокет с umask 0077: файл сразу получает права 0600, и
+// другой пользователь не успеет подключиться до chmod
+func listenUnix(address string) (net.Listener, error) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+	return net.Listen("unix", address)
+}
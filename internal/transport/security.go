@@ -0,0 +1,223 @@
+package transport
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials/local"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ServerSecurity — настройки TLS и аутентификации демона
+type ServerSecurity struct {
+	CertFile string // сертификат сервера; вместе с KeyFile включает TLS
+	KeyFile  string
+	// ClientCAFile — CA для проверки клиентских сертификатов. Если задан,
+	// демон принимает только клиентов с сертификатом, подписанным этим CA (mTLS).
+	ClientCAFile string
+	// TokenFile — файл с допустимыми bearer-токенами, по одному на строку
+	TokenFile string
+}
+
+// TLSEnabled сообщает, включен ли TLS на сервере
+func (s ServerSecurity) TLSEnabled() bool {
+	return s.CertFile != "" || s.KeyFile != ""
+}
+
+// ClientSecurity — настройки TLS и аутентификации CLI
+type ClientSecurity struct {
+	CAFile   string // CA для проверки сертификата демона; включает TLS
+	CertFile string // клиентский сертификат для mTLS
+	KeyFile  string
+	// ServerName переопределяет имя, по которому проверяется сертификат демона
+	ServerName string
+	Token      string
+}
+
+// TLSEnabled сообщает, подключается ли клиент по TLS
+func (c ClientSecurity) TLSEnabled() bool {
+	return c.CAFile != "" || c.CertFile != ""
+}
+
+// ServerOptions возвращает опции gRPC-сервера: TLS-креды и перехватчики,
+// проверяющие bearer-токен, если задан TokenFile.
+func ServerOptions(sec ServerSecurity) ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+
+	if sec.TLSEnabled() {
+		cert, err := tls.LoadX509KeyPair(sec.CertFile, sec.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось загрузить сертификат сервера: %w", err)
+		}
+		cfg := &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+		if sec.ClientCAFile != "" {
+			pool, err := loadCertPool(sec.ClientCAFile)
+			if err != nil {
+				return nil, err
+			}
+			cfg.ClientCAs = pool
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg)))
+	} else if sec.ClientCAFile != "" {
+		return nil, errors.New("проверка клиентских сертификатов требует TLS: укажите сертификат и ключ сервера")
+	}
+
+	if sec.TokenFile != "" {
+		tokens, err := loadTokens(sec.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		auth := tokenAuth{tokens: tokens}
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(auth.unary),
+			grpc.ChainStreamInterceptor(auth.stream),
+		)
+	}
+
+	return opts, nil
+}
+
+// DialOptions возвращает опции подключения CLI к демону по адресу addr.
+// Токен передается только по TLS или через Unix-сокет: по TCP без TLS его
+// мог бы перехватить любой, кто видит трафик.
+func DialOptions(addr string, sec ClientSecurity) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+
+	if sec.Token != "" && !sec.TLSEnabled() && !IsUnix(addr) {
+		return nil, errors.New("токен доступа не передается по TCP без TLS: укажите CA демона (--tls-ca) или подключайтесь через Unix-сокет")
+	}
+
+	if sec.TLSEnabled() {
+		cfg := &tls.Config{
+			ServerName: sec.ServerName,
+			MinVersion: tls.VersionTLS12,
+		}
+		if sec.CAFile != "" {
+			pool, err := loadCertPool(sec.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			cfg.RootCAs = pool
+		}
+		if sec.CertFile != "" || sec.KeyFile != "" {
+			cert, err := tls.LoadX509KeyPair(sec.CertFile, sec.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("не удалось загрузить клиентский сертификат: %w", err)
+			}
+			cfg.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	} else if IsUnix(addr) {
+		// Соединение через Unix-сокет не покидает машину: local-креды сообщают
+		// gRPC, что по нему можно передавать токен
+		opts = append(opts, grpc.WithTransportCredentials(local.NewCredentials()))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if sec.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerToken{token: sec.Token}))
+	}
+
+	return opts, nil
+}
+
+// bearerToken добавляет токен в заголовок authorization каждого запроса
+type bearerToken struct {
+	token string
+}
+
+func (t bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity запрещает gRPC отправлять токен по соединению без
+// TLS, кроме Unix-сокета
+func (t bearerToken) RequireTransportSecurity() bool {
+	return true
+}
+
+// tokenAuth проверяет bearer-токен в метаданных каждого вызова
+type tokenAuth struct {
+	tokens []string
+}
+
+func (a tokenAuth) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.check(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a tokenAuth) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.check(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (a tokenAuth) check(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "требуется токен доступа: укажите его флагом --token или в контексте CLI")
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return status.Error(codes.Unauthenticated, "ожидался заголовок 'authorization: Bearer <токен>'")
+	}
+	for _, valid := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(valid)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "неверный токен доступа")
+}
+
+func loadTokens(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл токенов: %w", err)
+	}
+
+	var tokens []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("файл токенов %s не содержит ни одного токена", path)
+	}
+	return tokens, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать CA-сертификат: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("файл %s не содержит PEM-сертификатов", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,137 @@
+// Package transport описывает адреса, по которым CLI подключается к демону forged.
+// Поддерживаются Unix-сокет ("unix:///путь/к/forged.sock") и TCP ("host:port" или
+// "tcp://host:port"). По умолчанию используется Unix-сокет в ~/.forge: доступ к нему
+// ограничен правами на файл, тогда как TCP-порт доступен любому локальному процессу.
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	unixScheme = "unix://"
+	tcpScheme  = "tcp://"
+
+	// SocketName — имя сокета демона в директории ~/.forge
+	SocketName = "forged.sock"
+)
+
+// DefaultAddress возвращает адрес Unix-сокета демона по умолчанию
+func DefaultAddress() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// Без домашней директории сокет создать негде: демон сообщит об ошибке при старте
+		return unixScheme + SocketName
+	}
+	return unixScheme + filepath.Join(home, ".forge", SocketName)
+}
+
+// Parse разбирает адрес демона на сеть ("unix" или "tcp") и адрес в этой сети
+func Parse(addr string) (network, address string, err error) {
+	switch {
+	case strings.HasPrefix(addr, unixScheme):
+		path := strings.TrimPrefix(addr, unixScheme)
+		if path == "" {
+			return "", "", fmt.Errorf("в адресе '%s' не указан путь к сокету", addr)
+		}
+		return "unix", path, nil
+	case strings.HasPrefix(addr, tcpScheme):
+		return "tcp", strings.TrimPrefix(addr, tcpScheme), nil
+	case strings.Contains(addr, "://"):
+		return "", "", fmt.Errorf("неподдерживаемая схема адреса '%s' (доступны unix:// и tcp://)", addr)
+	default:
+		return "tcp", addr, nil
+	}
+}
+
+// IsUnix сообщает, указывает ли адрес на Unix-сокет
+func IsUnix(addr string) bool {
+	network, _, err := Parse(addr)
+	return err == nil && network == "unix"
+}
+
+// GRPCTarget преобразует адрес демона в target для grpc.Dial
+func GRPCTarget(addr string) (string, error) {
+	network, address, err := Parse(addr)
+	if err != nil {
+		return "", err
+	}
+	if network == "unix" {
+		abs, err := filepath.Abs(address)
+		if err != nil {
+			return "", err
+		}
+		return "unix://" + abs, nil
+	}
+	return address, nil
+}
+
+// Probe проверяет, что по адресу кто-то принимает соединения
+func Probe(addr string, timeout time.Duration) bool {
+	network, address, err := Parse(addr)
+	if err != nil {
+		return false
+	}
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Listen открывает listener демона. Для Unix-сокета создает директорию, удаляет
+// оставшийся от упавшего демона файл сокета и создает сокет с правами 0600,
+// чтобы подключаться мог только владелец.
+func Listen(addr string) (net.Listener, error) {
+	network, address, err := Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	if network == "tcp" {
+		return net.Listen("tcp", address)
+	}
+
+	dir := filepath.Dir(address)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию для сокета: %w", err)
+	}
+	// MkdirAll не меняет права существующей директории, а ~/.forge могла быть
+	// создана старыми версиями с правами 0755
+	if _, def, _ := Parse(DefaultAddress()); dir == filepath.Dir(def) {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return nil, fmt.Errorf("не удалось ограничить права на директорию %s: %w", dir, err)
+		}
+	}
+
+	if info, err := os.Lstat(address); err == nil {
+		// Удаляется только сокет: ошибка в адресе не должна стоить пользователю файла
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("по адресу сокета %s находится не сокет: укажите другой адрес", address)
+		}
+		if Probe(addr, time.Second) {
+			return nil, fmt.Errorf("сокет %s уже используется другим процессом: возможно, демон уже запущен", address)
+		}
+		if err := os.Remove(address); err != nil {
+			return nil, fmt.Errorf("не удалось удалить устаревший сокет %s: %w", address, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	lis, err := listenUnix(address)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(address, 0600); err != nil {
+		lis.Close()
+		return nil, fmt.Errorf("не удалось ограничить права на сокет: %w", err)
+	}
+	return lis, nil
+}
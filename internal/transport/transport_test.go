@@ -0,0 +1,208 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	pb "github.com/waste3d/forge/internal/gen/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		addr, network, address string
+		wantErr                bool
+	}{
+		{addr: "unix:///home/me/.forge/forged.sock", network: "unix", address: "/home/me/.forge/forged.sock"},
+		{addr: "tcp://10.0.0.5:9001", network: "tcp", address: "10.0.0.5:9001"},
+		{addr: "localhost:9001", network: "tcp", address: "localhost:9001"},
+		{addr: "unix://", wantErr: true},
+		{addr: "http://localhost:9001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		network, address, err := Parse(tt.addr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) ошибка = %v, ожидалась ошибка: %v", tt.addr, err, tt.wantErr)
+			continue
+		}
+		if network != tt.network || address != tt.address {
+			t.Errorf("Parse(%q) = %s %s, ожидалось %s %s", tt.addr, network, address, tt.network, tt.address)
+		}
+	}
+}
+
+func TestDialOptionsTokenRequiresTLS(t *testing.T) {
+	if _, err := DialOptions("10.0.0.5:9001", ClientSecurity{Token: "secret"}); err == nil {
+		t.Error("токен по TCP без TLS должен быть отклонен")
+	}
+	if _, err := DialOptions("unix:///tmp/forged.sock", ClientSecurity{Token: "secret"}); err != nil {
+		t.Errorf("токен через Unix-сокет: %v", err)
+	}
+	if _, err := DialOptions("10.0.0.5:9001", ClientSecurity{}); err != nil {
+		t.Errorf("TCP без токена: %v", err)
+	}
+}
+
+// TestTokenOverUnixSocket проверяет, что токен передается через Unix-сокет без TLS
+func TestTokenOverUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix-сокеты не поддерживаются")
+	}
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, TokenFile)
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	serverOpts, err := ServerOptions(ServerSecurity{TokenFile: tokenFile})
+	if err != nil {
+		t.Fatalf("ServerOptions: %v", err)
+	}
+
+	addr := "unix://" + filepath.Join(dir, SocketName)
+	lis, err := Listen(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(serverOpts...)
+	pb.RegisterForgeServer(srv, pb.UnimplementedForgeServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	opts, err := DialOptions(addr, ClientSecurity{Token: "secret"})
+	if err != nil {
+		t.Fatalf("DialOptions: %v", err)
+	}
+	target, _ := GRPCTarget(addr)
+	conn, err := grpc.Dial(target, opts...)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	_, err = pb.NewForgeClient(conn).History(context.Background(), &pb.HistoryRequest{AppName: "shop"})
+	if code := status.Code(err); code != codes.Unimplemented {
+		t.Errorf("запрос с токеном через Unix-сокет: %v, ожидалось Unimplemented", err)
+	}
+}
+
+func TestListenUnixPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("права на файлы не поддерживаются")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Директория, созданная старой версией с правами 0755
+	dir := filepath.Join(home, ".forge")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := Listen(DefaultAddress())
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer lis.Close()
+
+	for path, want := range map[string]os.FileMode{dir: 0700, filepath.Join(dir, SocketName): 0600} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("права на %s: %v, ожидалось %v", path, info.Mode().Perm(), want)
+		}
+	}
+}
+
+func TestListenKeepsRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forge.yaml")
+	if err := os.WriteFile(path, []byte("appName: shop"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if lis, err := Listen("unix://" + path); err == nil {
+		lis.Close()
+		t.Fatal("ожидалась ошибка: по адресу сокета находится обычный файл")
+	}
+	if content, err := os.ReadFile(path); err != nil || string(content) != "appName: shop" {
+		t.Errorf("файл по адресу сокета изменен: %q, %v", content, err)
+	}
+}
+
+// TestMutualTLSWithToken поднимает сервер с mTLS и токеном на сертификатах из
+// GenerateCertificates и проверяет, что пропускаются только клиенты с верным токеном.
+func TestMutualTLSWithToken(t *testing.T) {
+	dir := t.TempDir()
+	if err := GenerateCertificates(dir, []string{"localhost", "127.0.0.1"}, false); err != nil {
+		t.Fatalf("GenerateCertificates: %v", err)
+	}
+	// Повторный запуск без force не перезаписывает выданные сертификаты
+	if err := GenerateCertificates(dir, []string{"localhost"}, false); !errors.Is(err, ErrCertificatesExist) {
+		t.Fatalf("повторный GenerateCertificates: ожидалась ErrCertificatesExist, получено %v", err)
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tokens, err := loadTokens(path(TokenFile))
+	if err != nil {
+		t.Fatalf("loadTokens: %v", err)
+	}
+
+	serverOpts, err := ServerOptions(ServerSecurity{
+		CertFile:     path(ServerFile),
+		KeyFile:      path(ServerKeyFile),
+		ClientCAFile: path(CAFile),
+		TokenFile:    path(TokenFile),
+	})
+	if err != nil {
+		t.Fatalf("ServerOptions: %v", err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(serverOpts...)
+	pb.RegisterForgeServer(srv, pb.UnimplementedForgeServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	call := func(token string) codes.Code {
+		opts, err := DialOptions(lis.Addr().String(), ClientSecurity{
+			CAFile:   path(CAFile),
+			CertFile: path(ClientFile),
+			KeyFile:  path(ClientKeyFile),
+			Token:    token,
+		})
+		if err != nil {
+			t.Fatalf("DialOptions: %v", err)
+		}
+		conn, err := grpc.Dial(lis.Addr().String(), opts...)
+		if err != nil {
+			t.Fatalf("Dial: %v", err)
+		}
+		defer conn.Close()
+
+		_, err = pb.NewForgeClient(conn).History(context.Background(), &pb.HistoryRequest{AppName: "shop"})
+		return status.Code(err)
+	}
+
+	// Unimplemented означает, что запрос прошел TLS и проверку токена и дошел до обработчика
+	if code := call(tokens[0]); code != codes.Unimplemented {
+		t.Errorf("с верным токеном получен код %s, ожидался Unimplemented", code)
+	}
+	if code := call("wrong"); code != codes.Unauthenticated {
+		t.Errorf("с неверным токеном получен код %s, ожидался Unauthenticated", code)
+	}
+	if code := call(""); code != codes.Unauthenticated {
+		t.Errorf("без токена получен код %s, ожидался Unauthenticated", code)
+	}
+}
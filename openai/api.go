@@ -21,14 +21,14 @@ type OpenRouterResponse struct {
 	} `json:"choices"`
 }
 
-func makeOpenRouterRequest(ctx context.Context, prompt string, maxTokens int) (string, error) {
-	apiKey := os.Getenv("AI_API_KEY")
+func makeChatRequest(ctx context.Context, cfg Config, prompt string, maxTokens int) (string, error) {
+	apiKey := os.Getenv(cfg.apiKeyEnv())
 	if apiKey == "" || apiKey == "YOUR_OPENROUTER_API_KEY" {
-		return "", fmt.Errorf("не установлен API ключ. Установите переменную окружения AI_API_KEY")
+		return "", fmt.Errorf("не установлен API ключ. Установите переменную окружения %s", cfg.apiKeyEnv())
 	}
 
 	reqBody := map[string]interface{}{
-		"model": "openai/gpt-oss-20b:free",
+		"model": cfg.Model,
 		"messages": []map[string]string{
 			{"role": "user", "content": prompt},
 		},
@@ -40,7 +40,7 @@ func makeOpenRouterRequest(ctx context.Context, prompt string, maxTokens int) (s
 		return "", fmt.Errorf("ошибка сериализации запроса: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.endpoint(), bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("ошибка создания запроса: %w", err)
 	}
@@ -50,13 +50,13 @@ func makeOpenRouterRequest(ctx context.Context, prompt string, maxTokens int) (s
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("ошибка отправки запроса к OpenRouter API: %w", err)
+		return "", fmt.Errorf("ошибка отправки запроса к API %s: %w", cfg.Provider, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ошибка от OpenRouter API (статус %d): %s", resp.StatusCode, string(bodyBytes))
+		return "", fmt.Errorf("ошибка от API %s (статус %d): %s", cfg.Provider, resp.StatusCode, string(bodyBytes))
 	}
 
 	var result OpenRouterResponse
@@ -70,7 +70,7 @@ func makeOpenRouterRequest(ctx context.Context, prompt string, maxTokens int) (s
 	return "ИИ не вернул ответа", nil
 }
 
-func AnalyzeLogsWithAI(ctx context.Context, collectedLogs map[string][]string) (string, error) {
+func AnalyzeLogsWithAI(ctx context.Context, cfg Config, collectedLogs map[string][]string) (string, error) {
 	var logBuilder strings.Builder
 	for serviceName, logs := range collectedLogs {
 		for _, logLine := range logs {
@@ -79,9 +79,9 @@ func AnalyzeLogsWithAI(ctx context.Context, collectedLogs map[string][]string) (
 	}
 
 	prompt := prompts.LogsPrompt(logBuilder.String())
-	return makeOpenRouterRequest(ctx, prompt, 1500)
+	return makeChatRequest(ctx, cfg, prompt, 1500)
 }
 
-func AnalyzeDockerfileWithAI(ctx context.Context, prompt string) (string, error) {
-	return makeOpenRouterRequest(ctx, prompt, 1500)
+func AnalyzeDockerfileWithAI(ctx context.Context, cfg Config, prompt string) (string, error) {
+	return makeChatRequest(ctx, cfg, prompt, 1500)
 }
@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Поддерживаемые провайдеры ИИ. Оба используют формат OpenAI Chat Completions.
+const (
+	ProviderOpenRouter = "openrouter"
+	ProviderOpenAI     = "openai"
+	// ProviderOffline анализирует логи локальными правилами, без сети и API-ключа
+	ProviderOffline = "offline"
+)
+
+// DefaultAPIKeyEnv — переменная окружения с API-ключом по умолчанию
+const DefaultAPIKeyEnv = "AI_API_KEY"
+
+// Config — настройки провайдера ИИ для анализа логов и Dockerfile
+type Config struct {
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+	// Endpoint — адрес Chat Completions API. Пустое значение — адрес провайдера.
+	Endpoint string `yaml:"endpoint"`
+	// APIKeyEnv — переменная окружения, из которой CLI берет API-ключ. Сам ключ в конфигурации не хранится.
+	APIKeyEnv string `yaml:"apiKeyEnv"`
+}
+
+// DefaultConfig возвращает настройки по умолчанию: бесплатная модель OpenRouter
+func DefaultConfig() Config {
+	return Config{
+		Provider:  ProviderOpenRouter,
+		Model:     "openai/gpt-oss-20b:free",
+		APIKeyEnv: DefaultAPIKeyEnv,
+	}
+}
+
+// Validate проверяет, что провайдер поддерживается
+func (c Config) Validate() error {
+	switch c.Provider {
+	case ProviderOpenRouter, ProviderOpenAI:
+	case ProviderOffline:
+		return nil
+	default:
+		return fmt.Errorf("неизвестный провайдер ИИ '%s' (доступны: %s, %s, %s)", c.Provider, ProviderOpenRouter, ProviderOpenAI, ProviderOffline)
+	}
+	if strings.TrimSpace(c.Model) == "" {
+		return fmt.Errorf("не указана модель ИИ")
+	}
+	if c.Endpoint != "" {
+		return validateEndpoint(c.Endpoint)
+	}
+	return nil
+}
+
+// validateEndpoint проверяет адрес API: вместе с запросом уходит API-ключ,
+// поэтому без TLS допускаются только адреса локальной машины
+func validateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("некорректный адрес API ИИ '%s': ожидается https://", endpoint)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+		return fmt.Errorf("адрес API ИИ '%s' должен использовать https://: http:// допускается только для localhost", endpoint)
+	default:
+		return fmt.Errorf("некорректный адрес API ИИ '%s': ожидается https://", endpoint)
+	}
+}
+
+func (c Config) endpoint() string {
+	if c.Endpoint != "" {
+		return c.Endpoint
+	}
+	if c.Provider == ProviderOpenAI {
+		return "https://api.openai.com/v1/chat/completions"
+	}
+	return "https://openrouter.ai/api/v1/chat/completions"
+}
+
+func (c Config) apiKeyEnv() string {
+	if c.APIKeyEnv != "" {
+		return c.APIKeyEnv
+	}
+	return DefaultAPIKeyEnv
+}
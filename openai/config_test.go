@@ -0,0 +1,27 @@
+package ai
+
+import "testing"
+
+func TestConfigValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{endpoint: ""},
+		{endpoint: "https://llm.example.com/v1/chat/completions"},
+		{endpoint: "http://localhost:11434/v1/chat/completions"},
+		{endpoint: "http://127.0.0.1:8080/v1/chat/completions"},
+		{endpoint: "http://[::1]:8080/v1/chat/completions"},
+		{endpoint: "http://llm.example.com/v1/chat/completions", wantErr: true},
+		{endpoint: "ftp://llm.example.com", wantErr: true},
+		{endpoint: "llm.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.Endpoint = tt.endpoint
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate() для %q: ошибка %v, ожидалась ошибка: %v", tt.endpoint, err, tt.wantErr)
+		}
+	}
+}
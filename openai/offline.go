@@ -0,0 +1,336 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ContainerState — состояние контейнера сервиса, которое дополняет логи при анализе
+type ContainerState struct {
+	ServiceName  string
+	Running      bool
+	ExitCode     int
+	OOMKilled    bool
+	RestartCount int
+}
+
+// maxEvidence — сколько строк логов приводится в отчете для одной находки
+const maxEvidence = 3
+
+// stackLines — сколько строк трассировки стека приводится после паники
+const stackLines = 8
+
+// logRule — известная причина сбоя, которую можно распознать по строкам логов
+type logRule struct {
+	title    string
+	patterns []*regexp.Regexp
+	// cause объясняет находку. match — подгруппы первого совпадения.
+	cause   func(service string, match []string) string
+	actions []string
+	// stack — после совпадения в отчет попадают следующие строки: трассировка стека
+	stack bool
+}
+
+// oomRule — индекс правила нехватки памяти в logRules: этот же вывод следует из кода выхода 137
+const oomRule = 0
+
+// logRules упорядочены по тому, насколько вероятно, что находка — первопричина,
+// а не следствие: отказ в подключении обычно вызван падением зависимости.
+var logRules = []logRule{
+	{
+		title: "Нехватка памяти",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)out of memory|OOMKilled|cannot allocate memory|heap out of memory|java\.lang\.OutOfMemoryError`),
+		},
+		cause: func(service string, _ []string) string {
+			return fmt.Sprintf("Сервису `%s` не хватает памяти: процесс не смог выделить память или был остановлен ядром.", service)
+		},
+		actions: []string{
+			"Увеличьте `resources.memory` сервиса в forge.yaml или уберите ограничение, чтобы проверить, что дело в нем.",
+			"Проверьте, не растет ли потребление памяти со временем: утечка проявится повторным падением через `forge events`.",
+		},
+	},
+	{
+		title: "Ошибка аутентификации PostgreSQL",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)password authentication failed for user "?([\w.-]+)"?`),
+			regexp.MustCompile(`(?i)role "([^"]+)" does not exist`),
+			regexp.MustCompile(`(?i)no pg_hba\.conf entry for host`),
+		},
+		cause: func(service string, match []string) string {
+			if len(match) > 1 && match[1] != "" {
+				return fmt.Sprintf("PostgreSQL отклоняет подключение пользователя `%s` (сервис `%s`): учетные данные приложения не совпадают с настройками базы.", match[1], service)
+			}
+			return fmt.Sprintf("PostgreSQL отклоняет подключение (сервис `%s`): учетные данные или правила доступа не совпадают с настройками базы.", service)
+		},
+		actions: []string{
+			"Сравните `POSTGRES_USER`/`POSTGRES_PASSWORD` базы в forge.yaml с DSN или переменными окружения сервиса.",
+			"Помните, что пароль задается только при первой инициализации тома: после его смены выполните `forge down` с удалением тома или смените пароль через `forge exec ... psql`.",
+		},
+	},
+	{
+		title: "Порт уже занят",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)listen tcp\d? ([\w.\-\[\]:]*:\d+): bind: address already in use`),
+			regexp.MustCompile(`(?i)address already in use|EADDRINUSE|port is already allocated`),
+		},
+		cause: func(service string, match []string) string {
+			if len(match) > 1 && match[1] != "" {
+				return fmt.Sprintf("Сервис `%s` не может занять адрес `%s`: его уже использует другой процесс.", service, match[1])
+			}
+			return fmt.Sprintf("Сервис `%s` не может занять порт: его уже использует другой процесс.", service)
+		},
+		actions: []string{
+			"Найдите процесс, занимающий порт на хосте (`lsof -i :ПОРТ` или `ss -ltnp`), и остановите его.",
+			"Или укажите другой порт в forge.yaml, либо `port: auto`, чтобы Forge выбрал свободный.",
+		},
+	},
+	{
+		title: "Ошибка миграции базы данных",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)migration.*(?:failed|error)|(?:failed|error).*migration|dirty database version`),
+			regexp.MustCompile(`(?i)relation "([^"]+)" (?:does not exist|already exists)|duplicate column|column "[^"]+" (?:does not exist|of relation)`),
+		},
+		cause: func(service string, _ []string) string {
+			return fmt.Sprintf("Миграции сервиса `%s` не применились: схема базы не соответствует той, которую ожидает код.", service)
+		},
+		actions: []string{
+			"Проверьте последнюю миграцию и состояние таблицы версий миграций; при `dirty database version` исправьте схему и сбросьте флаг.",
+			"Если база локальная и данные не нужны, пересоздайте ее том и примените миграции заново.",
+		},
+	},
+	{
+		title: "Паника или необработанное исключение",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^panic: (.*)`),
+			regexp.MustCompile(`^fatal error: (.*)`),
+			regexp.MustCompile(`^Traceback \(most recent call last\)`),
+			regexp.MustCompile(`^Exception in thread|^Unhandled(?:PromiseRejection| exception)`),
+		},
+		cause: func(service string, match []string) string {
+			if len(match) > 1 && match[1] != "" {
+				return fmt.Sprintf("Процесс сервиса `%s` аварийно завершился: `%s`.", service, match[1])
+			}
+			return fmt.Sprintf("Процесс сервиса `%s` аварийно завершился из-за необработанной ошибки.", service)
+		},
+		actions: []string{
+			"Найдите в трассировке стека первый кадр из кода приложения: это место ошибки.",
+			"Воспроизведите сбой локально с теми же переменными окружения (`forge exec ... env`).",
+		},
+		stack: true,
+	},
+	{
+		title: "Зависимость недоступна",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)dial tcp ([\w.\-\[\]:]+):\s*connect: connection refused`),
+			regexp.MustCompile(`(?i)connect ECONNREFUSED ([\w.\-\[\]:]+)`),
+			regexp.MustCompile(`(?i)could not connect to server|connection refused`),
+		},
+		cause: func(service string, match []string) string {
+			if len(match) > 1 && match[1] != "" {
+				return fmt.Sprintf("Сервис `%s` не может подключиться к `%s`: зависимость не запущена, еще не готова или слушает другой адрес.", service, match[1])
+			}
+			return fmt.Sprintf("Сервис `%s` не может подключиться к зависимости: она не запущена, еще не готова или слушает другой адрес.", service)
+		},
+		actions: []string{
+			"Проверьте состояние зависимости командой `forge ps` и ее логи: отказ в подключении часто лишь следствие ее падения.",
+			"Укажите зависимость в `dependsOn`, чтобы сервис запускался после ее проверки готовности.",
+			"Внутри сети приложения обращайтесь к зависимости по имени узла и внутреннему порту, а не через localhost.",
+		},
+	},
+}
+
+// finding — распознанная в логах проблема
+type finding struct {
+	rule     int // индекс в logRules, -1 — код выхода контейнера
+	title    string
+	service  string
+	cause    string
+	evidence []string
+	actions  []string
+}
+
+// AnalyzeLogsOffline ищет в логах известные причины сбоев по правилам, без
+// обращения к ИИ, и возвращает отчет в том же формате Markdown, что и AnalyzeLogsWithAI
+func AnalyzeLogsOffline(collectedLogs map[string][]string, containers []ContainerState) string {
+	services := make([]string, 0, len(collectedLogs))
+	for service := range collectedLogs {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	var findings []finding
+	for _, service := range services {
+		findings = append(findings, matchRules(service, collectedLogs[service])...)
+	}
+	findings = append(findings, exitCodeFindings(containers, findings)...)
+
+	// Сначала вероятные первопричины, коды выхода — после них
+	sort.SliceStable(findings, func(i, j int) bool {
+		return rank(findings[i]) < rank(findings[j])
+	})
+	return offlineReport(findings)
+}
+
+func rank(f finding) int {
+	if f.rule < 0 {
+		return len(logRules)
+	}
+	return f.rule
+}
+
+// matchRules применяет правила к строкам одного сервиса. Каждое правило дает не
+// больше одной находки на сервис, совпавшие строки становятся ее подтверждением.
+func matchRules(service string, lines []string) []finding {
+	var findings []finding
+	for i, rule := range logRules {
+		var f *finding
+		for n := 0; n < len(lines); n++ {
+			line := lines[n]
+			match := firstMatch(rule.patterns, stripTimestamp(strings.TrimSpace(line)))
+			if match == nil {
+				continue
+			}
+			if f == nil {
+				f = &finding{rule: i, title: rule.title, service: service, cause: rule.cause(service, match), actions: rule.actions}
+			}
+			if len(f.evidence) >= maxEvidence {
+				break
+			}
+			if rule.stack {
+				end := min(n+1+stackLines, len(lines))
+				f.evidence = append(f.evidence, strings.Join(lines[n:end], "\n"))
+				n = end - 1
+				continue
+			}
+			f.evidence = append(f.evidence, line)
+		}
+		if f != nil {
+			findings = append(findings, *f)
+		}
+	}
+	return findings
+}
+
+// stripTimestamp убирает время RFC3339 в начале строки, которое добавляет
+// 'forge logs --timestamps': правила с '^' проверяют начало сообщения
+func stripTimestamp(line string) string {
+	ts, message, ok := strings.Cut(line, " ")
+	if !ok {
+		return line
+	}
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+		return line
+	}
+	return message
+}
+
+func firstMatch(patterns []*regexp.Regexp, line string) []string {
+	for _, re := range patterns {
+		if match := re.FindStringSubmatch(line); match != nil {
+			return match
+		}
+	}
+	return nil
+}
+
+// exitCodeFindings объясняет ненулевые коды выхода остановленных контейнеров.
+// Нехватка памяти, уже найденная в логах сервиса, повторно не сообщается. Код
+// 137 без OOMKilled означает лишь SIGKILL: такая находка идет после правил логов.
+func exitCodeFindings(containers []ContainerState, found []finding) []finding {
+	oomInLogs := make(map[string]bool)
+	for _, f := range found {
+		if f.rule == oomRule {
+			oomInLogs[f.service] = true
+		}
+	}
+
+	var findings []finding
+	for _, c := range containers {
+		if c.Running || c.ExitCode == 0 && !c.OOMKilled {
+			continue
+		}
+		f := finding{rule: -1, service: c.ServiceName}
+		status := fmt.Sprintf("контейнер завершился с кодом %d", c.ExitCode)
+		if c.RestartCount > 0 {
+			status += fmt.Sprintf(", перезапусков: %d", c.RestartCount)
+		}
+		f.evidence = []string{status}
+
+		switch {
+		case c.OOMKilled:
+			if oomInLogs[c.ServiceName] {
+				continue
+			}
+			f.rule = oomRule
+			f.title = logRules[oomRule].title
+			f.cause = fmt.Sprintf("Docker сообщает, что контейнер `%s` остановлен из-за нехватки памяти (OOMKilled).", c.ServiceName)
+			f.actions = logRules[oomRule].actions
+		case c.ExitCode == 137:
+			f.title = "Контейнер остановлен SIGKILL"
+			f.cause = fmt.Sprintf("Контейнер `%s` был принудительно остановлен (SIGKILL): его завершили извне, например `docker kill`, или не дождались остановки по SIGTERM. Docker не сообщает о нехватке памяти.", c.ServiceName)
+			f.actions = []string{"Если остановку никто не запрашивал, проверьте `forge events` и системные журналы хоста: процесс мог завершить OOM killer хоста вне лимитов контейнера."}
+		case c.ExitCode == 139:
+			f.title = "Ошибка сегментации"
+			f.cause = fmt.Sprintf("Процесс контейнера `%s` обратился к недопустимому адресу памяти (SIGSEGV).", c.ServiceName)
+			f.actions = []string{"Проверьте нативные зависимости и совместимость образа с архитектурой хоста."}
+		case c.ExitCode == 126 || c.ExitCode == 127:
+			f.title = "Команда контейнера не запускается"
+			f.cause = fmt.Sprintf("Команда контейнера `%s` не найдена или не является исполняемой.", c.ServiceName)
+			f.actions = []string{"Проверьте `command`/`entrypoint` в forge.yaml и наличие бинарника в образе, включая права на выполнение."}
+		case c.ExitCode == 143:
+			f.title = "Контейнер остановлен сигналом"
+			f.cause = fmt.Sprintf("Контейнер `%s` завершился по SIGTERM: его остановили извне.", c.ServiceName)
+			f.actions = []string{"Если остановку никто не запрашивал, проверьте `forge events` и системные журналы хоста."}
+		default:
+			f.title = "Приложение завершилось с ошибкой"
+			f.cause = fmt.Sprintf("Процесс контейнера `%s` завершился с кодом %d: приложение сообщило об ошибке при запуске или работе.", c.ServiceName, c.ExitCode)
+			f.actions = []string{fmt.Sprintf("Посмотрите последние строки логов сервиса: `forge logs <app> %s --tail 50`.", c.ServiceName)}
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func offlineReport(findings []finding) string {
+	var b strings.Builder
+
+	b.WriteString("### 💡 Общий вывод\n\n")
+	if len(findings) == 0 {
+		b.WriteString("Известных признаков сбоя в логах не найдено. Локальный анализ распознает только типичные ошибки; для разбора остальных используйте анализ с помощью ИИ.\n\n")
+		b.WriteString("### 🔍 Анализ первопричины\n\nНет данных.\n\n")
+		b.WriteString("### 🚀 Рекомендуемые действия\n\n1. Проверьте состояние сервисов командой `forge ps`.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Найдено проблем: %d. Наиболее вероятная первопричина — **%s** в сервисе `%s`.\n\n", len(findings), findings[0].title, findings[0].service)
+
+	b.WriteString("### 🔍 Анализ первопричины\n\n")
+	for _, f := range findings {
+		fmt.Fprintf(&b, "**%s** (`%s`)\n\n%s\n\n", f.title, f.service, f.cause)
+		if len(f.evidence) > 0 {
+			b.WriteString("```\n")
+			for _, line := range f.evidence {
+				b.WriteString(line + "\n")
+			}
+			b.WriteString("```\n\n")
+		}
+	}
+
+	b.WriteString("### 🚀 Рекомендуемые действия\n\n")
+	seen := make(map[string]bool)
+	n := 0
+	for _, f := range findings {
+		for _, action := range f.actions {
+			if seen[action] {
+				continue
+			}
+			seen[action] = true
+			n++
+			fmt.Fprintf(&b, "%d. %s\n", n, action)
+		}
+	}
+	return b.String()
+}
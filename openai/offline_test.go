@@ -0,0 +1,87 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeLogsOffline(t *testing.T) {
+	logs := map[string][]string{
+		"api": {
+			"starting server",
+			"dial tcp db:5432: connect: connection refused",
+			"panic: runtime error: invalid memory address or nil pointer dereference",
+			"goroutine 1 [running]:",
+			"main.main()",
+			"\t/app/main.go:42 +0x1d",
+		},
+		"db": {
+			`FATAL:  password authentication failed for user "shop"`,
+		},
+		"web": {
+			"Error: listen EADDRINUSE: address already in use :::3000",
+		},
+		"migrator": {
+			"error: Dirty database version 3. Fix and force version.",
+		},
+	}
+	containers := []ContainerState{
+		{ServiceName: "api", ExitCode: 2},
+		{ServiceName: "worker", ExitCode: 137, OOMKilled: true, RestartCount: 3},
+		{ServiceName: "web", Running: true},
+	}
+
+	report := AnalyzeLogsOffline(logs, containers)
+
+	for _, want := range []string{
+		"### 💡 Общий вывод",
+		"### 🔍 Анализ первопричины",
+		"### 🚀 Рекомендуемые действия",
+		"**Нехватка памяти** (`worker`)",
+		"перезапусков: 3",
+		"пользователя `shop`",
+		"**Порт уже занят** (`web`)",
+		"**Ошибка миграции базы данных** (`migrator`)",
+		"`runtime error: invalid memory address or nil pointer dereference`",
+		"/app/main.go:42",
+		"не может подключиться к `db:5432`",
+		"завершился с кодом 2",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("в отчете нет %q:\n%s", want, report)
+		}
+	}
+
+	// Первопричина выбирается по приоритету правил: нехватка памяти важнее отказа в подключении
+	if !strings.Contains(report, "первопричина — **Нехватка памяти** в сервисе `worker`") {
+		t.Errorf("неверная первопричина:\n%s", report)
+	}
+	if strings.Index(report, "Паника") > strings.Index(report, "Зависимость недоступна") {
+		t.Errorf("паника должна быть выше отказа в подключении, который обычно ее следствие:\n%s", report)
+	}
+}
+
+func TestAnalyzeLogsOfflineNoFindings(t *testing.T) {
+	report := AnalyzeLogsOffline(map[string][]string{"api": {"listening on :8080"}}, []ContainerState{{ServiceName: "api", Running: true}})
+	if !strings.Contains(report, "Известных признаков сбоя в логах не найдено") || !strings.Contains(report, "### 🚀 Рекомендуемые действия") {
+		t.Errorf("неожиданный отчет без находок:\n%s", report)
+	}
+}
+
+func TestAnalyzeLogsOfflineSIGKILL(t *testing.T) {
+	logs := map[string][]string{
+		// С --timestamps перед сообщением стоит время
+		"api": {"2024-05-01T10:00:00.123456789Z panic: boom", "goroutine 1 [running]:"},
+	}
+	report := AnalyzeLogsOffline(logs, []ContainerState{{ServiceName: "worker", ExitCode: 137}})
+
+	if strings.Contains(report, "Нехватка памяти") {
+		t.Errorf("код 137 без OOMKilled не означает нехватку памяти:\n%s", report)
+	}
+	if !strings.Contains(report, "**Контейнер остановлен SIGKILL** (`worker`)") {
+		t.Errorf("в отчете нет находки SIGKILL:\n%s", report)
+	}
+	if !strings.Contains(report, "первопричина — **Паника") {
+		t.Errorf("паника в логах должна быть важнее SIGKILL:\n%s", report)
+	}
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Command — команда контейнера. Принимает как список аргументов,
+// так и строку, которая разбивается на аргументы по правилам shell.
+type Command []string
+
+func (c *Command) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		args, err := splitCommand(value.Value)
+		if err != nil {
+			return err
+		}
+		*c = args
+		return nil
+	}
+
+	var args []string
+	if err := value.Decode(&args); err != nil {
+		return fmt.Errorf("команда должна быть строкой или списком строк: %w", err)
+	}
+	*c = args
+	return nil
+}
+
+// splitCommand разбивает строку на аргументы с учетом кавычек и экранирования
+func splitCommand(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg, escaped := false, false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("незакрытая кавычка в команде '%s'", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -2,10 +2,12 @@ package parser
 
 // Config — это корневая структура, представляющая весь файл forge.yaml
 type Config struct {
-	Version   int             `yaml:"version"`
-	AppName   string          `yaml:"appName"`
-	Services  []ServiceConfig `yaml:"services"`
-	Databases []DBConfig      `yaml:"databases"`
+	Version         int             `yaml:"version"`
+	AppName         string          `yaml:"appName"`
+	BindAddress     string          `yaml:"bindAddress,omitempty"`     // адрес хоста для публикации портов, по умолчанию 127.0.0.1
+	AllowPublicBind bool            `yaml:"allowPublicBind,omitempty"` // явное разрешение публиковать порты за пределами loopback
+	Services        []ServiceConfig `yaml:"services"`
+	Databases       []DBConfig      `yaml:"databases"`
 }
 
 // ServiceConfig описывает один сервис, например, бэкенд или фронтенд
@@ -14,12 +16,15 @@ type ServiceConfig struct {
 	Type               string   `yaml:"type"` // example: go, node, python, etc..
 	Repo               string   `yaml:"repo,omitempty"`
 	Path               string   `yaml:"path,omitempty"`
-	Port               int      `yaml:"port"`
+	Port               HostPort `yaml:"port"` // число или "auto"
 	InternalPort       int      `yaml:"internalPort,omitempty"`
+	Ports              []string `yaml:"ports,omitempty"` // в стиле compose: "127.0.0.1:8080:80/tcp", "5353:53/udp"
 	Image              string   `yaml:"image,omitempty"`
 	HealthCheckTimeout int      `yaml:"healthCheckTimeout,omitempty"`
 	DependsOn          []string `yaml:"dependsOn,omitempty"`
 	Env                []string `yaml:"env,omitempty"`
+
+	ContainerOptions `yaml:",inline"`
 }
 
 // DBConfig описывает одну базу данных
@@ -27,9 +32,37 @@ type DBConfig struct {
 	Name               string   `yaml:"name"`
 	Type               string   `yaml:"type"` // example: "postgres", "redis", "mongo", etc..
 	Version            string   `yaml:"version"`
-	Port               int      `yaml:"port"`
+	Port               HostPort `yaml:"port"` // число или "auto"
 	InternalPort       int      `yaml:"internalPort,omitempty"`
+	Ports              []string `yaml:"ports,omitempty"`
 	HealthCheckTimeout int      `yaml:"healthCheckTimeout,omitempty"`
 	DependsOn          []string `yaml:"dependsOn,omitempty"`
 	Env                []string `yaml:"env,omitempty"`
+
+	ContainerOptions `yaml:",inline"`
+}
+
+// ContainerOptions — общие для сервисов и баз данных параметры контейнера,
+// которые передаются в Docker без изменений
+type ContainerOptions struct {
+	Command    Command           `yaml:"command,omitempty"`
+	Entrypoint Command           `yaml:"entrypoint,omitempty"`
+	WorkingDir string            `yaml:"workingDir,omitempty"`
+	User       string            `yaml:"user,omitempty"`
+	Labels     map[string]string `yaml:"labels,omitempty"`
+	Hostname   string            `yaml:"hostname,omitempty"`
+	ExtraHosts []string          `yaml:"extraHosts,omitempty"` // "host:ip"
+	CapAdd     []string          `yaml:"capAdd,omitempty"`
+	Tmpfs      []string          `yaml:"tmpfs,omitempty"`   // "/путь[:опции]"
+	ShmSize    string            `yaml:"shmSize,omitempty"` // например, "256m"
+	Resources  Resources         `yaml:"resources,omitempty"`
+	Restart    string            `yaml:"restart,omitempty"`   // no | on-failure[:N] | always | unless-stopped; паузы между перезапусками задает Docker
+	LogFormat  string            `yaml:"logFormat,omitempty"` // json | logfmt | text (по умолчанию)
+}
+
+// Resources описывает ограничения ресурсов контейнера
+type Resources struct {
+	CPUs   float64 `yaml:"cpus,omitempty"`   // доля процессорного времени, например 1.5
+	Memory string  `yaml:"memory,omitempty"` // например, "512m" или "2g"
+	Pids   int64   `yaml:"pids,omitempty"`   // максимальное число процессов
 }
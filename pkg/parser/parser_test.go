@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -50,6 +52,60 @@ services:
 				}
 			},
 		},
+		{
+			name: "Автоматический порт хоста",
+			yamlContent: []byte(`
+version: 1
+appName: my-test-app
+services:
+  - name: backend
+    image: nginx
+    port: auto
+    internalPort: 80
+`),
+			expectErr: false,
+			validate: func(t *testing.T, c *Config) {
+				if c.Services[0].Port != AutoPort {
+					t.Errorf("Ожидался порт 'auto', получено %d", c.Services[0].Port)
+				}
+			},
+		},
+		{
+			name: "Параметры контейнера",
+			yamlContent: []byte(`
+version: 1
+appName: my-test-app
+services:
+  - name: worker
+    image: my-app:latest
+    command: ./app worker --queue "high priority"
+    entrypoint: ["/bin/sh", "-c"]
+    workingDir: /srv
+    user: "1000:1000"
+    labels:
+      team: backend
+    capAdd: [NET_ADMIN]
+    tmpfs: ["/tmp:size=64m"]
+    shmSize: 256m
+`),
+			expectErr: false,
+			validate: func(t *testing.T, c *Config) {
+				s := c.Services[0]
+				expectedCmd := Command{"./app", "worker", "--queue", "high priority"}
+				if !reflect.DeepEqual(s.Command, expectedCmd) {
+					t.Errorf("Ожидалась команда %q, получено %q", expectedCmd, s.Command)
+				}
+				if len(s.Entrypoint) != 2 || s.Entrypoint[1] != "-c" {
+					t.Errorf("Неверный entrypoint: %q", s.Entrypoint)
+				}
+				if s.WorkingDir != "/srv" || s.User != "1000:1000" || s.ShmSize != "256m" {
+					t.Errorf("Неверные параметры контейнера: %+v", s.ContainerOptions)
+				}
+				if s.Labels["team"] != "backend" {
+					t.Errorf("Ожидалась метка team=backend, получено %v", s.Labels)
+				}
+			},
+		},
 		{
 			name: "Ошибка при некорректном YAML",
 			yamlContent: []byte(`
@@ -87,3 +143,124 @@ appName: my-app
 		})
 	}
 }
+
+func TestParsePortMapping(t *testing.T) {
+	testCases := []struct {
+		name      string
+		spec      string
+		expected  PortMapping
+		expectErr bool
+	}{
+		{
+			name:     "Только порт контейнера",
+			spec:     "80",
+			expected: PortMapping{ContainerPort: 80, ContainerEnd: 80, Protocol: "tcp"},
+		},
+		{
+			name:     "Хост и контейнер",
+			spec:     "8080:80",
+			expected: PortMapping{HostPort: 8080, HostPortEnd: 8080, ContainerPort: 80, ContainerEnd: 80, Protocol: "tcp"},
+		},
+		{
+			name:     "IP хоста и протокол",
+			spec:     "127.0.0.1:8080:80/tcp",
+			expected: PortMapping{HostIP: "127.0.0.1", HostPort: 8080, HostPortEnd: 8080, ContainerPort: 80, ContainerEnd: 80, Protocol: "tcp"},
+		},
+		{
+			name:     "UDP",
+			spec:     "5353:53/udp",
+			expected: PortMapping{HostPort: 5353, HostPortEnd: 5353, ContainerPort: 53, ContainerEnd: 53, Protocol: "udp"},
+		},
+		{
+			name:     "Диапазон",
+			spec:     "9000-9002:8000-8002",
+			expected: PortMapping{HostPort: 9000, HostPortEnd: 9002, ContainerPort: 8000, ContainerEnd: 8002, Protocol: "tcp"},
+		},
+		{
+			name:     "IPv6",
+			spec:     "[::1]:8080:80",
+			expected: PortMapping{HostIP: "::1", HostPort: 8080, HostPortEnd: 8080, ContainerPort: 80, ContainerEnd: 80, Protocol: "tcp"},
+		},
+		{
+			name:     "Автоматический порт хоста",
+			spec:     "127.0.0.1:auto:53/udp",
+			expected: PortMapping{HostIP: "127.0.0.1", ContainerPort: 53, ContainerEnd: 53, Protocol: "udp", Auto: true},
+		},
+		{name: "Auto с диапазоном", spec: "auto:8000-8002", expectErr: true},
+		{name: "Диапазоны разной длины", spec: "9000-9001:8000-8002", expectErr: true},
+		{name: "Неизвестный протокол", spec: "8080:80/sctp", expectErr: true},
+		{name: "Некорректный IP", spec: "localhost:8080:80", expectErr: true},
+		{name: "Порт вне диапазона", spec: "70000:80", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := ParsePortMapping(tc.spec)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Ожидалась ошибка для '%s', но получено nil", tc.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Неожиданная ошибка: %v", err)
+			}
+			if m != tc.expected {
+				t.Errorf("Ожидалось %+v, получено %+v", tc.expected, m)
+			}
+		})
+	}
+}
+
+func TestRedactEnv(t *testing.T) {
+	content := []byte(`
+appName: shop
+services:
+  - name: api
+    image: shop/api
+    env:
+      - DATABASE_URL=postgres://shop:s3cret@db:5432/shop
+      - "API_TOKEN=abc=def"
+      - HOME
+databases:
+  - name: db
+    type: postgres
+    env: ["POSTGRES_PASSWORD=s3cret"]
+`)
+
+	redacted, err := RedactEnv(content)
+	if err != nil {
+		t.Fatalf("RedactEnv: %v", err)
+	}
+	if strings.Contains(string(redacted), "s3cret") || strings.Contains(string(redacted), "abc") {
+		t.Fatalf("значения переменных не скрыты:\n%s", redacted)
+	}
+
+	config, err := Parse(redacted)
+	if err != nil {
+		t.Fatalf("скрытая конфигурация не разбирается: %v\n%s", err, redacted)
+	}
+	wantAPI := []string{"DATABASE_URL=" + RedactedValue, "API_TOKEN=" + RedactedValue, "HOME"}
+	if !reflect.DeepEqual(config.Services[0].Env, wantAPI) {
+		t.Errorf("env сервиса: %v, ожидалось %v", config.Services[0].Env, wantAPI)
+	}
+	if want := []string{"POSTGRES_PASSWORD=" + RedactedValue}; !reflect.DeepEqual(config.Databases[0].Env, want) {
+		t.Errorf("env базы: %v, ожидалось %v", config.Databases[0].Env, want)
+	}
+	if config.AppName != "shop" || config.Services[0].Image != "shop/api" {
+		t.Errorf("остальная конфигурация изменилась: %+v", config)
+	}
+}
+
+func TestAutoPortRequiresInternalPort(t *testing.T) {
+	s := ServiceConfig{Name: "backend", Port: AutoPort}
+	if _, err := s.PortMappings(); err == nil {
+		t.Error("ожидалась ошибка для 'port: auto' без 'internalPort'")
+	}
+
+	s.InternalPort = 80
+	ports, err := s.PortMappings()
+	if err != nil || len(ports) != 1 || !ports[0].Auto {
+		t.Errorf("PortMappings() = %+v, %v", ports, err)
+	}
+}
@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AutoPort — значение поля 'port', при котором демон сам выделяет свободный порт хоста
+const AutoPort HostPort = -1
+
+// HostPort — порт хоста в поле 'port'. Помимо числа принимает значение "auto".
+type HostPort int
+
+func (p *HostPort) UnmarshalYAML(value *yaml.Node) error {
+	if value.Value == "auto" {
+		*p = AutoPort
+		return nil
+	}
+	var n int
+	if err := value.Decode(&n); err != nil {
+		return fmt.Errorf("поле 'port' должно быть числом или 'auto', получено '%s'", value.Value)
+	}
+	*p = HostPort(n)
+	return nil
+}
+
+func (p HostPort) MarshalYAML() (interface{}, error) {
+	if p == AutoPort {
+		return "auto", nil
+	}
+	return int(p), nil
+}
+
+// PortMapping описывает один проброс порта (или диапазона портов) с хоста в контейнер
+type PortMapping struct {
+	HostIP        string
+	HostPort      int
+	HostPortEnd   int
+	ContainerPort int
+	ContainerEnd  int
+	Protocol      string // "tcp" или "udp"
+	Auto          bool   // порт хоста выделяется демоном при запуске
+}
+
+// ParsePortMapping разбирает строку в стиле docker compose:
+// "80", "8080:80", "127.0.0.1:8080:80/tcp", "5353:53/udp", "9000-9002:9000-9002".
+// Вместо порта хоста можно указать "auto": "auto:80", "127.0.0.1:auto:53/udp".
+func ParsePortMapping(spec string) (PortMapping, error) {
+	var m PortMapping
+
+	raw := strings.TrimSpace(spec)
+	if raw == "" {
+		return m, fmt.Errorf("пустое описание порта")
+	}
+
+	m.Protocol = "tcp"
+	if idx := strings.LastIndex(raw, "/"); idx >= 0 {
+		m.Protocol = strings.ToLower(raw[idx+1:])
+		raw = raw[:idx]
+	}
+	if m.Protocol != "tcp" && m.Protocol != "udp" {
+		return m, fmt.Errorf("порт '%s': неподдерживаемый протокол '%s'", spec, m.Protocol)
+	}
+
+	var hostIP, hostPart, containerPart string
+	if strings.HasPrefix(raw, "[") {
+		// IPv6-адрес в квадратных скобках: "[::1]:8080:80"
+		end := strings.Index(raw, "]")
+		if end < 0 || end+1 >= len(raw) || raw[end+1] != ':' {
+			return m, fmt.Errorf("порт '%s': некорректный IPv6-адрес", spec)
+		}
+		hostIP = raw[1:end]
+		rest := strings.Split(raw[end+2:], ":")
+		if len(rest) != 2 {
+			return m, fmt.Errorf("порт '%s': ожидался формат '[ip]:хост:контейнер'", spec)
+		}
+		hostPart, containerPart = rest[0], rest[1]
+	} else {
+		parts := strings.Split(raw, ":")
+		switch len(parts) {
+		case 1:
+			containerPart = parts[0]
+		case 2:
+			hostPart, containerPart = parts[0], parts[1]
+		case 3:
+			hostIP, hostPart, containerPart = parts[0], parts[1], parts[2]
+		default:
+			return m, fmt.Errorf("порт '%s': ожидался формат '[ip:][хост:]контейнер[/протокол]'", spec)
+		}
+	}
+
+	if hostIP != "" && net.ParseIP(hostIP) == nil {
+		return m, fmt.Errorf("порт '%s': некорректный IP-адрес '%s'", spec, hostIP)
+	}
+	m.HostIP = hostIP
+
+	var err error
+	m.ContainerPort, m.ContainerEnd, err = parsePortRange(containerPart)
+	if err != nil {
+		return m, fmt.Errorf("порт '%s': %w", spec, err)
+	}
+
+	if hostPart == "auto" {
+		if m.ContainerEnd != m.ContainerPort {
+			return m, fmt.Errorf("порт '%s': 'auto' нельзя использовать с диапазоном портов", spec)
+		}
+		m.Auto = true
+	} else if hostPart != "" {
+		m.HostPort, m.HostPortEnd, err = parsePortRange(hostPart)
+		if err != nil {
+			return m, fmt.Errorf("порт '%s': %w", spec, err)
+		}
+		if m.HostPortEnd-m.HostPort != m.ContainerEnd-m.ContainerPort {
+			return m, fmt.Errorf("порт '%s': диапазоны хоста и контейнера имеют разную длину", spec)
+		}
+	}
+
+	return m, nil
+}
+
+// ParsePortMappings разбирает список портов из поля 'ports'
+func ParsePortMappings(specs []string) ([]PortMapping, error) {
+	mappings := make([]PortMapping, 0, len(specs))
+	for _, spec := range specs {
+		m, err := ParsePortMapping(spec)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, nil
+}
+
+// Published сообщает, пробрасывается ли порт на хост
+func (m PortMapping) Published() bool {
+	return m.HostPort > 0 || m.Auto
+}
+
+// Pairs раскладывает диапазон на пары "порт хоста -> порт контейнера".
+// Для непубликуемого порта порт хоста равен нулю.
+func (m PortMapping) Pairs() [][2]int {
+	var pairs [][2]int
+	for i := 0; i <= m.ContainerEnd-m.ContainerPort; i++ {
+		hostPort := 0
+		if m.HostPort > 0 {
+			hostPort = m.HostPort + i
+		}
+		pairs = append(pairs, [2]int{hostPort, m.ContainerPort + i})
+	}
+	return pairs
+}
+
+func (m PortMapping) String() string {
+	container := rangeString(m.ContainerPort, m.ContainerEnd)
+	if !m.Published() {
+		return fmt.Sprintf("%s/%s", container, m.Protocol)
+	}
+	host := rangeString(m.HostPort, m.HostPortEnd)
+	if m.HostPort == 0 {
+		host = "auto"
+	}
+	if m.HostIP != "" {
+		return fmt.Sprintf("%s:%s->%s/%s", m.HostIP, host, container, m.Protocol)
+	}
+	return fmt.Sprintf("%s->%s/%s", host, container, m.Protocol)
+}
+
+// PortMappings возвращает все пробросы портов сервиса: устаревшую пару
+// port/internalPort (если указана) и затем элементы списка 'ports'.
+func (s *ServiceConfig) PortMappings() ([]PortMapping, error) {
+	return collectPortMappings(s.Port, s.InternalPort, s.Ports)
+}
+
+// PortMappings возвращает все пробросы портов базы данных
+func (d *DBConfig) PortMappings() ([]PortMapping, error) {
+	return collectPortMappings(d.Port, d.InternalPort, d.Ports)
+}
+
+func collectPortMappings(port HostPort, internalPort int, specs []string) ([]PortMapping, error) {
+	var mappings []PortMapping
+	if port == AutoPort && internalPort <= 0 {
+		return nil, errors.New("для 'port: auto' нужно указать 'internalPort'")
+	}
+	if port == AutoPort {
+		mappings = append(mappings, PortMapping{
+			ContainerPort: internalPort,
+			ContainerEnd:  internalPort,
+			Protocol:      "tcp",
+			Auto:          true,
+		})
+	} else if port > 0 && internalPort > 0 {
+		mappings = append(mappings, PortMapping{
+			HostPort:      int(port),
+			HostPortEnd:   int(port),
+			ContainerPort: internalPort,
+			ContainerEnd:  internalPort,
+			Protocol:      "tcp",
+		})
+	}
+
+	parsed, err := ParsePortMappings(specs)
+	if err != nil {
+		return nil, err
+	}
+	return append(mappings, parsed...), nil
+}
+
+func parsePortRange(s string) (int, int, error) {
+	start, end, isRange := strings.Cut(s, "-")
+	first, err := parsePortNumber(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return first, first, nil
+	}
+	last, err := parsePortNumber(end)
+	if err != nil {
+		return 0, 0, err
+	}
+	if last < first {
+		return 0, 0, fmt.Errorf("некорректный диапазон портов '%s'", s)
+	}
+	return first, last, nil
+}
+
+func parsePortNumber(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 || n > 65535 {
+		return 0, fmt.Errorf("некорректный номер порта '%s'", s)
+	}
+	return n, nil
+}
+
+func rangeString(start, end int) string {
+	if end > start {
+		return fmt.Sprintf("%d-%d", start, end)
+	}
+	return strconv.Itoa(start)
+}
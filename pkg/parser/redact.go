@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue заменяет значения переменных окружения в сохраненной конфигурации
+const RedactedValue = "***"
+
+// RedactEnv возвращает forge.yaml, в котором значения переменных окружения
+// сервисов и баз данных заменены на RedactedValue: в них часто передаются
+// пароли и токены, которые не должны попадать в историю запусков. Имена
+// переменных и остальная конфигурация сохраняются.
+func RedactEnv(content []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("ошибка при парсинге конфига: %v", err)
+	}
+	if len(doc.Content) == 0 {
+		return content, nil
+	}
+
+	root := doc.Content[0]
+	for _, section := range []string{"services", "databases"} {
+		nodes := mappingValue(root, section)
+		if nodes == nil || nodes.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, node := range nodes.Content {
+			if env := mappingValue(node, "env"); env != nil && env.Kind == yaml.SequenceNode {
+				for _, item := range env.Content {
+					redactEnvItem(item)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("не удалось собрать конфиг: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("не удалось собрать конфиг: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// redactEnvItem скрывает значение в строке KEY=VALUE. Строка без '=' — это
+// имя переменной, значение которой берется из окружения Docker, ее нечего скрывать.
+func redactEnvItem(item *yaml.Node) {
+	if item.Kind != yaml.ScalarNode {
+		return
+	}
+	if key, _, ok := strings.Cut(item.Value, "="); ok {
+		item.Value = key + "=" + RedactedValue
+		item.Style = 0
+	}
+}
+
+// mappingValue возвращает значение ключа key в узле-отображении или nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package version
+
+import (
+	"strings"
+)
+
+// APIVersion — версия gRPC API между forge и forged. Увеличивается только при
+// несовместимых изменениях; новые RPC и поля добавляются как возможности.
+const APIVersion = 1
+
+// Возможности API, появившиеся после первой версии. Демон сообщает их в ответе
+// на Version, а CLI по ним понимает, какие команды демон не поддержит.
+const (
+	FeatureReconcile     = "reconcile"
+	FeatureHistory       = "history"
+	FeatureAppliedConfig = "applied-config"
+	FeatureStateTransfer = "state-transfer"
+	FeatureDaemonInfo    = "daemon-info"
+	FeatureShutdown      = "shutdown"
+	FeatureDaemonConfig  = "daemon-config"
+	FeatureJobs          = "jobs"
+	FeatureEvents        = "events"
+	FeatureLogQuery      = "log-query"
+	FeatureLogHistory    = "log-history"
+	FeatureLogFields     = "log-fields"
+)
+
+// Features — возможности API, которые поддерживает эта сборка
+var Features = []string{
+	FeatureReconcile,
+	FeatureHistory,
+	FeatureAppliedConfig,
+	FeatureStateTransfer,
+	FeatureDaemonInfo,
+	FeatureShutdown,
+	FeatureDaemonConfig,
+	FeatureJobs,
+	FeatureEvents,
+	FeatureLogQuery,
+	FeatureLogHistory,
+	FeatureLogFields,
+}
+
+// Compatibility — результат сравнения этой сборки с версией другой стороны
+type Compatibility struct {
+	// SameVersion — версии сборок совпадают, предупреждать не о чем
+	SameVersion bool
+	// APICompatible — версии API совпадают. Иначе часть запросов может не работать.
+	APICompatible bool
+	// MissingFeatures — возможности этой сборки, которых нет у другой стороны
+	MissingFeatures []string
+}
+
+// Compare сравнивает эту сборку с версией, версией API и возможностями другой стороны
+func Compare(otherVersion string, otherAPIVersion int, otherFeatures []string) Compatibility {
+	supported := make(map[string]bool, len(otherFeatures))
+	for _, f := range otherFeatures {
+		supported[f] = true
+	}
+
+	c := Compatibility{
+		SameVersion:   strings.TrimPrefix(otherVersion, "v") == strings.TrimPrefix(Version, "v"),
+		APICompatible: otherAPIVersion == APIVersion,
+	}
+	for _, f := range Features {
+		if !supported[f] {
+			c.MissingFeatures = append(c.MissingFeatures, f)
+		}
+	}
+	return c
+}
@@ -0,0 +1,22 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	c := Compare(Version, APIVersion, Features)
+	if !c.SameVersion || !c.APICompatible || len(c.MissingFeatures) != 0 {
+		t.Errorf("сборка несовместима сама с собой: %+v", c)
+	}
+
+	c = Compare("v0.0.9", APIVersion, []string{FeatureReconcile, FeatureHistory})
+	if c.SameVersion || !c.APICompatible {
+		t.Errorf("старая сборка с тем же API: %+v", c)
+	}
+	if len(c.MissingFeatures) != len(Features)-2 || c.MissingFeatures[0] != FeatureAppliedConfig {
+		t.Errorf("недостающие возможности = %v", c.MissingFeatures)
+	}
+
+	if c := Compare("v9.0.0", APIVersion+1, Features); c.APICompatible {
+		t.Errorf("другая версия API не должна считаться совместимой: %+v", c)
+	}
+}